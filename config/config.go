@@ -2,13 +2,47 @@ package config
 
 import (
 	"ApiSmart/pkg/database"
+	"ApiSmart/pkg/email"
+	"ApiSmart/pkg/webpush"
 	"os"
+	"strconv"
+	"strings"
 )
 
 type Config struct {
 	ServerPort string
 	DBConfig   database.DBConfig
 	JWTSecret  string
+	// Cuentas ya registradas que se promueven a administrador al arrancar, mientras no
+	// haya ningún administrador
+	AdminEmails []string
+	SMTPConfig  email.SMTPConfig
+	VAPIDKeys   webpush.VAPIDKeys
+	// Contacto del servidor enviado a los servicios de push (mailto: o https:)
+	VAPIDSubject string
+	// Intervalos sin lecturas tras los que un dispositivo se considera caído
+	DeviceMissedIntervals int
+	// Frecuencia de la revisión de dispositivos sin datos, en segundos
+	DeviceCheckSeconds int
+	// Orígenes aceptados en /ws; vacío solo permite el mismo host y "*" cualquiera
+	WSAllowedOrigins []string
+	// Capacidad de la cola de salida de cada cliente WebSocket
+	WSSendBuffer int
+	// Qué hacer cuando se llena la cola de un cliente: "disconnect" o "drop_oldest"
+	WSSlowConsumerPolicy string
+	// Mensajes recientes que se guardan para reenviar a los clientes que reconectan
+	WSReplayBuffer int
+	// Reparto de los mensajes WebSocket entre instancias: "memory" (una sola) o "redis"
+	WSBackplane string
+	// Canal de Redis del backplane; las instancias de un mismo despliegue deben compartirlo
+	WSBackplaneChannel string
+	// Conexión con Redis, p. ej. redis://:clave@localhost:6379/0
+	RedisURL string
+	// Tiempo que tiene un dispositivo para confirmar un comando de actuador, en segundos
+	ActuatorAckTimeoutSeconds int
+	// Tiempo máximo para cerrar las conexiones y terminar el trabajo pendiente al detener
+	// la API, en segundos
+	ShutdownTimeoutSeconds int
 }
 
 func LoadConfig() *Config {
@@ -21,11 +55,37 @@ func LoadConfig() *Config {
 			Password: getEnv("DB_PASSWORD", "manuel"),
 			DBName:   getEnv("DB_NAME", "sensores_db"),
 		},
-		JWTSecret: getEnv("JWT_SECRET", "secret_key_cambiar_en_produccion"),
+		JWTSecret:   getEnv("JWT_SECRET", "secret_key_cambiar_en_produccion"),
+		AdminEmails: getEnvList("ADMIN_EMAILS"),
+		// Si SMTP_HOST está vacío no se envían correos
+		SMTPConfig: email.SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnv("SMTP_PORT", "587"),
+			Username: getEnv("SMTP_USER", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "smartgarden@localhost"),
+			StartTLS: getEnv("SMTP_STARTTLS", "true") == "true",
+		},
+		// Si no se configuran, las claves VAPID se generan y guardan en la base de datos
+		VAPIDKeys: webpush.VAPIDKeys{
+			PublicKey:  getEnv("VAPID_PUBLIC_KEY", ""),
+			PrivateKey: getEnv("VAPID_PRIVATE_KEY", ""),
+		},
+		VAPIDSubject:              getEnv("VAPID_SUBJECT", "mailto:admin@localhost"),
+		DeviceMissedIntervals:     getEnvInt("DEVICE_MISSED_INTERVALS", 3),
+		DeviceCheckSeconds:        getEnvInt("DEVICE_CHECK_SECONDS", 30),
+		WSAllowedOrigins:          getEnvList("WS_ALLOWED_ORIGINS"),
+		WSSendBuffer:              getEnvInt("WS_SEND_BUFFER", 256),
+		WSSlowConsumerPolicy:      getEnv("WS_SLOW_CONSUMER_POLICY", "disconnect"),
+		WSReplayBuffer:            getEnvInt("WS_REPLAY_BUFFER", 1024),
+		WSBackplane:               getEnv("WS_BACKPLANE", "memory"),
+		WSBackplaneChannel:        getEnv("WS_BACKPLANE_CHANNEL", "smartgarden:ws"),
+		RedisURL:                  getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		ActuatorAckTimeoutSeconds: getEnvInt("ACTUATOR_ACK_TIMEOUT_SECONDS", 30),
+		ShutdownTimeoutSeconds:    getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 15),
 	}
 }
 
-
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -33,3 +93,22 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// getEnvList lee una lista separada por comas, ignorando los elementos vacíos
+func getEnvList(key string) []string {
+	values := []string{}
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
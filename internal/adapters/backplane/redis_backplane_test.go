@@ -0,0 +1,113 @@
+package backplane
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// Las pruebas usan la instancia de Redis indicada en TEST_REDIS_URL, p. ej.
+// redis://localhost:6379/15, y se omiten si no está definida
+func newTestBackplane(t *testing.T, channel string) *redisBackplane {
+	t.Helper()
+
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL no está definida")
+	}
+
+	backplane, err := NewRedisBackplane(url, channel)
+	if err != nil {
+		t.Fatalf("no se pudo conectar con Redis: %v", err)
+	}
+	t.Cleanup(func() { backplane.Close() })
+
+	return backplane.(*redisBackplane)
+}
+
+type received struct {
+	seq     uint64
+	message string
+}
+
+// subscribe suscribe el backplane y espera a que Redis registre la suscripción
+func subscribe(t *testing.T, ctx context.Context, b *redisBackplane, subscribers int64) <-chan received {
+	t.Helper()
+
+	messages := make(chan received, 16)
+	go b.Subscribe(ctx, func(seq uint64, message []byte) {
+		messages <- received{seq, string(message)}
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		counts, err := b.client.PubSubNumSub(ctx, b.channel).Result()
+		if err == nil && counts[b.channel] >= subscribers {
+			return messages
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("la suscripción no se registró a tiempo")
+	return nil
+}
+
+func TestRedisBackplaneDeliversInOrderToAllInstances(t *testing.T) {
+	channel := fmt.Sprintf("test:backplane:%d", time.Now().UnixNano())
+	first := newTestBackplane(t, channel)
+	second := newTestBackplane(t, channel)
+	t.Cleanup(func() { first.client.Del(context.Background(), first.seqKey) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	firstMessages := subscribe(t, ctx, first, 1)
+	secondMessages := subscribe(t, ctx, second, 2)
+
+	// Las dos instancias publican y ambas reciben todo con la misma numeración
+	sent := []string{`{"n":1}`, `{"n":2}`, `{"n":3}`, `{"n":4}`}
+	for i, message := range sent {
+		publisher := first
+		if i%2 == 1 {
+			publisher = second
+		}
+		if err := publisher.Publish(ctx, []byte(message)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	for name, messages := range map[string]<-chan received{"primera": firstMessages, "segunda": secondMessages} {
+		for i, want := range sent {
+			select {
+			case got := <-messages:
+				if got.seq != uint64(i+1) || got.message != want {
+					t.Errorf("%s instancia, mensaje %d = %d:%s, se esperaba %d:%s", name, i, got.seq, got.message, i+1, want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s instancia: no llegó el mensaje %d", name, i)
+			}
+		}
+	}
+}
+
+func TestRedisBackplaneSubscribeStopsOnCancel(t *testing.T) {
+	backplane := newTestBackplane(t, fmt.Sprintf("test:backplane:%d", time.Now().UnixNano()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- backplane.Subscribe(ctx, func(uint64, []byte) {})
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Subscribe = %v, se esperaba context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Subscribe no terminó al cancelar el contexto")
+	}
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Parámetros de la URL que contienen credenciales. EventSource no permite cabeceras, así
+// que /api/stream acepta el token en la URL
+var redactedQueryParams = []string{"token"}
+
+// AccessLogFormatter reproduce el formato del registro de acceso de gin ocultando las
+// credenciales que lleguen en la URL
+func AccessLogFormatter(param gin.LogFormatterParams) string {
+	var statusColor, methodColor, resetColor string
+	if param.IsOutputColor() {
+		statusColor = param.StatusCodeColor()
+		methodColor = param.MethodColor()
+		resetColor = param.ResetColor()
+	}
+
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+
+	return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, param.StatusCode, resetColor,
+		param.Latency,
+		param.ClientIP,
+		methodColor, param.Method, resetColor,
+		redactPath(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+// redactPath sustituye el valor de los parámetros con credenciales
+func redactPath(path string) string {
+	parsed, err := url.Parse(path)
+	if err != nil || parsed.RawQuery == "" {
+		return path
+	}
+
+	query := parsed.Query()
+	redacted := false
+	for _, name := range redactedQueryParams {
+		if query.Has(name) {
+			query.Set(name, "REDACTED")
+			redacted = true
+		}
+	}
+	if !redacted {
+		return path
+	}
+
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type ActuatorHandler struct {
+	actuatorService ports.ActuatorService
+}
+
+func NewActuatorHandler(actuatorService ports.ActuatorService) *ActuatorHandler {
+	return &ActuatorHandler{
+		actuatorService: actuatorService,
+	}
+}
+
+func (h *ActuatorHandler) CreateActuator(c *gin.Context) {
+	var req domain.CreateActuatorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actuator, err := h.actuatorService.CreateActuator(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, actuator)
+}
+
+func (h *ActuatorHandler) DeleteActuator(c *gin.Context) {
+	id, ok := parseActuatorID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.actuatorService.DeleteActuator(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *ActuatorHandler) GetActuators(c *gin.Context) {
+	actuators, err := h.actuatorService.GetActuators(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, actuators)
+}
+
+func (h *ActuatorHandler) GetActuator(c *gin.Context) {
+	id, ok := parseActuatorID(c)
+	if !ok {
+		return
+	}
+
+	actuator, err := h.actuatorService.GetActuator(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(actuatorErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, actuator)
+}
+
+// SendCommand emite un comando; la respuesta es el comando pendiente de confirmar
+func (h *ActuatorHandler) SendCommand(c *gin.Context) {
+	id, ok := parseActuatorID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.ActuatorCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	command, err := h.actuatorService.SendCommand(c.Request.Context(), c.GetUint("userID"), id, req)
+	if err != nil {
+		c.JSON(actuatorErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, command)
+}
+
+func (h *ActuatorHandler) GetCommands(c *gin.Context) {
+	id, ok := parseActuatorID(c)
+	if !ok {
+		return
+	}
+
+	commands, err := h.actuatorService.GetCommands(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(actuatorErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, commands)
+}
+
+// GetPendingCommands devuelve al dispositivo autenticado los comandos que debe ejecutar,
+// para los que no mantienen una conexión WebSocket
+func (h *ActuatorHandler) GetPendingCommands(c *gin.Context) {
+	commands, err := h.actuatorService.PendingCommands(c.Request.Context(), c.GetString("deviceID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, commands)
+}
+
+// AcknowledgeCommand registra el resultado de un comando enviado por el dispositivo
+func (h *ActuatorHandler) AcknowledgeCommand(c *gin.Context) {
+	commandID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de comando inválido"})
+		return
+	}
+
+	var ack domain.CommandAck
+	if err := c.ShouldBindJSON(&ack); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ack.CommandID = uint(commandID)
+
+	command, err := h.actuatorService.AcknowledgeCommand(c.Request.Context(), c.GetString("deviceID"), ack)
+	if err != nil {
+		c.JSON(actuatorErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, command)
+}
+
+func parseActuatorID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de actuador inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// actuatorErrorStatus distingue la falta de permisos y los comandos ya cerrados del resto de errores
+func actuatorErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, domain.ErrGardenAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrCommandExpired):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
+}
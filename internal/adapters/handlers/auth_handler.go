@@ -3,7 +3,7 @@ package handlers
 
 import (
 	"net/http"
-	_ "strconv"
+	"strconv"
 	"strings"
 
 	"ApiSmart/internal/core/domain"
@@ -80,3 +80,64 @@ func (h *AuthHandler) AuthMiddleware() gin.HandlerFunc {
 	}
 }
 
+// AdminMiddleware restringe la ruta a administradores; debe ir después de AuthMiddleware
+func (h *AuthHandler) AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := h.authService.GetUser(c.Request.Context(), c.GetUint("userID"))
+		if err != nil || user.Role != domain.RoleAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "se requieren permisos de administrador"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (h *AuthHandler) GetProfile(c *gin.Context) {
+	user, err := h.authService.GetUser(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *AuthHandler) UpdatePreferences(c *gin.Context) {
+	var req domain.UpdateUserPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.authService.UpdatePreferences(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// SetUserRole cambia el rol de un usuario; solo para administradores
+func (h *AuthHandler) SetUserRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	var req domain.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.authService.SetRole(c.Request.Context(), uint(userID), req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
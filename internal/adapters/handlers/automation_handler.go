@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type AutomationHandler struct {
+	automationService ports.AutomationService
+}
+
+func NewAutomationHandler(automationService ports.AutomationService) *AutomationHandler {
+	return &AutomationHandler{
+		automationService: automationService,
+	}
+}
+
+func (h *AutomationHandler) CreateAutomation(c *gin.Context) {
+	var req domain.AutomationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	automation, err := h.automationService.CreateAutomation(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(automationErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, automation)
+}
+
+func (h *AutomationHandler) GetAutomations(c *gin.Context) {
+	automations, err := h.automationService.GetAutomations(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, automations)
+}
+
+func (h *AutomationHandler) GetAutomation(c *gin.Context) {
+	id, ok := parseAutomationID(c)
+	if !ok {
+		return
+	}
+
+	automation, err := h.automationService.GetAutomation(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(automationErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, automation)
+}
+
+func (h *AutomationHandler) UpdateAutomation(c *gin.Context) {
+	id, ok := parseAutomationID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.AutomationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	automation, err := h.automationService.UpdateAutomation(c.Request.Context(), c.GetUint("userID"), id, req)
+	if err != nil {
+		c.JSON(automationErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, automation)
+}
+
+// SetEnabled activa o desactiva la automatización sin cambiar su definición
+func (h *AutomationHandler) SetEnabled(c *gin.Context) {
+	id, ok := parseAutomationID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.SetAutomationEnabledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	automation, err := h.automationService.SetEnabled(c.Request.Context(), c.GetUint("userID"), id, *req.Enabled)
+	if err != nil {
+		c.JSON(automationErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, automation)
+}
+
+func (h *AutomationHandler) DeleteAutomation(c *gin.Context) {
+	id, ok := parseAutomationID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.automationService.DeleteAutomation(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		c.JSON(automationErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetExecutions devuelve el historial de ejecuciones, de la más reciente a la más antigua
+func (h *AutomationHandler) GetExecutions(c *gin.Context) {
+	id, ok := parseAutomationID(c)
+	if !ok {
+		return
+	}
+
+	executions, err := h.automationService.GetExecutions(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(automationErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, executions)
+}
+
+func parseAutomationID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de automatización inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// automationErrorStatus distingue la falta de permisos del resto de errores
+func automationErrorStatus(err error, fallback int) int {
+	if errors.Is(err, domain.ErrGardenAccessDenied) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+// Pasos del registro de un lazo de control devueltos por defecto
+const defaultControlLoopSampleLimit = 200
+
+type ControlLoopHandler struct {
+	loopService ports.ControlLoopService
+}
+
+func NewControlLoopHandler(loopService ports.ControlLoopService) *ControlLoopHandler {
+	return &ControlLoopHandler{
+		loopService: loopService,
+	}
+}
+
+func (h *ControlLoopHandler) CreateControlLoop(c *gin.Context) {
+	var req domain.ControlLoopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loop, err := h.loopService.CreateControlLoop(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(controlLoopErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, loop)
+}
+
+func (h *ControlLoopHandler) GetControlLoops(c *gin.Context) {
+	loops, err := h.loopService.GetControlLoops(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loops)
+}
+
+func (h *ControlLoopHandler) GetControlLoop(c *gin.Context) {
+	id, ok := parseControlLoopID(c)
+	if !ok {
+		return
+	}
+
+	loop, err := h.loopService.GetControlLoop(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(controlLoopErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loop)
+}
+
+// UpdateControlLoop sustituye la definición del lazo y reinicia el controlador
+func (h *ControlLoopHandler) UpdateControlLoop(c *gin.Context) {
+	id, ok := parseControlLoopID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.ControlLoopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loop, err := h.loopService.UpdateControlLoop(c.Request.Context(), c.GetUint("userID"), id, req)
+	if err != nil {
+		c.JSON(controlLoopErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loop)
+}
+
+// TuneControlLoop cambia el objetivo y las ganancias indicadas sin reiniciar el controlador
+func (h *ControlLoopHandler) TuneControlLoop(c *gin.Context) {
+	id, ok := parseControlLoopID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.TuneControlLoopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loop, err := h.loopService.TuneControlLoop(c.Request.Context(), c.GetUint("userID"), id, req)
+	if err != nil {
+		c.JSON(controlLoopErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loop)
+}
+
+func (h *ControlLoopHandler) SetEnabled(c *gin.Context) {
+	id, ok := parseControlLoopID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.SetControlLoopEnabledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	loop, err := h.loopService.SetEnabled(c.Request.Context(), c.GetUint("userID"), id, *req.Enabled)
+	if err != nil {
+		c.JSON(controlLoopErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loop)
+}
+
+func (h *ControlLoopHandler) DeleteControlLoop(c *gin.Context) {
+	id, ok := parseControlLoopID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.loopService.DeleteControlLoop(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		c.JSON(controlLoopErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetSamples devuelve el registro del controlador para ajustar las ganancias; ?limit indica
+// cuántos pasos
+func (h *ControlLoopHandler) GetSamples(c *gin.Context) {
+	id, ok := parseControlLoopID(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultControlLoopSampleLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "límite inválido"})
+			return
+		}
+		limit = parsed
+	}
+
+	samples, err := h.loopService.GetSamples(c.Request.Context(), c.GetUint("userID"), id, limit)
+	if err != nil {
+		c.JSON(controlLoopErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, samples)
+}
+
+func parseControlLoopID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de lazo de control inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// controlLoopErrorStatus distingue la falta de permisos del resto de errores
+func controlLoopErrorStatus(err error, fallback int) int {
+	if errors.Is(err, domain.ErrGardenAccessDenied) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
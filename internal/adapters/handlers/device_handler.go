@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceHandler struct {
+	deviceService ports.DeviceService
+}
+
+func NewDeviceHandler(deviceService ports.DeviceService) *DeviceHandler {
+	return &DeviceHandler{
+		deviceService: deviceService,
+	}
+}
+
+func (h *DeviceHandler) GetDevices(c *gin.Context) {
+	devices, err := h.deviceService.GetDevices(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+func (h *DeviceHandler) GetDevice(c *gin.Context) {
+	device, err := h.deviceService.GetDevice(c.Request.Context(), c.GetUint("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(deviceErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, device)
+}
+
+func (h *DeviceHandler) UpdateDevice(c *gin.Context) {
+	var req domain.UpdateDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	device, err := h.deviceService.UpdateDevice(c.Request.Context(), c.GetUint("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(deviceErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, device)
+}
+
+// deviceErrorStatus distingue la falta de permisos del resto de errores
+func deviceErrorStatus(err error, fallback int) int {
+	if errors.Is(err, domain.ErrGardenAccessDenied) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
+
+// GenerateToken crea el token con el que el dispositivo se conecta a /ws
+func (h *DeviceHandler) GenerateToken(c *gin.Context) {
+	token, err := h.deviceService.GenerateToken(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"device_id": c.Param("id"), "token": token})
+}
+
+// DeviceAuthMiddleware autentica a los dispositivos con su ID y token, igual que en /ws
+func (h *DeviceHandler) DeviceAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := firstNonEmpty(c.GetHeader("X-Device-ID"), c.Query("device_id"))
+		// El token solo se acepta en la cabecera para que no quede en los registros de acceso
+		token := c.GetHeader("X-Device-Token")
+
+		if err := h.deviceService.AuthenticateDevice(c.Request.Context(), deviceID, token); err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set("deviceID", deviceID)
+		c.Next()
+	}
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceTwinHandler struct {
+	twinService ports.DeviceTwinService
+}
+
+func NewDeviceTwinHandler(twinService ports.DeviceTwinService) *DeviceTwinHandler {
+	return &DeviceTwinHandler{
+		twinService: twinService,
+	}
+}
+
+// GetTwin devuelve la configuración deseada y comunicada del dispositivo y lo que falta por aplicar
+func (h *DeviceTwinHandler) GetTwin(c *gin.Context) {
+	twin, err := h.twinService.GetTwin(c.Request.Context(), c.GetUint("userID"), c.Param("id"))
+	if err != nil {
+		c.JSON(twinErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, twin)
+}
+
+// UpdateDesired reemplaza la configuración deseada; responde 409 si la versión no es la actual
+func (h *DeviceTwinHandler) UpdateDesired(c *gin.Context) {
+	var req domain.UpdateDesiredConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	twin, err := h.twinService.UpdateDesired(c.Request.Context(), c.GetUint("userID"), c.Param("id"), req)
+	if err != nil {
+		c.JSON(twinErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, twin)
+}
+
+// GetPendingDelta devuelve al dispositivo autenticado la configuración que debe aplicar,
+// para los que no mantienen una conexión WebSocket
+func (h *DeviceTwinHandler) GetPendingDelta(c *gin.Context) {
+	delta, err := h.twinService.PendingDelta(c.Request.Context(), c.GetString("deviceID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, delta)
+}
+
+// ReportConfig registra la configuración aplicada en el dispositivo autenticado
+func (h *DeviceTwinHandler) ReportConfig(c *gin.Context) {
+	var req domain.ReportConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	twin, err := h.twinService.ReportConfig(c.Request.Context(), c.GetString("deviceID"), req)
+	if err != nil {
+		c.JSON(twinErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, twin.TwinDelta())
+}
+
+// twinErrorStatus distingue la falta de permisos, las versiones obsoletas y los informes
+// inválidos del resto de errores
+func twinErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, domain.ErrGardenAccessDenied):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrTwinVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrTwinReportInvalid):
+		return http.StatusBadRequest
+	default:
+		return fallback
+	}
+}
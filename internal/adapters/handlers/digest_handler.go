@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type DigestHandler struct {
+	digestService ports.DigestService
+}
+
+func NewDigestHandler(digestService ports.DigestService) *DigestHandler {
+	return &DigestHandler{
+		digestService: digestService,
+	}
+}
+
+func (h *DigestHandler) GetSubscription(c *gin.Context) {
+	sub, err := h.digestService.GetSubscription(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *DigestHandler) UpdateSubscription(c *gin.Context) {
+	var req domain.UpdateDigestSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.digestService.UpdateSubscription(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sub)
+}
+
+func (h *DigestHandler) DeleteSubscription(c *gin.Context) {
+	if err := h.digestService.DeleteSubscription(c.Request.Context(), c.GetUint("userID")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suscripción a resúmenes eliminada"})
+}
+
+// PreviewDigest genera el resumen del periodo que termina ahora sin enviarlo
+func (h *DigestHandler) PreviewDigest(c *gin.Context) {
+	frequency := c.DefaultQuery("frequency", domain.DigestDaily)
+
+	var period time.Duration
+	switch frequency {
+	case domain.DigestHourly:
+		period = time.Hour
+	case domain.DigestDaily:
+		period = 24 * time.Hour
+	case domain.DigestWeekly:
+		period = 7 * 24 * time.Hour
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "frecuencia inválida"})
+		return
+	}
+
+	now := time.Now()
+	digest, err := h.digestService.BuildDigest(c.Request.Context(), c.GetUint("userID"), frequency, now.Add(-period), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, digest)
+}
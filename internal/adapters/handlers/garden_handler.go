@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type GardenHandler struct {
+	gardenService ports.GardenService
+}
+
+func NewGardenHandler(gardenService ports.GardenService) *GardenHandler {
+	return &GardenHandler{
+		gardenService: gardenService,
+	}
+}
+
+func (h *GardenHandler) CreateGarden(c *gin.Context) {
+	var req domain.CreateGardenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	garden, err := h.gardenService.CreateGarden(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, garden)
+}
+
+func (h *GardenHandler) GetGardens(c *gin.Context) {
+	gardens, err := h.gardenService.GetGardens(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gardens)
+}
+
+func (h *GardenHandler) GetGarden(c *gin.Context) {
+	gardenID, ok := parseGardenID(c)
+	if !ok {
+		return
+	}
+
+	garden, err := h.gardenService.GetGarden(c.Request.Context(), c.GetUint("userID"), gardenID)
+	if err != nil {
+		c.JSON(gardenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, garden)
+}
+
+// UpdateGarden cambia el nombre, la zona horaria y la ubicación; solo el propietario
+func (h *GardenHandler) UpdateGarden(c *gin.Context) {
+	gardenID, ok := parseGardenID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.UpdateGardenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	garden, err := h.gardenService.UpdateGarden(c.Request.Context(), c.GetUint("userID"), gardenID, req)
+	if err != nil {
+		c.JSON(gardenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, garden)
+}
+
+func (h *GardenHandler) GetMembers(c *gin.Context) {
+	gardenID, ok := parseGardenID(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.gardenService.GetMembers(c.Request.Context(), c.GetUint("userID"), gardenID)
+	if err != nil {
+		c.JSON(gardenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *GardenHandler) AddMember(c *gin.Context) {
+	gardenID, ok := parseGardenID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.AddGardenMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.gardenService.AddMember(c.Request.Context(), c.GetUint("userID"), gardenID, req); err != nil {
+		c.JSON(gardenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Miembro añadido"})
+}
+
+func (h *GardenHandler) RemoveMember(c *gin.Context) {
+	gardenID, ok := parseGardenID(c)
+	if !ok {
+		return
+	}
+
+	memberID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+
+	if err := h.gardenService.RemoveMember(c.Request.Context(), c.GetUint("userID"), gardenID, uint(memberID)); err != nil {
+		c.JSON(gardenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Miembro eliminado"})
+}
+
+func (h *GardenHandler) AssignDevice(c *gin.Context) {
+	gardenID, ok := parseGardenID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.gardenService.AssignDevice(c.Request.Context(), c.GetUint("userID"), gardenID, c.Param("deviceId")); err != nil {
+		c.JSON(gardenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dispositivo asignado al jardín"})
+}
+
+func (h *GardenHandler) UnassignDevice(c *gin.Context) {
+	gardenID, ok := parseGardenID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.gardenService.UnassignDevice(c.Request.Context(), c.GetUint("userID"), gardenID, c.Param("deviceId")); err != nil {
+		c.JSON(gardenErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Dispositivo retirado del jardín"})
+}
+
+// parseGardenID lee el ID del jardín de la ruta; si es inválido ya ha respondido
+func parseGardenID(c *gin.Context) (uint, bool) {
+	gardenID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de jardín inválido"})
+		return 0, false
+	}
+	return uint(gardenID), true
+}
+
+// gardenErrorStatus distingue la falta de permisos del resto de errores
+func gardenErrorStatus(err error) int {
+	if errors.Is(err, domain.ErrGardenAccessDenied) {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type MessageHandler struct {
+	messageService ports.MessageService
+}
+
+func NewMessageHandler(messageService ports.MessageService) *MessageHandler {
+	return &MessageHandler{
+		messageService: messageService,
+	}
+}
+
+func (h *MessageHandler) GetTemplates(c *gin.Context) {
+	templates, err := h.messageService.GetTemplates(c.Param("locale"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, templates)
+}
+
+func (h *MessageHandler) UpdateTemplate(c *gin.Context) {
+	var req domain.UpdateMessageTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tmpl, err := h.messageService.UpdateTemplate(c.Request.Context(), c.GetUint("userID"), c.Param("locale"), c.Param("key"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tmpl)
+}
+
+func (h *MessageHandler) ResetTemplate(c *gin.Context) {
+	if err := h.messageService.ResetTemplate(c.Request.Context(), c.Param("locale"), c.Param("key")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Plantilla restablecida"})
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	notificationService ports.NotificationService
+}
+
+func NewNotificationHandler(notificationService ports.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID := c.GetUint("userID")
+
+	prefs, err := h.notificationService.GetPreferences(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, prefs)
+}
+
+func (h *NotificationHandler) UpdatePreference(c *gin.Context) {
+	var req domain.UpdateNotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID := c.GetUint("userID")
+
+	pref, err := h.notificationService.UpdatePreference(c.Request.Context(), userID, c.Param("channel"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
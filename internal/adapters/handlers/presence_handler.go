@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+// Eventos de presencia devueltos por defecto
+const defaultPresenceLimit = 100
+
+type PresenceHandler struct {
+	presenceService ports.PresenceService
+	gardenService   ports.GardenService
+}
+
+func NewPresenceHandler(presenceService ports.PresenceService, gardenService ports.GardenService) *PresenceHandler {
+	return &PresenceHandler{
+		presenceService: presenceService,
+		gardenService:   gardenService,
+	}
+}
+
+// GetSystemStatus devuelve el estado del sistema con los dispositivos visibles para el usuario
+func (h *PresenceHandler) GetSystemStatus(c *gin.Context) {
+	access, err := h.gardenService.DeviceAccess(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.presenceService.GetStatus(c.Request.Context(), access)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// GetDevicePresence devuelve el historial de conexiones y presencia de un dispositivo
+func (h *PresenceHandler) GetDevicePresence(c *gin.Context) {
+	deviceID := c.Param("id")
+
+	access, err := h.gardenService.DeviceAccess(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !access.Allows(deviceID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": domain.ErrGardenAccessDenied.Error()})
+		return
+	}
+
+	limit := defaultPresenceLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "límite inválido"})
+			return
+		}
+		limit = parsed
+	}
+
+	events, err := h.presenceService.GetHistory(c.Request.Context(), deviceID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type PushHandler struct {
+	pushService ports.PushService
+}
+
+func NewPushHandler(pushService ports.PushService) *PushHandler {
+	return &PushHandler{
+		pushService: pushService,
+	}
+}
+
+func (h *PushHandler) GetVAPIDPublicKey(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"public_key": h.pushService.VAPIDPublicKey()})
+}
+
+func (h *PushHandler) Subscribe(c *gin.Context) {
+	var req domain.PushSubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.pushService.Subscribe(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(pushErrorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, sub)
+}
+
+func (h *PushHandler) Unsubscribe(c *gin.Context) {
+	var req domain.PushUnsubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.pushService.Unsubscribe(c.Request.Context(), c.GetUint("userID"), req.Endpoint); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Suscripción eliminada"})
+}
+
+// pushErrorStatus distingue los endpoints inválidos y los de otros usuarios del resto de
+// errores
+func pushErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, domain.ErrPushEndpointInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrPushEndpointTaken):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleHandler struct {
+	scheduleService ports.ScheduleService
+}
+
+func NewScheduleHandler(scheduleService ports.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduleService: scheduleService,
+	}
+}
+
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	var req domain.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.scheduleService.CreateSchedule(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+func (h *ScheduleHandler) GetSchedules(c *gin.Context) {
+	schedules, err := h.scheduleService.GetSchedules(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.scheduleService.GetSchedule(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.ScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.scheduleService.UpdateSchedule(c.Request.Context(), c.GetUint("userID"), id, req)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// SetEnabled activa o desactiva la programación sin cambiar su definición
+func (h *ScheduleHandler) SetEnabled(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	var req domain.SetScheduleEnabledRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.scheduleService.SetEnabled(c.Request.Context(), c.GetUint("userID"), id, *req.Enabled)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.scheduleService.DeleteSchedule(c.Request.Context(), c.GetUint("userID"), id); err != nil {
+		c.JSON(scheduleErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetRuns devuelve el historial de ejecuciones, de la más reciente a la más antigua
+func (h *ScheduleHandler) GetRuns(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	runs, err := h.scheduleService.GetRuns(c.Request.Context(), c.GetUint("userID"), id)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// Preview lista las próximas ejecuciones; ?count indica cuántas (10 por defecto)
+func (h *ScheduleHandler) Preview(c *gin.Context) {
+	id, ok := parseScheduleID(c)
+	if !ok {
+		return
+	}
+
+	count := 0
+	if raw := c.Query("count"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "count debe ser un entero positivo"})
+			return
+		}
+		count = parsed
+	}
+
+	preview, err := h.scheduleService.Preview(c.Request.Context(), c.GetUint("userID"), id, count)
+	if err != nil {
+		c.JSON(scheduleErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, preview)
+}
+
+func parseScheduleID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de programación inválido"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// scheduleErrorStatus distingue la falta de permisos del resto de errores
+func scheduleErrorStatus(err error, fallback int) int {
+	if errors.Is(err, domain.ErrGardenAccessDenied) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
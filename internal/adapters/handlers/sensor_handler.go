@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"ApiSmart/internal/core/domain"
 	"ApiSmart/internal/core/ports"
@@ -10,12 +12,14 @@ import (
 )
 
 type SensorHandler struct {
-	sensorService ports.SensorService
+	sensorService  ports.SensorService
+	messageService ports.MessageService
 }
 
-func NewSensorHandler(sensorService ports.SensorService) *SensorHandler {
+func NewSensorHandler(sensorService ports.SensorService, messageService ports.MessageService) *SensorHandler {
 	return &SensorHandler{
-		sensorService: sensorService,
+		sensorService:  sensorService,
+		messageService: messageService,
 	}
 }
 
@@ -26,6 +30,9 @@ func (h *SensorHandler) CreateSensorData(c *gin.Context) {
 		return
 	}
 
+	// El dispositivo solo envía lecturas en su nombre
+	data.DeviceID = c.GetString("deviceID")
+
 	// Guardar datos del sensor y generar alertas si es necesario
 	if err := h.sensorService.SaveSensorData(c.Request.Context(), &data); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -59,23 +66,82 @@ func (h *SensorHandler) GetLatestSensorData(c *gin.Context) {
 }
 
 func (h *SensorHandler) GetAlerts(c *gin.Context) {
+	filter := domain.AlertFilter{
+		SensorType: c.Query("sensor_type"),
+		DeviceID:   c.Query("device_id"),
+		Severity:   c.Query("severity"),
+		State:      c.Query("state"),
+	}
+
 	// Filtrar alertas por estado (leídas/no leídas)
-	var isRead *bool
 	isReadParam := c.Query("is_read")
 	if isReadParam != "" {
 		isReadBool, err := strconv.ParseBool(isReadParam)
 		if err == nil {
-			isRead = &isReadBool
+			filter.IsRead = &isReadBool
+		}
+	}
+
+	if silencedParam := c.Query("silenced"); silencedParam != "" {
+		silenced, err := strconv.ParseBool(silencedParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parámetro silenced inválido"})
+			return
+		}
+		filter.Silenced = &silenced
+	}
+
+	var err error
+	if filter.From, filter.To, err = parseTimeRange(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if cursor := c.Query("cursor"); cursor != "" {
+		value, err := strconv.ParseUint(cursor, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cursor inválido"})
+			return
+		}
+		filter.Cursor = uint(value)
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "límite inválido"})
+			return
 		}
+		filter.Limit = value
 	}
 
-	alerts, err := h.sensorService.GetAlerts(c.Request.Context(), isRead)
+	page, err := h.sensorService.GetAlerts(c.Request.Context(), c.GetUint("userID"), filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, alerts)
+	// Traducir los mensajes al idioma del usuario
+	locale := h.messageService.ResolveLocale(c.Request.Context(), c.GetUint("userID"), c.GetHeader("Accept-Language"))
+	h.messageService.LocalizeAlerts(locale, page.Alerts)
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *SensorHandler) GetAlertStats(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := h.sensorService.GetAlertStats(c.Request.Context(), c.GetUint("userID"), from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
 }
 
 func (h *SensorHandler) MarkAlertAsRead(c *gin.Context) {
@@ -85,10 +151,71 @@ func (h *SensorHandler) MarkAlertAsRead(c *gin.Context) {
 		return
 	}
 
-	if err := h.sensorService.MarkAlertAsRead(c.Request.Context(), uint(alertID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.sensorService.MarkAlertAsRead(c.Request.Context(), c.GetUint("userID"), uint(alertID)); err != nil {
+		c.JSON(alertErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Alerta marcada como leída"})
 }
+
+func (h *SensorHandler) AcknowledgeAlert(c *gin.Context) {
+	alertID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de alerta inválido"})
+		return
+	}
+
+	if err := h.sensorService.AcknowledgeAlert(c.Request.Context(), c.GetUint("userID"), uint(alertID)); err != nil {
+		c.JSON(alertErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alerta reconocida"})
+}
+
+func (h *SensorHandler) ResolveAlert(c *gin.Context) {
+	alertID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de alerta inválido"})
+		return
+	}
+
+	if err := h.sensorService.ResolveAlert(c.Request.Context(), c.GetUint("userID"), uint(alertID)); err != nil {
+		c.JSON(alertErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Alerta resuelta"})
+}
+
+// alertErrorStatus distingue la falta de permisos del resto de errores
+func alertErrorStatus(err error, fallback int) int {
+	if errors.Is(err, domain.ErrGardenAccessDenied) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
+
+// parseTimeRange lee los parámetros opcionales from/to en formato RFC3339
+func parseTimeRange(c *gin.Context) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+
+	if value := c.Query("from"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, nil, errors.New("parámetro from inválido, se espera RFC3339")
+		}
+		from = &t
+	}
+
+	if value := c.Query("to"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, nil, errors.New("parámetro to inválido, se espera RFC3339")
+		}
+		to = &t
+	}
+
+	return from, to, nil
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type SilenceHandler struct {
+	silenceService ports.SilenceService
+}
+
+func NewSilenceHandler(silenceService ports.SilenceService) *SilenceHandler {
+	return &SilenceHandler{
+		silenceService: silenceService,
+	}
+}
+
+func (h *SilenceHandler) CreateSilence(c *gin.Context) {
+	var req domain.CreateSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	silence, err := h.silenceService.CreateSilence(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(silenceErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, silence)
+}
+
+func (h *SilenceHandler) GetSilences(c *gin.Context) {
+	// Por defecto solo se devuelven los silencios vigentes
+	activeOnly := true
+	if value := c.Query("active"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err == nil {
+			activeOnly = parsed
+		}
+	}
+
+	silences, err := h.silenceService.GetSilences(c.Request.Context(), c.GetUint("userID"), activeOnly)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, silences)
+}
+
+func (h *SilenceHandler) ExpireSilence(c *gin.Context) {
+	silenceID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de silencio inválido"})
+		return
+	}
+
+	if err := h.silenceService.ExpireSilence(c.Request.Context(), c.GetUint("userID"), uint(silenceID)); err != nil {
+		c.JSON(silenceErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Silencio expirado"})
+}
+
+func (h *SilenceHandler) CreateMaintenanceWindow(c *gin.Context) {
+	var req domain.CreateMaintenanceWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	window, err := h.silenceService.CreateMaintenanceWindow(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(silenceErrorStatus(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, window)
+}
+
+func (h *SilenceHandler) GetMaintenanceWindows(c *gin.Context) {
+	windows, err := h.silenceService.GetMaintenanceWindows(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, windows)
+}
+
+func (h *SilenceHandler) DeleteMaintenanceWindow(c *gin.Context) {
+	windowID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de ventana inválido"})
+		return
+	}
+
+	if err := h.silenceService.DeleteMaintenanceWindow(c.Request.Context(), c.GetUint("userID"), uint(windowID)); err != nil {
+		c.JSON(silenceErrorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ventana de mantenimiento eliminada"})
+}
+
+// silenceErrorStatus distingue la falta de permisos del resto de errores
+func silenceErrorStatus(err error, fallback int) int {
+	if errors.Is(err, domain.ErrGardenAccessDenied) {
+		return http.StatusForbidden
+	}
+	return fallback
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ApiSmart/internal/core/ports"
+	wsService "ApiSmart/internal/core/services/websocket"
+	"github.com/gin-gonic/gin"
+)
+
+// Frecuencia de los comentarios que mantienen abierta la conexión en los proxies
+const sseKeepAlive = 30 * time.Second
+
+type SSEHandler struct {
+	authService ports.AuthService
+	server      *wsService.Server
+}
+
+func NewSSEHandler(authService ports.AuthService, server *wsService.Server) *SSEHandler {
+	return &SSEHandler{
+		authService: authService,
+		server:      server,
+	}
+}
+
+// Stream envía los mensajes del servidor WebSocket como Server-Sent Events. Como
+// EventSource no permite cabeceras, el token también se acepta en ?token=, que se oculta
+// en el registro de acceso. Los temas se indican en ?topics= separados por comas y
+// Last-Event-ID reanuda la secuencia
+func (h *SSEHandler) Stream(c *gin.Context) {
+	token := c.Query("token")
+	if parts := strings.Split(c.GetHeader("Authorization"), " "); len(parts) == 2 && parts[0] == "Bearer" {
+		token = parts[1]
+	}
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "token de autenticación no proporcionado"})
+		return
+	}
+
+	userID, err := h.authService.ValidateToken(token)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "token inválido o expirado"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastSeq *uint64
+	if lastEventID != "" {
+		seq, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID inválido"})
+			return
+		}
+		lastSeq = &seq
+	}
+
+	topics := []string{}
+	for _, topic := range strings.Split(c.Query("topics"), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+
+	stream, err := h.server.OpenStream(userID, lastSeq, topics)
+	if errors.Is(err, wsService.ErrServerClosed) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer stream.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+
+		case message, ok := <-stream.Messages():
+			if !ok {
+				return
+			}
+			if err := writeSSEMessage(c.Writer, message); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeSSEMessage escribe un mensaje como evento SSE sin nombre, para que los clientes lo
+// traten igual que los de WebSocket. Su número de secuencia se usa como ID para reanudar
+// con Last-Event-ID
+func writeSSEMessage(w gin.ResponseWriter, message []byte) error {
+	var header struct {
+		Seq *uint64 `json:"seq"`
+	}
+	json.Unmarshal(message, &header)
+
+	var event strings.Builder
+	if header.Seq != nil {
+		fmt.Fprintf(&event, "id: %d\n", *header.Seq)
+	}
+	fmt.Fprintf(&event, "data: %s\n\n", message)
+
+	_, err := fmt.Fprint(w, event.String())
+	return err
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService ports.WebhookService
+}
+
+func NewWebhookHandler(webhookService ports.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req domain.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), c.GetUint("userID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// El secreto solo se muestra en la respuesta de creación
+	c.JSON(http.StatusCreated, gin.H{
+		"webhook": webhook,
+		"secret":  webhook.Secret,
+	})
+}
+
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.GetWebhooks(c.Request.Context(), c.GetUint("userID"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de webhook inválido"})
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), c.GetUint("userID"), uint(webhookID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook eliminado"})
+}
+
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de webhook inválido"})
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(c.Request.Context(), c.GetUint("userID"), uint(webhookID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	deliveryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ID de entrega inválido"})
+		return
+	}
+
+	if err := h.webhookService.Redeliver(c.Request.Context(), c.GetUint("userID"), uint(deliveryID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Reenvío programado"})
+}
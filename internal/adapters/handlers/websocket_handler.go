@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ApiSmart/internal/core/ports"
+	wsService "ApiSmart/internal/core/services/websocket"
+	"github.com/gin-gonic/gin"
+	gorillaWs "github.com/gorilla/websocket"
+)
+
+// Subprotocolo con el que el cliente envía el token: "bearer, <token>"
+const bearerSubprotocol = "bearer"
+
+// Tiempo que se espera el mensaje de autenticación cuando el token no llega en la conexión
+const wsAuthTimeout = 10 * time.Second
+
+// Primer mensaje del cliente cuando no envía el token como subprotocolo
+type wsAuthMessage struct {
+	Type  string `json:"type"` // "auth"
+	Token string `json:"token"`
+}
+
+type WebSocketHandler struct {
+	authService   ports.AuthService
+	deviceService ports.DeviceService
+	server        *wsService.Server
+	upgrader      gorillaWs.Upgrader
+}
+
+// NewWebSocketHandler crea el manejador de /ws. Sin orígenes configurados solo se aceptan
+// conexiones del mismo host; "*" permite cualquier origen
+func NewWebSocketHandler(authService ports.AuthService, deviceService ports.DeviceService, server *wsService.Server, allowedOrigins []string) *WebSocketHandler {
+	return &WebSocketHandler{
+		authService:   authService,
+		deviceService: deviceService,
+		server:        server,
+		upgrader: gorillaWs.Upgrader{
+			CheckOrigin: checkOrigin(allowedOrigins),
+		},
+	}
+}
+
+func (h *WebSocketHandler) HandleWebSocket(c *gin.Context) {
+	// Los dispositivos se identifican con su ID y token en lugar de un JWT
+	if deviceID := firstNonEmpty(c.GetHeader("X-Device-ID"), c.Query("device_id")); deviceID != "" {
+		h.handleDevice(c, deviceID)
+		return
+	}
+
+	var responseHeader http.Header
+
+	// El token llega como subprotocolo o en el primer mensaje; nunca en la URL, que
+	// queda en los registros de acceso
+	var token string
+	protocols := gorillaWs.Subprotocols(c.Request)
+	if len(protocols) == 2 && protocols[0] == bearerSubprotocol {
+		token = protocols[1]
+		responseHeader = http.Header{"Sec-Websocket-Protocol": {bearerSubprotocol}}
+	}
+
+	// Último mensaje recibido antes de reconectar
+	var lastSeq *uint64
+	if value := c.Query("last_seq"); value != "" {
+		seq, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "last_seq inválido"})
+			return
+		}
+		lastSeq = &seq
+	}
+
+	var userID uint
+	if token != "" {
+		id, err := h.authService.ValidateToken(token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token inválido o expirado"})
+			return
+		}
+		userID = id
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, responseHeader)
+	if err != nil {
+		log.Printf("Error al actualizar conexión: %v", err)
+		return
+	}
+
+	// Si no llegó en la conexión, el token debe ser el primer mensaje
+	if userID == 0 {
+		userID, err = h.authenticateFirstMessage(conn)
+		if err != nil {
+			conn.WriteControl(
+				gorillaWs.CloseMessage,
+				gorillaWs.FormatCloseMessage(gorillaWs.ClosePolicyViolation, "token inválido o expirado"),
+				time.Now().Add(time.Second),
+			)
+			conn.Close()
+			return
+		}
+	}
+
+	h.server.HandleWebSocket(conn, userID, lastSeq)
+}
+
+// handleDevice valida el token del dispositivo y le abre una conexión para enviar lecturas
+func (h *WebSocketHandler) handleDevice(c *gin.Context, deviceID string) {
+	token := c.GetHeader("X-Device-Token")
+	if err := h.deviceService.AuthenticateDevice(c.Request.Context(), deviceID, token); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("Error al actualizar conexión: %v", err)
+		return
+	}
+
+	h.server.HandleDeviceWebSocket(conn, deviceID)
+}
+
+// authenticateFirstMessage espera un mensaje {"type":"auth","token":"..."} y valida el token
+func (h *WebSocketHandler) authenticateFirstMessage(conn *gorillaWs.Conn) (uint, error) {
+	conn.SetReadDeadline(time.Now().Add(wsAuthTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		return 0, err
+	}
+
+	var auth wsAuthMessage
+	if err := json.Unmarshal(message, &auth); err != nil {
+		return 0, err
+	}
+
+	return h.authService.ValidateToken(auth.Token)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// checkOrigin construye la comprobación de origen del upgrader
+func checkOrigin(allowedOrigins []string) func(r *http.Request) bool {
+	if len(allowedOrigins) == 0 {
+		// Comprobación predeterminada de gorilla: mismo host
+		return nil
+	}
+
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			// Clientes que no son navegadores, como las placas
+			return true
+		}
+		for _, allowed := range allowedOrigins {
+			if allowed == "*" || strings.EqualFold(allowed, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// GetMetrics devuelve las métricas del servidor WebSocket
+func (h *WebSocketHandler) GetMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, h.server.Metrics())
+}
@@ -0,0 +1,370 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type actuatorRepository struct {
+	db *sql.DB
+}
+
+func NewActuatorRepository(db *sql.DB) ports.ActuatorRepository {
+	return &actuatorRepository{
+		db: db,
+	}
+}
+
+const actuatorColumns = `id, device_id, name, type, channel, state, duty_cycle, state_until, updated_at, created_at`
+
+const commandColumns = `id, actuator_id, device_id, channel, action, duty_cycle, duration_seconds, status, error,
+	issued_by, created_at, sent_at, acked_at, expires_at`
+
+// Estados en los que un comando todavía espera la confirmación del dispositivo
+const openCommandStatuses = `'` + domain.CommandStatusPending + `', '` + domain.CommandStatusSent + `'`
+
+func (r *actuatorRepository) Create(ctx context.Context, actuator *domain.Actuator) error {
+	query := `
+		INSERT INTO actuators (device_id, name, type, channel, state, duty_cycle, updated_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		actuator.DeviceID, actuator.Name, actuator.Type, actuator.Channel,
+		actuator.State, actuator.DutyCycle, actuator.UpdatedAt, actuator.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	actuator.ID = uint(id)
+	return nil
+}
+
+func (r *actuatorRepository) FindAll(ctx context.Context) ([]domain.Actuator, error) {
+	query := `SELECT ` + actuatorColumns + ` FROM actuators ORDER BY device_id, channel`
+	return r.queryActuators(ctx, query)
+}
+
+func (r *actuatorRepository) FindByDevices(ctx context.Context, deviceIDs []string) ([]domain.Actuator, error) {
+	if len(deviceIDs) == 0 {
+		return []domain.Actuator{}, nil
+	}
+
+	query := `SELECT ` + actuatorColumns + ` FROM actuators WHERE device_id IN (` + placeholders(len(deviceIDs)) + `) ORDER BY device_id, channel`
+	return r.queryActuators(ctx, query, stringArgs(deviceIDs)...)
+}
+
+func (r *actuatorRepository) FindByID(ctx context.Context, id uint) (*domain.Actuator, error) {
+	query := `SELECT ` + actuatorColumns + ` FROM actuators WHERE id = ?`
+
+	actuator, err := scanActuator(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("actuador no encontrado")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return actuator, nil
+}
+
+func (r *actuatorRepository) Delete(ctx context.Context, id uint) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM actuators WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("actuador no encontrado")
+	}
+
+	return nil
+}
+
+func (r *actuatorRepository) UpdateState(ctx context.Context, actuator *domain.Actuator) error {
+	query := `
+		UPDATE actuators
+		SET state = ?, duty_cycle = ?, state_until = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, actuator.State, actuator.DutyCycle, actuator.StateUntil, actuator.UpdatedAt, actuator.ID)
+	return err
+}
+
+func (r *actuatorRepository) EndTimedRuns(ctx context.Context, now time.Time) ([]domain.Actuator, error) {
+	query := `SELECT ` + actuatorColumns + ` FROM actuators WHERE state_until IS NOT NULL AND state_until <= ?`
+
+	actuators, err := r.queryActuators(ctx, query, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// Solo se devuelven los que apaga esta llamada, por si otra instancia hace lo mismo
+	ended := []domain.Actuator{}
+	for _, actuator := range actuators {
+		result, err := r.db.ExecContext(ctx, `
+			UPDATE actuators
+			SET state = ?, duty_cycle = 0, state_until = NULL, updated_at = ?
+			WHERE id = ? AND state_until = ?
+		`, domain.ActuatorStateOff, now, actuator.ID, actuator.StateUntil)
+		if err != nil {
+			return nil, err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected == 0 {
+			continue
+		}
+
+		actuator.State = domain.ActuatorStateOff
+		actuator.DutyCycle = 0
+		actuator.StateUntil = nil
+		actuator.UpdatedAt = now
+		ended = append(ended, actuator)
+	}
+
+	return ended, nil
+}
+
+func (r *actuatorRepository) CreateCommand(ctx context.Context, command *domain.ActuatorCommand) error {
+	query := `
+		INSERT INTO actuator_commands (actuator_id, device_id, channel, action, duty_cycle, duration_seconds,
+			status, error, issued_by, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		command.ActuatorID, command.DeviceID, command.Channel, command.Action,
+		command.DutyCycle, command.DurationSeconds, command.Status, command.Error,
+		command.IssuedBy, command.CreatedAt, command.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	command.ID = uint(id)
+	return nil
+}
+
+func (r *actuatorRepository) FindCommandByID(ctx context.Context, id uint) (*domain.ActuatorCommand, error) {
+	query := `SELECT ` + commandColumns + ` FROM actuator_commands WHERE id = ?`
+
+	command, err := scanCommand(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("comando no encontrado")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return command, nil
+}
+
+func (r *actuatorRepository) FindCommands(ctx context.Context, actuatorID uint, limit int) ([]domain.ActuatorCommand, error) {
+	query := `
+		SELECT ` + commandColumns + `
+		FROM actuator_commands
+		WHERE actuator_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	return r.queryCommands(ctx, query, actuatorID, limit)
+}
+
+func (r *actuatorRepository) FindOpenCommands(ctx context.Context, deviceID string, now time.Time) ([]domain.ActuatorCommand, error) {
+	query := `
+		SELECT ` + commandColumns + `
+		FROM actuator_commands
+		WHERE device_id = ? AND status IN (` + openCommandStatuses + `) AND expires_at > ?
+		ORDER BY id
+	`
+	return r.queryCommands(ctx, query, deviceID, now)
+}
+
+func (r *actuatorRepository) FindExpiredCommands(ctx context.Context, now time.Time) ([]domain.ActuatorCommand, error) {
+	query := `
+		SELECT ` + commandColumns + `
+		FROM actuator_commands
+		WHERE status IN (` + openCommandStatuses + `) AND expires_at <= ?
+		ORDER BY id
+	`
+	return r.queryCommands(ctx, query, now)
+}
+
+func (r *actuatorRepository) MarkCommandsSent(ctx context.Context, ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	args := []interface{}{domain.CommandStatusSent, at}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	query := `
+		UPDATE actuator_commands
+		SET status = ?, sent_at = COALESCE(sent_at, ?)
+		WHERE id IN (` + placeholders(len(ids)) + `) AND status IN (` + openCommandStatuses + `)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *actuatorRepository) CloseCommand(ctx context.Context, command *domain.ActuatorCommand) (bool, error) {
+	query := `
+		UPDATE actuator_commands
+		SET status = ?, error = ?, acked_at = ?
+		WHERE id = ? AND status IN (` + openCommandStatuses + `)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, command.Status, command.Error, command.AckedAt, command.ID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *actuatorRepository) queryActuators(ctx context.Context, query string, args ...interface{}) ([]domain.Actuator, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actuators := []domain.Actuator{}
+
+	for rows.Next() {
+		actuator, err := scanActuator(rows)
+		if err != nil {
+			return nil, err
+		}
+		actuators = append(actuators, *actuator)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return actuators, nil
+}
+
+func (r *actuatorRepository) queryCommands(ctx context.Context, query string, args ...interface{}) ([]domain.ActuatorCommand, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commands := []domain.ActuatorCommand{}
+
+	for rows.Next() {
+		command, err := scanCommand(rows)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, *command)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return commands, nil
+}
+
+func scanActuator(row rowScanner) (*domain.Actuator, error) {
+	var actuator domain.Actuator
+	var stateUntil sql.NullTime
+
+	err := row.Scan(
+		&actuator.ID, &actuator.DeviceID, &actuator.Name, &actuator.Type, &actuator.Channel,
+		&actuator.State, &actuator.DutyCycle, &stateUntil, &actuator.UpdatedAt, &actuator.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if stateUntil.Valid {
+		actuator.StateUntil = &stateUntil.Time
+	}
+
+	return &actuator, nil
+}
+
+func scanCommand(row rowScanner) (*domain.ActuatorCommand, error) {
+	var command domain.ActuatorCommand
+	var dutyCycle, duration sql.NullInt64
+	var sentAt, ackedAt sql.NullTime
+
+	err := row.Scan(
+		&command.ID, &command.ActuatorID, &command.DeviceID, &command.Channel, &command.Action,
+		&dutyCycle, &duration, &command.Status, &command.Error,
+		&command.IssuedBy, &command.CreatedAt, &sentAt, &ackedAt, &command.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if dutyCycle.Valid {
+		value := int(dutyCycle.Int64)
+		command.DutyCycle = &value
+	}
+	if duration.Valid {
+		value := int(duration.Int64)
+		command.DurationSeconds = &value
+	}
+	if sentAt.Valid {
+		command.SentAt = &sentAt.Time
+	}
+	if ackedAt.Valid {
+		command.AckedAt = &ackedAt.Time
+	}
+
+	return &command, nil
+}
+
+// placeholders devuelve n marcadores separados por comas para una lista IN
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// stringArgs convierte los valores en argumentos de una consulta
+func stringArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
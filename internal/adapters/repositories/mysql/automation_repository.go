@@ -0,0 +1,295 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type automationRepository struct {
+	db *sql.DB
+}
+
+func NewAutomationRepository(db *sql.DB) ports.AutomationRepository {
+	return &automationRepository{
+		db: db,
+	}
+}
+
+const automationColumns = `id, name, enabled, device_id, sensor_type, operator, threshold, for_seconds,
+	actuator_id, action, duty_cycle, duration_seconds, cooldown_seconds, max_per_day,
+	condition_since, last_triggered_at, created_by, created_at, updated_at`
+
+func (r *automationRepository) Create(ctx context.Context, automation *domain.Automation) error {
+	query := `
+		INSERT INTO automations (name, enabled, device_id, sensor_type, operator, threshold, for_seconds,
+			actuator_id, action, duty_cycle, duration_seconds, cooldown_seconds, max_per_day,
+			created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		automation.Name, automation.Enabled, automation.DeviceID, automation.SensorType,
+		automation.Operator, automation.Threshold, automation.ForSeconds,
+		automation.ActuatorID, automation.Action, automation.DutyCycle, automation.DurationSeconds,
+		automation.CooldownSeconds, automation.MaxPerDay,
+		automation.CreatedBy, automation.CreatedAt, automation.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	automation.ID = uint(id)
+	return nil
+}
+
+func (r *automationRepository) Update(ctx context.Context, automation *domain.Automation) error {
+	query := `
+		UPDATE automations
+		SET name = ?, enabled = ?, device_id = ?, sensor_type = ?, operator = ?, threshold = ?,
+			for_seconds = ?, actuator_id = ?, action = ?, duty_cycle = ?, duration_seconds = ?,
+			cooldown_seconds = ?, max_per_day = ?, condition_since = NULL, updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		automation.Name, automation.Enabled, automation.DeviceID, automation.SensorType,
+		automation.Operator, automation.Threshold, automation.ForSeconds,
+		automation.ActuatorID, automation.Action, automation.DutyCycle, automation.DurationSeconds,
+		automation.CooldownSeconds, automation.MaxPerDay, automation.UpdatedAt, automation.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("automatización no encontrada")
+	}
+
+	automation.ConditionSince = nil
+	return nil
+}
+
+func (r *automationRepository) FindAll(ctx context.Context) ([]domain.Automation, error) {
+	query := `SELECT ` + automationColumns + ` FROM automations ORDER BY id`
+	return r.queryAutomations(ctx, query)
+}
+
+func (r *automationRepository) FindByDevices(ctx context.Context, deviceIDs []string) ([]domain.Automation, error) {
+	if len(deviceIDs) == 0 {
+		return []domain.Automation{}, nil
+	}
+
+	query := `SELECT ` + automationColumns + ` FROM automations WHERE device_id IN (` + placeholders(len(deviceIDs)) + `) ORDER BY id`
+	return r.queryAutomations(ctx, query, stringArgs(deviceIDs)...)
+}
+
+func (r *automationRepository) FindByID(ctx context.Context, id uint) (*domain.Automation, error) {
+	query := `SELECT ` + automationColumns + ` FROM automations WHERE id = ?`
+
+	automation, err := scanAutomation(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("automatización no encontrada")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return automation, nil
+}
+
+func (r *automationRepository) FindEnabledByDevice(ctx context.Context, deviceID string) ([]domain.Automation, error) {
+	query := `SELECT ` + automationColumns + ` FROM automations WHERE device_id = ? AND enabled = TRUE ORDER BY id`
+	return r.queryAutomations(ctx, query, deviceID)
+}
+
+func (r *automationRepository) Delete(ctx context.Context, id uint) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM automations WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("automatización no encontrada")
+	}
+
+	return nil
+}
+
+func (r *automationRepository) SetConditionSince(ctx context.Context, id uint, since *time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE automations SET condition_since = ? WHERE id = ?`, since, id)
+	return err
+}
+
+func (r *automationRepository) MarkTriggered(ctx context.Context, id uint, previous *time.Time, at time.Time) (bool, error) {
+	query := `
+		UPDATE automations
+		SET last_triggered_at = ?
+		WHERE id = ? AND last_triggered_at <=> ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, at, id, previous)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *automationRepository) RecordExecution(ctx context.Context, execution *domain.AutomationExecution) error {
+	query := `
+		INSERT INTO automation_executions (automation_id, status, value, command_id, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		execution.AutomationID, execution.Status, execution.Value,
+		execution.CommandID, execution.Error, execution.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	execution.ID = uint(id)
+	return nil
+}
+
+func (r *automationRepository) FindExecutions(ctx context.Context, automationID uint, limit int) ([]domain.AutomationExecution, error) {
+	query := `
+		SELECT id, automation_id, status, value, command_id, error, created_at
+		FROM automation_executions
+		WHERE automation_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, automationID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	executions := []domain.AutomationExecution{}
+
+	for rows.Next() {
+		var execution domain.AutomationExecution
+		var commandID sql.NullInt64
+
+		err := rows.Scan(
+			&execution.ID, &execution.AutomationID, &execution.Status, &execution.Value,
+			&commandID, &execution.Error, &execution.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if commandID.Valid {
+			id := uint(commandID.Int64)
+			execution.CommandID = &id
+		}
+		executions = append(executions, execution)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return executions, nil
+}
+
+func (r *automationRepository) CountExecutionsSince(ctx context.Context, automationID uint, status string, since time.Time) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM automation_executions
+		WHERE automation_id = ? AND status = ? AND created_at >= ?
+	`
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, automationID, status, since).Scan(&count)
+	return count, err
+}
+
+func (r *automationRepository) queryAutomations(ctx context.Context, query string, args ...interface{}) ([]domain.Automation, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	automations := []domain.Automation{}
+
+	for rows.Next() {
+		automation, err := scanAutomation(rows)
+		if err != nil {
+			return nil, err
+		}
+		automations = append(automations, *automation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return automations, nil
+}
+
+func scanAutomation(row rowScanner) (*domain.Automation, error) {
+	var automation domain.Automation
+	var dutyCycle, duration sql.NullInt64
+	var conditionSince, lastTriggeredAt sql.NullTime
+
+	err := row.Scan(
+		&automation.ID, &automation.Name, &automation.Enabled, &automation.DeviceID,
+		&automation.SensorType, &automation.Operator, &automation.Threshold, &automation.ForSeconds,
+		&automation.ActuatorID, &automation.Action, &dutyCycle, &duration,
+		&automation.CooldownSeconds, &automation.MaxPerDay,
+		&conditionSince, &lastTriggeredAt, &automation.CreatedBy, &automation.CreatedAt, &automation.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if dutyCycle.Valid {
+		value := int(dutyCycle.Int64)
+		automation.DutyCycle = &value
+	}
+	if duration.Valid {
+		value := int(duration.Int64)
+		automation.DurationSeconds = &value
+	}
+	if conditionSince.Valid {
+		automation.ConditionSince = &conditionSince.Time
+	}
+	if lastTriggeredAt.Valid {
+		automation.LastTriggeredAt = &lastTriggeredAt.Time
+	}
+
+	return &automation, nil
+}
@@ -0,0 +1,304 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type controlLoopRepository struct {
+	db *sql.DB
+}
+
+func NewControlLoopRepository(db *sql.DB) ports.ControlLoopRepository {
+	return &controlLoopRepository{
+		db: db,
+	}
+}
+
+const controlLoopColumns = `id, name, enabled, device_id, sensor_type, setpoint, kp, ki, kd, reverse_acting,
+	actuator_id, output_min, output_max, sample_seconds,
+	integral, last_input, last_input_at, last_output, last_command_id, last_update_at,
+	created_by, created_at, updated_at`
+
+func (r *controlLoopRepository) Create(ctx context.Context, loop *domain.ControlLoop) error {
+	query := `
+		INSERT INTO control_loops (name, enabled, device_id, sensor_type, setpoint, kp, ki, kd, reverse_acting,
+			actuator_id, output_min, output_max, sample_seconds, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		loop.Name, loop.Enabled, loop.DeviceID, loop.SensorType, loop.Setpoint,
+		loop.Kp, loop.Ki, loop.Kd, loop.Reverse,
+		loop.ActuatorID, loop.OutputMin, loop.OutputMax, loop.SampleSeconds,
+		loop.CreatedBy, loop.CreatedAt, loop.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	loop.ID = uint(id)
+	return nil
+}
+
+func (r *controlLoopRepository) Update(ctx context.Context, loop *domain.ControlLoop) error {
+	query := `
+		UPDATE control_loops
+		SET name = ?, enabled = ?, device_id = ?, sensor_type = ?, setpoint = ?, kp = ?, ki = ?, kd = ?,
+			reverse_acting = ?, actuator_id = ?, output_min = ?, output_max = ?, sample_seconds = ?,
+			integral = ?, last_input = ?, last_input_at = ?, last_output = ?, last_command_id = ?, last_update_at = ?,
+			updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		loop.Name, loop.Enabled, loop.DeviceID, loop.SensorType, loop.Setpoint,
+		loop.Kp, loop.Ki, loop.Kd, loop.Reverse,
+		loop.ActuatorID, loop.OutputMin, loop.OutputMax, loop.SampleSeconds,
+		loop.Integral, loop.LastInput, loop.LastInputAt, loop.LastOutput, loop.LastCommandID, loop.LastUpdateAt,
+		loop.UpdatedAt, loop.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("lazo de control no encontrado")
+	}
+
+	return nil
+}
+
+func (r *controlLoopRepository) UpdateTuning(ctx context.Context, loop *domain.ControlLoop) error {
+	query := `
+		UPDATE control_loops
+		SET setpoint = ?, kp = ?, ki = ?, kd = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, loop.Setpoint, loop.Kp, loop.Ki, loop.Kd, loop.UpdatedAt, loop.ID)
+	return err
+}
+
+func (r *controlLoopRepository) FindAll(ctx context.Context) ([]domain.ControlLoop, error) {
+	query := `SELECT ` + controlLoopColumns + ` FROM control_loops ORDER BY id`
+	return r.queryControlLoops(ctx, query)
+}
+
+func (r *controlLoopRepository) FindByDevices(ctx context.Context, deviceIDs []string) ([]domain.ControlLoop, error) {
+	if len(deviceIDs) == 0 {
+		return []domain.ControlLoop{}, nil
+	}
+
+	query := `SELECT ` + controlLoopColumns + ` FROM control_loops WHERE device_id IN (` + placeholders(len(deviceIDs)) + `) ORDER BY id`
+	return r.queryControlLoops(ctx, query, stringArgs(deviceIDs)...)
+}
+
+func (r *controlLoopRepository) FindByID(ctx context.Context, id uint) (*domain.ControlLoop, error) {
+	query := `SELECT ` + controlLoopColumns + ` FROM control_loops WHERE id = ?`
+
+	loop, err := scanControlLoop(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("lazo de control no encontrado")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return loop, nil
+}
+
+func (r *controlLoopRepository) FindEnabled(ctx context.Context) ([]domain.ControlLoop, error) {
+	query := `SELECT ` + controlLoopColumns + ` FROM control_loops WHERE enabled = TRUE ORDER BY id`
+	return r.queryControlLoops(ctx, query)
+}
+
+func (r *controlLoopRepository) Delete(ctx context.Context, id uint) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM control_loops WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("lazo de control no encontrado")
+	}
+
+	return nil
+}
+
+func (r *controlLoopRepository) SaveState(ctx context.Context, loop *domain.ControlLoop, previous *time.Time) (bool, error) {
+	query := `
+		UPDATE control_loops
+		SET integral = ?, last_input = ?, last_input_at = ?, last_update_at = ?
+		WHERE id = ? AND enabled = TRUE AND last_input_at <=> ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		loop.Integral, loop.LastInput, loop.LastInputAt, loop.LastUpdateAt, loop.ID, previous,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *controlLoopRepository) SetOutput(ctx context.Context, id uint, output int, commandID uint) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE control_loops SET last_output = ?, last_command_id = ? WHERE id = ?`, output, commandID, id)
+	return err
+}
+
+func (r *controlLoopRepository) RecordSample(ctx context.Context, sample *domain.ControlLoopSample) error {
+	query := `
+		INSERT INTO control_loop_samples (loop_id, input_value, setpoint, error, p_term, i_term, d_term,
+			output_value, duty_cycle, command_id, command_error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		sample.LoopID, sample.Input, sample.Setpoint, sample.Error,
+		sample.Proportional, sample.Integral, sample.Derivative,
+		sample.Output, sample.DutyCycle, sample.CommandID, sample.CommandError, sample.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	sample.ID = uint(id)
+	return nil
+}
+
+func (r *controlLoopRepository) FindSamples(ctx context.Context, loopID uint, limit int) ([]domain.ControlLoopSample, error) {
+	query := `
+		SELECT id, loop_id, input_value, setpoint, error, p_term, i_term, d_term,
+			output_value, duty_cycle, command_id, command_error, created_at
+		FROM control_loop_samples
+		WHERE loop_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, loopID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := []domain.ControlLoopSample{}
+
+	for rows.Next() {
+		var sample domain.ControlLoopSample
+		var commandID sql.NullInt64
+
+		err := rows.Scan(
+			&sample.ID, &sample.LoopID, &sample.Input, &sample.Setpoint, &sample.Error,
+			&sample.Proportional, &sample.Integral, &sample.Derivative,
+			&sample.Output, &sample.DutyCycle, &commandID, &sample.CommandError, &sample.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if commandID.Valid {
+			id := uint(commandID.Int64)
+			sample.CommandID = &id
+		}
+		samples = append(samples, sample)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return samples, nil
+}
+
+func (r *controlLoopRepository) queryControlLoops(ctx context.Context, query string, args ...interface{}) ([]domain.ControlLoop, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loops := []domain.ControlLoop{}
+
+	for rows.Next() {
+		loop, err := scanControlLoop(rows)
+		if err != nil {
+			return nil, err
+		}
+		loops = append(loops, *loop)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return loops, nil
+}
+
+func scanControlLoop(row rowScanner) (*domain.ControlLoop, error) {
+	var loop domain.ControlLoop
+	var lastInput sql.NullFloat64
+	var lastOutput, lastCommandID sql.NullInt64
+	var lastInputAt, lastUpdateAt sql.NullTime
+
+	err := row.Scan(
+		&loop.ID, &loop.Name, &loop.Enabled, &loop.DeviceID, &loop.SensorType, &loop.Setpoint,
+		&loop.Kp, &loop.Ki, &loop.Kd, &loop.Reverse,
+		&loop.ActuatorID, &loop.OutputMin, &loop.OutputMax, &loop.SampleSeconds,
+		&loop.Integral, &lastInput, &lastInputAt, &lastOutput, &lastCommandID, &lastUpdateAt,
+		&loop.CreatedBy, &loop.CreatedAt, &loop.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastInput.Valid {
+		loop.LastInput = &lastInput.Float64
+	}
+	if lastInputAt.Valid {
+		loop.LastInputAt = &lastInputAt.Time
+	}
+	if lastOutput.Valid {
+		output := int(lastOutput.Int64)
+		loop.LastOutput = &output
+	}
+	if lastCommandID.Valid {
+		commandID := uint(lastCommandID.Int64)
+		loop.LastCommandID = &commandID
+	}
+	if lastUpdateAt.Valid {
+		loop.LastUpdateAt = &lastUpdateAt.Time
+	}
+
+	return &loop, nil
+}
@@ -0,0 +1,192 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type deviceRepository struct {
+	db *sql.DB
+}
+
+func NewDeviceRepository(db *sql.DB) ports.DeviceRepository {
+	return &deviceRepository{
+		db: db,
+	}
+}
+
+func (r *deviceRepository) Touch(ctx context.Context, deviceID string, readingID uint, at time.Time, defaultInterval int) (string, error) {
+	var previous string
+
+	err := r.db.QueryRowContext(ctx, `SELECT status FROM devices WHERE id = ?`, deviceID).Scan(&previous)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	query := `
+		INSERT INTO devices (id, name, expected_interval_seconds, status, last_seen_at, last_reading_id, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?) 
+		ON DUPLICATE KEY UPDATE 
+			status = VALUES(status), 
+			last_seen_at = VALUES(last_seen_at), 
+			last_reading_id = IF(VALUES(last_reading_id) = 0, last_reading_id, VALUES(last_reading_id))
+	`
+
+	_, err = r.db.ExecContext(ctx, query, deviceID, deviceID, defaultInterval, domain.DeviceStatusOnline, at, readingID, at)
+	if err != nil {
+		return "", err
+	}
+
+	return previous, nil
+}
+
+func (r *deviceRepository) FindAll(ctx context.Context) ([]domain.Device, error) {
+	query := `
+		SELECT id, name, garden_id, expected_interval_seconds, status, last_seen_at, last_reading_id, created_at 
+		FROM devices 
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []domain.Device{}
+
+	for rows.Next() {
+		device, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, *device)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (r *deviceRepository) FindByID(ctx context.Context, id string) (*domain.Device, error) {
+	query := `
+		SELECT id, name, garden_id, expected_interval_seconds, status, last_seen_at, last_reading_id, created_at 
+		FROM devices 
+		WHERE id = ?
+	`
+
+	device, err := scanDevice(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("dispositivo no encontrado")
+		}
+		return nil, err
+	}
+
+	return device, nil
+}
+
+func (r *deviceRepository) Update(ctx context.Context, id string, req domain.UpdateDeviceRequest) error {
+	query := `
+		UPDATE devices 
+		SET name = IF(? = '', name, ?), expected_interval_seconds = ? 
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, req.Name, req.Name, req.ExpectedIntervalSeconds, id)
+	return err
+}
+
+func (r *deviceRepository) SetGarden(ctx context.Context, id string, gardenID *uint) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE devices SET garden_id = ? WHERE id = ?`, gardenID, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("dispositivo no encontrado")
+	}
+
+	return nil
+}
+
+func (r *deviceRepository) SetTokenHash(ctx context.Context, id, tokenHash string, defaultInterval int) error {
+	query := `
+		INSERT INTO devices (id, name, expected_interval_seconds, status, token_hash, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?) 
+		ON DUPLICATE KEY UPDATE token_hash = VALUES(token_hash)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, id, defaultInterval, domain.DeviceStatusOnline, tokenHash, time.Now())
+	return err
+}
+
+func (r *deviceRepository) GetTokenHash(ctx context.Context, id string) (string, error) {
+	var tokenHash sql.NullString
+
+	err := r.db.QueryRowContext(ctx, `SELECT token_hash FROM devices WHERE id = ?`, id).Scan(&tokenHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return tokenHash.String, nil
+}
+
+func (r *deviceRepository) SetStatus(ctx context.Context, id, status string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `UPDATE devices SET status = ? WHERE id = ? AND status <> ?`, status, id, status)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// scanDevice lee una fila de la tabla devices
+func scanDevice(row rowScanner) (*domain.Device, error) {
+	var device domain.Device
+	var gardenID sql.NullInt64
+	var lastSeenAt sql.NullTime
+
+	err := row.Scan(
+		&device.ID,
+		&device.Name,
+		&gardenID,
+		&device.ExpectedIntervalSeconds,
+		&device.Status,
+		&lastSeenAt,
+		&device.LastReadingID,
+		&device.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if gardenID.Valid {
+		id := uint(gardenID.Int64)
+		device.GardenID = &id
+	}
+	if lastSeenAt.Valid {
+		device.LastSeenAt = &lastSeenAt.Time
+	}
+
+	return &device, nil
+}
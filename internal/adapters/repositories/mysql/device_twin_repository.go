@@ -0,0 +1,130 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type deviceTwinRepository struct {
+	db *sql.DB
+}
+
+func NewDeviceTwinRepository(db *sql.DB) ports.DeviceTwinRepository {
+	return &deviceTwinRepository{
+		db: db,
+	}
+}
+
+func (r *deviceTwinRepository) Find(ctx context.Context, deviceID string) (*domain.DeviceTwin, error) {
+	query := `
+		SELECT desired, desired_version, desired_updated_at,
+			reported, reported_version, applied_version, reported_updated_at
+		FROM device_twins
+		WHERE device_id = ?
+	`
+
+	twin := &domain.DeviceTwin{DeviceID: deviceID}
+	var desired, reported string
+	var desiredUpdatedAt, reportedUpdatedAt sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, deviceID).Scan(
+		&desired, &twin.DesiredVersion, &desiredUpdatedAt,
+		&reported, &twin.ReportedVersion, &twin.AppliedVersion, &reportedUpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return twin, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(desired), &twin.Desired); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(reported), &twin.Reported); err != nil {
+		return nil, err
+	}
+	if desiredUpdatedAt.Valid {
+		twin.DesiredUpdatedAt = &desiredUpdatedAt.Time
+	}
+	if reportedUpdatedAt.Valid {
+		twin.ReportedUpdatedAt = &reportedUpdatedAt.Time
+	}
+
+	return twin, nil
+}
+
+func (r *deviceTwinRepository) UpdateDesired(ctx context.Context, deviceID string, desired domain.DeviceConfig, expectedVersion int, at time.Time) (bool, error) {
+	config, err := json.Marshal(desired)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.ensure(ctx, deviceID); err != nil {
+		return false, err
+	}
+
+	query := `
+		UPDATE device_twins
+		SET desired = ?, desired_version = desired_version + 1, desired_updated_at = ?
+		WHERE device_id = ? AND desired_version = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, config, at, deviceID, expectedVersion)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *deviceTwinRepository) UpdateReported(ctx context.Context, deviceID string, req domain.ReportConfigRequest, at time.Time) (bool, error) {
+	config, err := json.Marshal(req.Config)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.ensure(ctx, deviceID); err != nil {
+		return false, err
+	}
+
+	query := `
+		UPDATE device_twins
+		SET reported = ?, reported_version = reported_version + 1, applied_version = ?, reported_updated_at = ?
+		WHERE device_id = ? AND applied_version <= ? AND desired_version >= ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, config, req.AppliedVersion, at, deviceID, req.AppliedVersion, req.AppliedVersion)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+// ensure crea el gemelo vacío del dispositivo si aún no existe
+func (r *deviceTwinRepository) ensure(ctx context.Context, deviceID string) error {
+	query := `
+		INSERT IGNORE INTO device_twins (device_id, desired, reported)
+		VALUES (?, '{}', '{}')
+	`
+
+	_, err := r.db.ExecContext(ctx, query, deviceID)
+	return err
+}
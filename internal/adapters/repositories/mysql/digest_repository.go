@@ -0,0 +1,187 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type digestRepository struct {
+	db *sql.DB
+}
+
+func NewDigestRepository(db *sql.DB) ports.DigestRepository {
+	return &digestRepository{
+		db: db,
+	}
+}
+
+func (r *digestRepository) Upsert(ctx context.Context, sub *domain.DigestSubscription) error {
+	query := `
+		INSERT INTO digest_subscriptions 
+			(user_id, frequency, time_of_day, weekday, timezone, channels, enabled, next_run_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) 
+		ON DUPLICATE KEY UPDATE 
+			id = LAST_INSERT_ID(id), 
+			frequency = VALUES(frequency), 
+			time_of_day = VALUES(time_of_day), 
+			weekday = VALUES(weekday), 
+			timezone = VALUES(timezone), 
+			channels = VALUES(channels), 
+			enabled = VALUES(enabled), 
+			next_run_at = VALUES(next_run_at)
+	`
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		sub.UserID,
+		sub.Frequency,
+		sub.TimeOfDay,
+		sub.Weekday,
+		sub.Timezone,
+		strings.Join(sub.Channels, ","),
+		sub.Enabled,
+		sub.NextRunAt,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	sub.ID = uint(id)
+	return nil
+}
+
+func (r *digestRepository) FindByUser(ctx context.Context, userID uint) (*domain.DigestSubscription, error) {
+	query := `
+		SELECT id, user_id, frequency, time_of_day, weekday, timezone, channels, enabled, last_sent_at, next_run_at 
+		FROM digest_subscriptions 
+		WHERE user_id = ?
+	`
+
+	sub, err := scanDigestSubscription(r.db.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("no hay suscripción a resúmenes")
+		}
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (r *digestRepository) FindDue(ctx context.Context, at time.Time) ([]domain.DigestSubscription, error) {
+	query := `
+		SELECT id, user_id, frequency, time_of_day, weekday, timezone, channels, enabled, last_sent_at, next_run_at 
+		FROM digest_subscriptions 
+		WHERE enabled = true AND next_run_at <= ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, at)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []domain.DigestSubscription{}
+
+	for rows.Next() {
+		sub, err := scanDigestSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (r *digestRepository) Claim(ctx context.Context, id uint, previous, next time.Time) (bool, error) {
+	query := `UPDATE digest_subscriptions SET next_run_at = ? WHERE id = ? AND next_run_at = ?`
+
+	result, err := r.db.ExecContext(ctx, query, next, id, previous)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *digestRepository) MarkSent(ctx context.Context, id uint, sentAt, nextRunAt time.Time) error {
+	query := `UPDATE digest_subscriptions SET last_sent_at = ?, next_run_at = ? WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, sentAt, nextRunAt, id)
+	return err
+}
+
+func (r *digestRepository) Reschedule(ctx context.Context, id uint, nextRunAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE digest_subscriptions SET next_run_at = ? WHERE id = ?`, nextRunAt, id)
+	return err
+}
+
+func (r *digestRepository) Delete(ctx context.Context, userID uint) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM digest_subscriptions WHERE user_id = ?`, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("no hay suscripción a resúmenes")
+	}
+
+	return nil
+}
+
+// scanDigestSubscription lee una fila de la tabla digest_subscriptions
+func scanDigestSubscription(row rowScanner) (*domain.DigestSubscription, error) {
+	var sub domain.DigestSubscription
+	var channels string
+	var lastSentAt sql.NullTime
+
+	err := row.Scan(
+		&sub.ID,
+		&sub.UserID,
+		&sub.Frequency,
+		&sub.TimeOfDay,
+		&sub.Weekday,
+		&sub.Timezone,
+		&channels,
+		&sub.Enabled,
+		&lastSentAt,
+		&sub.NextRunAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.Channels = splitList(channels)
+	if lastSentAt.Valid {
+		sub.LastSentAt = &lastSentAt.Time
+	}
+
+	return &sub, nil
+}
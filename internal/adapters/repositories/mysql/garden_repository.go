@@ -0,0 +1,245 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type gardenRepository struct {
+	db *sql.DB
+}
+
+func NewGardenRepository(db *sql.DB) ports.GardenRepository {
+	return &gardenRepository{
+		db: db,
+	}
+}
+
+func (r *gardenRepository) Create(ctx context.Context, garden *domain.Garden) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	garden.CreatedAt = now
+
+	result, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO gardens (name, owner_id, timezone, latitude, longitude, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		garden.Name, garden.OwnerID, garden.Timezone, garden.Latitude, garden.Longitude, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	garden.ID = uint(id)
+	garden.Role = domain.GardenRoleOwner
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO garden_members (garden_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`,
+		garden.ID, garden.OwnerID, domain.GardenRoleOwner, now,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *gardenRepository) FindByUser(ctx context.Context, userID uint) ([]domain.Garden, error) {
+	query := `
+		SELECT g.id, g.name, g.owner_id, m.role, g.timezone, g.latitude, g.longitude, g.created_at 
+		FROM gardens g 
+		JOIN garden_members m ON m.garden_id = g.id 
+		WHERE m.user_id = ? 
+		ORDER BY g.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	gardens := []domain.Garden{}
+
+	for rows.Next() {
+		var garden domain.Garden
+		var latitude, longitude sql.NullFloat64
+		err := rows.Scan(
+			&garden.ID, &garden.Name, &garden.OwnerID, &garden.Role,
+			&garden.Timezone, &latitude, &longitude, &garden.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		setGardenLocation(&garden, latitude, longitude)
+		gardens = append(gardens, garden)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return gardens, nil
+}
+
+func (r *gardenRepository) FindByID(ctx context.Context, id uint) (*domain.Garden, error) {
+	query := `
+		SELECT id, name, owner_id, timezone, latitude, longitude, created_at 
+		FROM gardens 
+		WHERE id = ?
+	`
+
+	var garden domain.Garden
+	var latitude, longitude sql.NullFloat64
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&garden.ID, &garden.Name, &garden.OwnerID,
+		&garden.Timezone, &latitude, &longitude, &garden.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("jardín no encontrado")
+		}
+		return nil, err
+	}
+	setGardenLocation(&garden, latitude, longitude)
+
+	return &garden, nil
+}
+
+func (r *gardenRepository) Update(ctx context.Context, garden *domain.Garden) error {
+	query := `
+		UPDATE gardens 
+		SET name = ?, timezone = ?, latitude = ?, longitude = ? 
+		WHERE id = ?
+	`
+
+	_, err := r.db.ExecContext(ctx, query, garden.Name, garden.Timezone, garden.Latitude, garden.Longitude, garden.ID)
+	return err
+}
+
+func (r *gardenRepository) GetMemberRole(ctx context.Context, gardenID, userID uint) (string, error) {
+	var role string
+
+	err := r.db.QueryRowContext(ctx, `SELECT role FROM garden_members WHERE garden_id = ? AND user_id = ?`, gardenID, userID).Scan(&role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return role, nil
+}
+
+func (r *gardenRepository) FindMembers(ctx context.Context, gardenID uint) ([]domain.GardenMember, error) {
+	query := `
+		SELECT m.garden_id, m.user_id, u.username, u.email, m.role, m.created_at 
+		FROM garden_members m 
+		JOIN users u ON u.id = m.user_id 
+		WHERE m.garden_id = ? 
+		ORDER BY m.created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, gardenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []domain.GardenMember{}
+
+	for rows.Next() {
+		var member domain.GardenMember
+		err := rows.Scan(&member.GardenID, &member.UserID, &member.Username, &member.Email, &member.Role, &member.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (r *gardenRepository) AddMember(ctx context.Context, gardenID, userID uint, role string) error {
+	query := `
+		INSERT INTO garden_members (garden_id, user_id, role, created_at) 
+		VALUES (?, ?, ?, ?) 
+		ON DUPLICATE KEY UPDATE role = role
+	`
+
+	_, err := r.db.ExecContext(ctx, query, gardenID, userID, role, time.Now())
+	return err
+}
+
+func (r *gardenRepository) RemoveMember(ctx context.Context, gardenID, userID uint) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM garden_members WHERE garden_id = ? AND user_id = ?`, gardenID, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("miembro no encontrado")
+	}
+
+	return nil
+}
+
+func (r *gardenRepository) FindDeviceIDsByUser(ctx context.Context, userID uint) ([]string, error) {
+	query := `
+		SELECT d.id 
+		FROM devices d 
+		JOIN garden_members m ON m.garden_id = d.garden_id 
+		WHERE m.user_id = ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func setGardenLocation(garden *domain.Garden, latitude, longitude sql.NullFloat64) {
+	if latitude.Valid && longitude.Valid {
+		garden.Latitude = &latitude.Float64
+		garden.Longitude = &longitude.Float64
+	}
+}
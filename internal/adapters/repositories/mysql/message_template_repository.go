@@ -0,0 +1,90 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type messageTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewMessageTemplateRepository(db *sql.DB) ports.MessageTemplateRepository {
+	return &messageTemplateRepository{
+		db: db,
+	}
+}
+
+func (r *messageTemplateRepository) FindAll(ctx context.Context) ([]domain.MessageTemplate, error) {
+	query := `
+		SELECT locale, message_key, template, updated_by, updated_at 
+		FROM message_templates
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []domain.MessageTemplate{}
+
+	for rows.Next() {
+		var tmpl domain.MessageTemplate
+		var updatedAt time.Time
+
+		if err := rows.Scan(&tmpl.Locale, &tmpl.Key, &tmpl.Template, &tmpl.UpdatedBy, &updatedAt); err != nil {
+			return nil, err
+		}
+
+		tmpl.Overridden = true
+		tmpl.UpdatedAt = &updatedAt
+		templates = append(templates, tmpl)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (r *messageTemplateRepository) Upsert(ctx context.Context, tmpl *domain.MessageTemplate) error {
+	query := `
+		INSERT INTO message_templates (locale, message_key, template, updated_by, updated_at) 
+		VALUES (?, ?, ?, ?, ?) 
+		ON DUPLICATE KEY UPDATE 
+			template = VALUES(template), 
+			updated_by = VALUES(updated_by), 
+			updated_at = VALUES(updated_at)
+	`
+
+	now := time.Now()
+	tmpl.UpdatedAt = &now
+	tmpl.Overridden = true
+
+	_, err := r.db.ExecContext(ctx, query, tmpl.Locale, tmpl.Key, tmpl.Template, tmpl.UpdatedBy, now)
+	return err
+}
+
+func (r *messageTemplateRepository) Delete(ctx context.Context, locale, key string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM message_templates WHERE locale = ? AND message_key = ?`, locale, key)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("la plantilla no tiene personalización")
+	}
+
+	return nil
+}
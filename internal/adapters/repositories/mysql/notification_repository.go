@@ -0,0 +1,126 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type notificationPreferenceRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationPreferenceRepository(db *sql.DB) ports.NotificationPreferenceRepository {
+	return &notificationPreferenceRepository{
+		db: db,
+	}
+}
+
+func (r *notificationPreferenceRepository) Upsert(ctx context.Context, pref *domain.NotificationPreference) error {
+	query := `
+		INSERT INTO notification_preferences (user_id, channel, enabled, min_severity, sensor_types, updated_at) 
+		VALUES (?, ?, ?, ?, ?, ?) 
+		ON DUPLICATE KEY UPDATE 
+			id = LAST_INSERT_ID(id), 
+			enabled = VALUES(enabled), 
+			min_severity = VALUES(min_severity), 
+			sensor_types = VALUES(sensor_types), 
+			updated_at = VALUES(updated_at)
+	`
+
+	now := time.Now()
+	pref.UpdatedAt = now
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		pref.UserID,
+		pref.Channel,
+		pref.Enabled,
+		pref.MinSeverity,
+		strings.Join(pref.SensorTypes, ","),
+		now,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	pref.ID = uint(id)
+	return nil
+}
+
+func (r *notificationPreferenceRepository) FindByUser(ctx context.Context, userID uint) ([]domain.NotificationPreference, error) {
+	query := `
+		SELECT id, user_id, channel, enabled, min_severity, sensor_types, updated_at 
+		FROM notification_preferences 
+		WHERE user_id = ? 
+		ORDER BY channel
+	`
+
+	return r.queryPreferences(ctx, query, userID)
+}
+
+func (r *notificationPreferenceRepository) FindEnabledByChannel(ctx context.Context, channel string) ([]domain.NotificationPreference, error) {
+	query := `
+		SELECT id, user_id, channel, enabled, min_severity, sensor_types, updated_at 
+		FROM notification_preferences 
+		WHERE channel = ? AND enabled = true
+	`
+
+	return r.queryPreferences(ctx, query, channel)
+}
+
+func (r *notificationPreferenceRepository) queryPreferences(ctx context.Context, query string, args ...interface{}) ([]domain.NotificationPreference, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prefs := []domain.NotificationPreference{}
+
+	for rows.Next() {
+		var pref domain.NotificationPreference
+		var sensorTypes string
+
+		err := rows.Scan(
+			&pref.ID,
+			&pref.UserID,
+			&pref.Channel,
+			&pref.Enabled,
+			&pref.MinSeverity,
+			&sensorTypes,
+			&pref.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		pref.SensorTypes = splitList(sensorTypes)
+		prefs = append(prefs, pref)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// splitList convierte una lista separada por comas en un slice, vacío si no hay elementos
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, ",")
+}
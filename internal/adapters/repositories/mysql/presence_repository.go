@@ -0,0 +1,150 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type presenceRepository struct {
+	db *sql.DB
+}
+
+func NewPresenceRepository(db *sql.DB) ports.PresenceRepository {
+	return &presenceRepository{
+		db: db,
+	}
+}
+
+func (r *presenceRepository) RecordEvent(ctx context.Context, event *domain.PresenceEvent) error {
+	query := `
+		INSERT INTO device_presence (device_id, event, transport, created_at) 
+		VALUES (?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, event.DeviceID, event.Event, event.Transport, event.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	event.ID = uint(id)
+	return nil
+}
+
+func (r *presenceRepository) FindEvents(ctx context.Context, deviceID string, limit int) ([]domain.PresenceEvent, error) {
+	query := `
+		SELECT id, device_id, event, transport, created_at 
+		FROM device_presence 
+		WHERE device_id = ? 
+		ORDER BY id DESC 
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, deviceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []domain.PresenceEvent{}
+
+	for rows.Next() {
+		var event domain.PresenceEvent
+		if err := rows.Scan(&event.ID, &event.DeviceID, &event.Event, &event.Transport, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *presenceRepository) SetConnections(ctx context.Context, instanceID, deviceID string, connections int, at time.Time) error {
+	if connections <= 0 {
+		_, err := r.db.ExecContext(ctx, `DELETE FROM device_connections WHERE device_id = ? AND instance_id = ?`, deviceID, instanceID)
+		return err
+	}
+
+	query := `
+		INSERT INTO device_connections (device_id, instance_id, connections, updated_at) 
+		VALUES (?, ?, ?, ?) 
+		ON DUPLICATE KEY UPDATE connections = VALUES(connections), updated_at = VALUES(updated_at)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, deviceID, instanceID, connections, at)
+	return err
+}
+
+func (r *presenceRepository) TouchConnections(ctx context.Context, instanceID string, at time.Time) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE device_connections SET updated_at = ? WHERE instance_id = ?`, at, instanceID)
+	return err
+}
+
+func (r *presenceRepository) CountConnections(ctx context.Context, since time.Time) (map[string]int, error) {
+	query := `
+		SELECT device_id, SUM(connections) 
+		FROM device_connections 
+		WHERE updated_at >= ? 
+		GROUP BY device_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+
+	for rows.Next() {
+		var deviceID string
+		var connections int
+		if err := rows.Scan(&deviceID, &connections); err != nil {
+			return nil, err
+		}
+		counts[deviceID] = connections
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (r *presenceRepository) DeleteStaleConnections(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM device_connections WHERE updated_at < ?`, before)
+	return err
+}
+
+func (r *presenceRepository) SetOnline(ctx context.Context, deviceID string, online bool) (bool, error) {
+	query := `
+		UPDATE devices 
+		SET presence_online = ? 
+		WHERE id = ? AND presence_online <> ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, online, deviceID, online)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
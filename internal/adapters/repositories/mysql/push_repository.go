@@ -0,0 +1,156 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type pushRepository struct {
+	db *sql.DB
+}
+
+func NewPushRepository(db *sql.DB) ports.PushRepository {
+	return &pushRepository{
+		db: db,
+	}
+}
+
+func (r *pushRepository) SaveSubscription(ctx context.Context, sub *domain.PushSubscription) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// El bloqueo impide que otra petición registre el mismo endpoint a la vez
+	var id, ownerID uint
+	var createdAt time.Time
+	err = tx.QueryRowContext(
+		ctx,
+		`SELECT id, user_id, created_at FROM push_subscriptions WHERE endpoint = ? FOR UPDATE`,
+		sub.Endpoint,
+	).Scan(&id, &ownerID, &createdAt)
+
+	switch {
+	case err == nil:
+		// Un navegador que se vuelve a suscribir actualiza sus claves, pero un endpoint
+		// ajeno no cambia de dueño
+		if ownerID != sub.UserID {
+			return domain.ErrPushEndpointTaken
+		}
+		if _, err := tx.ExecContext(
+			ctx,
+			`UPDATE push_subscriptions SET p256dh = ?, auth = ? WHERE id = ?`,
+			sub.P256dh, sub.Auth, id,
+		); err != nil {
+			return err
+		}
+		sub.ID = id
+		sub.CreatedAt = createdAt
+
+	case errors.Is(err, sql.ErrNoRows):
+		now := time.Now()
+		result, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, created_at) VALUES (?, ?, ?, ?, ?)`,
+			sub.UserID, sub.Endpoint, sub.P256dh, sub.Auth, now,
+		)
+		if err != nil {
+			return err
+		}
+		insertID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		sub.ID = uint(insertID)
+		sub.CreatedAt = now
+
+	default:
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *pushRepository) FindSubscriptionsByUser(ctx context.Context, userID uint) ([]domain.PushSubscription, error) {
+	query := `
+		SELECT id, user_id, endpoint, p256dh, auth, created_at 
+		FROM push_subscriptions 
+		WHERE user_id = ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []domain.PushSubscription{}
+
+	for rows.Next() {
+		var sub domain.PushSubscription
+		err := rows.Scan(&sub.ID, &sub.UserID, &sub.Endpoint, &sub.P256dh, &sub.Auth, &sub.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (r *pushRepository) DeleteSubscription(ctx context.Context, userID uint, endpoint string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM push_subscriptions WHERE user_id = ? AND endpoint = ?`, userID, endpoint)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("suscripción no encontrada")
+	}
+
+	return nil
+}
+
+func (r *pushRepository) DeleteSubscriptionByID(ctx context.Context, id uint) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM push_subscriptions WHERE id = ?`, id)
+	return err
+}
+
+func (r *pushRepository) GetVAPIDKeys(ctx context.Context) (string, string, error) {
+	var publicKey, privateKey string
+
+	err := r.db.QueryRowContext(ctx, `SELECT public_key, private_key FROM vapid_keys WHERE id = 1`).Scan(&publicKey, &privateKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+
+	return publicKey, privateKey, nil
+}
+
+func (r *pushRepository) SaveVAPIDKeys(ctx context.Context, publicKey, privateKey string) error {
+	query := `
+		INSERT INTO vapid_keys (id, public_key, private_key, created_at) 
+		VALUES (1, ?, ?, ?) 
+		ON DUPLICATE KEY UPDATE public_key = VALUES(public_key), private_key = VALUES(private_key)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, publicKey, privateKey, time.Now())
+	return err
+}
@@ -0,0 +1,296 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type scheduleRepository struct {
+	db *sql.DB
+}
+
+func NewScheduleRepository(db *sql.DB) ports.ScheduleRepository {
+	return &scheduleRepository{
+		db: db,
+	}
+}
+
+const scheduleColumns = `id, name, enabled, kind, cron, sun_event, offset_minutes,
+	actuator_id, action, duty_cycle, duration_seconds,
+	skip_device_id, skip_sensor_type, skip_operator, skip_threshold,
+	next_run_at, last_run_at, created_by, created_at, updated_at`
+
+func (r *scheduleRepository) Create(ctx context.Context, schedule *domain.Schedule) error {
+	query := `
+		INSERT INTO schedules (name, enabled, kind, cron, sun_event, offset_minutes,
+			actuator_id, action, duty_cycle, duration_seconds,
+			skip_device_id, skip_sensor_type, skip_operator, skip_threshold,
+			next_run_at, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	args := []interface{}{
+		schedule.Name, schedule.Enabled, schedule.Kind, schedule.Cron, schedule.SunEvent, schedule.OffsetMinutes,
+		schedule.ActuatorID, schedule.Action, schedule.DutyCycle, schedule.DurationSeconds,
+	}
+	args = append(args, skipConditionArgs(schedule.SkipIf)...)
+	args = append(args, schedule.NextRunAt, schedule.CreatedBy, schedule.CreatedAt, schedule.UpdatedAt)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	schedule.ID = uint(id)
+	return nil
+}
+
+func (r *scheduleRepository) Update(ctx context.Context, schedule *domain.Schedule) error {
+	query := `
+		UPDATE schedules
+		SET name = ?, enabled = ?, kind = ?, cron = ?, sun_event = ?, offset_minutes = ?,
+			actuator_id = ?, action = ?, duty_cycle = ?, duration_seconds = ?,
+			skip_device_id = ?, skip_sensor_type = ?, skip_operator = ?, skip_threshold = ?,
+			next_run_at = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	args := []interface{}{
+		schedule.Name, schedule.Enabled, schedule.Kind, schedule.Cron, schedule.SunEvent, schedule.OffsetMinutes,
+		schedule.ActuatorID, schedule.Action, schedule.DutyCycle, schedule.DurationSeconds,
+	}
+	args = append(args, skipConditionArgs(schedule.SkipIf)...)
+	args = append(args, schedule.NextRunAt, schedule.UpdatedAt, schedule.ID)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("programación no encontrada")
+	}
+
+	return nil
+}
+
+func (r *scheduleRepository) FindAll(ctx context.Context) ([]domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules ORDER BY id`
+	return r.querySchedules(ctx, query)
+}
+
+func (r *scheduleRepository) FindByID(ctx context.Context, id uint) (*domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = ?`
+
+	schedule, err := scanSchedule(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("programación no encontrada")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (r *scheduleRepository) Delete(ctx context.Context, id uint) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM schedules WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("programación no encontrada")
+	}
+
+	return nil
+}
+
+func (r *scheduleRepository) FindDue(ctx context.Context, at time.Time) ([]domain.Schedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM schedules
+		WHERE enabled = TRUE AND next_run_at IS NOT NULL AND next_run_at <= ?
+		ORDER BY next_run_at
+	`
+	return r.querySchedules(ctx, query, at)
+}
+
+func (r *scheduleRepository) Advance(ctx context.Context, id uint, previous time.Time, next *time.Time, ranAt time.Time) (bool, error) {
+	query := `
+		UPDATE schedules
+		SET next_run_at = ?, last_run_at = ?
+		WHERE id = ? AND next_run_at = ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, next, ranAt, id, previous)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *scheduleRepository) RecordRun(ctx context.Context, run *domain.ScheduleRun) error {
+	query := `
+		INSERT INTO schedule_runs (schedule_id, status, scheduled_for, command_id, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		run.ScheduleID, run.Status, run.ScheduledFor, run.CommandID, run.Reason, run.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	run.ID = uint(id)
+	return nil
+}
+
+func (r *scheduleRepository) FindRuns(ctx context.Context, scheduleID uint, limit int) ([]domain.ScheduleRun, error) {
+	query := `
+		SELECT id, schedule_id, status, scheduled_for, command_id, reason, created_at
+		FROM schedule_runs
+		WHERE schedule_id = ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, scheduleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []domain.ScheduleRun{}
+
+	for rows.Next() {
+		var run domain.ScheduleRun
+		var commandID sql.NullInt64
+
+		err := rows.Scan(
+			&run.ID, &run.ScheduleID, &run.Status, &run.ScheduledFor,
+			&commandID, &run.Reason, &run.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if commandID.Valid {
+			id := uint(commandID.Int64)
+			run.CommandID = &id
+		}
+		runs = append(runs, run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
+
+func (r *scheduleRepository) querySchedules(ctx context.Context, query string, args ...interface{}) ([]domain.Schedule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []domain.Schedule{}
+
+	for rows.Next() {
+		schedule, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, *schedule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// skipConditionArgs devuelve las columnas de la condición de omisión, NULL si no hay
+func skipConditionArgs(condition *domain.ScheduleSkipCondition) []interface{} {
+	if condition == nil {
+		return []interface{}{nil, nil, nil, nil}
+	}
+	return []interface{}{condition.DeviceID, condition.SensorType, condition.Operator, condition.Threshold}
+}
+
+func scanSchedule(row rowScanner) (*domain.Schedule, error) {
+	var schedule domain.Schedule
+	var dutyCycle, duration sql.NullInt64
+	var skipDeviceID, skipSensorType, skipOperator sql.NullString
+	var skipThreshold sql.NullFloat64
+	var nextRunAt, lastRunAt sql.NullTime
+
+	err := row.Scan(
+		&schedule.ID, &schedule.Name, &schedule.Enabled, &schedule.Kind, &schedule.Cron,
+		&schedule.SunEvent, &schedule.OffsetMinutes,
+		&schedule.ActuatorID, &schedule.Action, &dutyCycle, &duration,
+		&skipDeviceID, &skipSensorType, &skipOperator, &skipThreshold,
+		&nextRunAt, &lastRunAt, &schedule.CreatedBy, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if dutyCycle.Valid {
+		value := int(dutyCycle.Int64)
+		schedule.DutyCycle = &value
+	}
+	if duration.Valid {
+		value := int(duration.Int64)
+		schedule.DurationSeconds = &value
+	}
+	if skipDeviceID.Valid {
+		schedule.SkipIf = &domain.ScheduleSkipCondition{
+			DeviceID:   skipDeviceID.String,
+			SensorType: skipSensorType.String,
+			Operator:   skipOperator.String,
+			Threshold:  skipThreshold.Float64,
+		}
+	}
+	if nextRunAt.Valid {
+		schedule.NextRunAt = &nextRunAt.Time
+	}
+	if lastRunAt.Valid {
+		schedule.LastRunAt = &lastRunAt.Time
+	}
+
+	return &schedule, nil
+}
@@ -3,13 +3,21 @@ package mysql
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"strconv"
 	"time"
 
 	"ApiSmart/internal/core/domain"
 	"ApiSmart/internal/core/ports"
 )
 
+// Tamaños de página para el listado de alertas
+const (
+	defaultAlertPageSize = 50
+	maxAlertPageSize     = 500
+)
+
 type sensorRepository struct {
 	db *sql.DB
 }
@@ -22,8 +30,8 @@ func NewSensorRepository(db *sql.DB) ports.SensorRepository {
 
 func (r *sensorRepository) SaveSensorData(ctx context.Context, data *domain.SensorData) error {
 	query := `
-		INSERT INTO sensor_data (temperatura_dht, luz, humedad, humo, created_at) 
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO sensor_data (device_id, temperatura_dht, luz, humedad, humo, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
 	now := time.Now()
@@ -32,6 +40,7 @@ func (r *sensorRepository) SaveSensorData(ctx context.Context, data *domain.Sens
 	result, err := r.db.ExecContext(
 		ctx,
 		query,
+		data.DeviceID,
 		data.TemperaturaDHT,
 		data.Luz,
 		data.Humedad,
@@ -54,7 +63,7 @@ func (r *sensorRepository) SaveSensorData(ctx context.Context, data *domain.Sens
 
 func (r *sensorRepository) GetAllSensorData(ctx context.Context) ([]domain.SensorData, error) {
 	query := `
-		SELECT id, temperatura_dht, luz, humedad, humo, created_at 
+		SELECT id, device_id, temperatura_dht, luz, humedad, humo, created_at 
 		FROM sensor_data 
 		ORDER BY created_at DESC 
 		LIMIT 1000
@@ -74,6 +83,7 @@ func (r *sensorRepository) GetAllSensorData(ctx context.Context) ([]domain.Senso
 
 		err := rows.Scan(
 			&data.ID,
+			&data.DeviceID,
 			&data.TemperaturaDHT,
 			&data.Luz,
 			&data.Humedad,
@@ -98,7 +108,7 @@ func (r *sensorRepository) GetAllSensorData(ctx context.Context) ([]domain.Senso
 
 func (r *sensorRepository) GetLatestSensorData(ctx context.Context) (*domain.SensorData, error) {
 	query := `
-		SELECT id, temperatura_dht, luz, humedad, humo, created_at 
+		SELECT id, device_id, temperatura_dht, luz, humedad, humo, created_at 
 		FROM sensor_data 
 		ORDER BY created_at DESC 
 		LIMIT 1
@@ -111,6 +121,7 @@ func (r *sensorRepository) GetLatestSensorData(ctx context.Context) (*domain.Sen
 
 	err := row.Scan(
 		&data.ID,
+		&data.DeviceID,
 		&data.TemperaturaDHT,
 		&data.Luz,
 		&data.Humedad,
@@ -130,23 +141,105 @@ func (r *sensorRepository) GetLatestSensorData(ctx context.Context) (*domain.Sen
 	return &data, nil
 }
 
-func (r *sensorRepository) SaveAlert(ctx context.Context, alert *domain.Alert) error {
+func (r *sensorRepository) GetLatestSensorDataByDevice(ctx context.Context) ([]domain.SensorData, error) {
 	query := `
-		INSERT INTO alerts (sensor_id, sensor_type, value, message, is_read, created_at) 
+		SELECT s.id, s.device_id, s.temperatura_dht, s.luz, s.humedad, s.humo, s.created_at 
+		FROM sensor_data s 
+		JOIN (SELECT MAX(id) AS id FROM sensor_data GROUP BY device_id) latest ON latest.id = s.id 
+		ORDER BY s.device_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	readings := []domain.SensorData{}
+
+	for rows.Next() {
+		var data domain.SensorData
+		err := rows.Scan(
+			&data.ID,
+			&data.DeviceID,
+			&data.TemperaturaDHT,
+			&data.Luz,
+			&data.Humedad,
+			&data.Humo,
+			&data.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		readings = append(readings, data)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return readings, nil
+}
+
+func (r *sensorRepository) SaveMetricReading(ctx context.Context, reading *domain.MetricReading) error {
+	query := `
+		INSERT INTO metric_readings (device_id, sensor_type, value, unit, created_at, device_time) 
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
+	result, err := r.db.ExecContext(ctx, query, reading.DeviceID, reading.SensorType, reading.Value, reading.Unit, reading.CreatedAt, reading.DeviceTime)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	reading.ID = uint(id)
+	return nil
+}
+
+func (r *sensorRepository) SaveAlert(ctx context.Context, alert *domain.Alert) error {
+	query := `
+		INSERT INTO alerts 
+			(sensor_id, device_id, sensor_type, rule, severity, state, value, message, message_key, message_params, is_read, silenced, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
 	now := time.Now()
 	alert.CreatedAt = now
+	if alert.State == "" {
+		alert.State = domain.AlertStateActive
+	}
+
+	params, err := json.Marshal(alert.MessageParams)
+	if err != nil {
+		return err
+	}
+
+	// Las alertas que no proceden de una lectura guardada no tienen sensor_id
+	var sensorID sql.NullInt64
+	if alert.SensorID != 0 {
+		sensorID = sql.NullInt64{Int64: int64(alert.SensorID), Valid: true}
+	}
 
 	result, err := r.db.ExecContext(
 		ctx,
 		query,
-		alert.SensorID,
+		sensorID,
+		alert.DeviceID,
 		alert.SensorType,
+		alert.Rule,
+		alert.Severity,
+		alert.State,
 		alert.Value,
 		alert.Message,
+		alert.MessageKey,
+		string(params),
 		alert.IsRead,
+		alert.Silenced,
 		now,
 	)
 
@@ -163,25 +256,34 @@ func (r *sensorRepository) SaveAlert(ctx context.Context, alert *domain.Alert) e
 	return nil
 }
 
-func (r *sensorRepository) GetAlerts(ctx context.Context, isRead *bool) ([]domain.Alert, error) {
-	var query string
-	var args []interface{}
-
+func (r *sensorRepository) GetAlerts(ctx context.Context, filter domain.AlertFilter) (*domain.AlertPage, error) {
 	// Base query
-	query = `
-		SELECT id, sensor_id, sensor_type, value, message, is_read, created_at 
+	query := `
+		SELECT id, sensor_id, device_id, sensor_type, rule, severity, state, value, message, message_key, message_params, 
+			is_read, silenced, created_at, acknowledged_at, resolved_at 
 		FROM alerts 
 		WHERE 1=1
 	`
+	where, args := alertFilterClause(filter)
+	query += where
 
-	// Filtrar por estado de lectura si se especifica
-	if isRead != nil {
-		query += " AND is_read = ?"
-		args = append(args, *isRead)
+	// Paginación por cursor: las alertas más recientes tienen IDs mayores
+	if filter.Cursor > 0 {
+		query += " AND id < ?"
+		args = append(args, filter.Cursor)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAlertPageSize
+	}
+	if limit > maxAlertPageSize {
+		limit = maxAlertPageSize
 	}
 
-	// Ordenar por fecha de creación, más recientes primero
-	query += " ORDER BY created_at DESC"
+	// Se pide un elemento extra para saber si existe una página siguiente
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit+1)
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -189,35 +291,119 @@ func (r *sensorRepository) GetAlerts(ctx context.Context, isRead *bool) ([]domai
 	}
 	defer rows.Close()
 
-	var alerts []domain.Alert
+	alerts := []domain.Alert{}
 
 	for rows.Next() {
-		var alert domain.Alert
-		var createdAtStr string
-
-		err := rows.Scan(
-			&alert.ID,
-			&alert.SensorID,
-			&alert.SensorType,
-			&alert.Value,
-			&alert.Message,
-			&alert.IsRead,
-			&createdAtStr,
-		)
-
+		alert, err := scanAlert(rows)
 		if err != nil {
 			return nil, err
 		}
+		alerts = append(alerts, *alert)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	page := &domain.AlertPage{Alerts: alerts}
+	if len(alerts) > limit {
+		page.Alerts = alerts[:limit]
+		page.NextCursor = strconv.FormatUint(uint64(page.Alerts[limit-1].ID), 10)
+	}
 
-		alert.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
-		alerts = append(alerts, alert)
+	return page, nil
+}
+
+func (r *sensorRepository) GetAlertStats(ctx context.Context, filter domain.AlertFilter, topDevices int) (*domain.AlertStats, error) {
+	where, args := alertFilterClause(filter)
+	stats := &domain.AlertStats{
+		CountsByTypeAndDay: []domain.AlertDailyCount{},
+		TopDevices:         []domain.DeviceAlertCount{},
 	}
 
+	// Alertas por tipo y día
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT sensor_type, DATE_FORMAT(created_at, '%Y-%m-%d') AS day, COUNT(*) 
+		FROM alerts 
+		WHERE 1=1`+where+`
+		GROUP BY sensor_type, day 
+		ORDER BY day DESC, sensor_type
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var count domain.AlertDailyCount
+		if err := rows.Scan(&count.SensorType, &count.Day, &count.Count); err != nil {
+			return nil, err
+		}
+		stats.Total += count.Count
+		stats.CountsByTypeAndDay = append(stats.CountsByTypeAndDay, count)
+	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return alerts, nil
+	// Tiempo medio hasta el reconocimiento y la resolución
+	var mtta, mttr sql.NullFloat64
+	err = r.db.QueryRowContext(ctx, `
+		SELECT AVG(TIMESTAMPDIFF(SECOND, created_at, acknowledged_at)), 
+			AVG(TIMESTAMPDIFF(SECOND, created_at, resolved_at)) 
+		FROM alerts 
+		WHERE 1=1`+where, args...).Scan(&mtta, &mttr)
+	if err != nil {
+		return nil, err
+	}
+	stats.MeanTimeToAcknowledgeSeconds = mtta.Float64
+	stats.MeanTimeToResolveSeconds = mttr.Float64
+
+	// Dispositivos con más alertas
+	deviceRows, err := r.db.QueryContext(ctx, `
+		SELECT device_id, COUNT(*) AS total 
+		FROM alerts 
+		WHERE 1=1`+where+`
+		GROUP BY device_id 
+		ORDER BY total DESC 
+		LIMIT ?
+	`, append(args, topDevices)...)
+	if err != nil {
+		return nil, err
+	}
+	defer deviceRows.Close()
+
+	for deviceRows.Next() {
+		var count domain.DeviceAlertCount
+		if err := deviceRows.Scan(&count.DeviceID, &count.Count); err != nil {
+			return nil, err
+		}
+		stats.TopDevices = append(stats.TopDevices, count)
+	}
+	if err = deviceRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (r *sensorRepository) FindAlertByID(ctx context.Context, alertID uint) (*domain.Alert, error) {
+	query := `
+		SELECT id, sensor_id, device_id, sensor_type, rule, severity, state, value, message, message_key, message_params, 
+			is_read, silenced, created_at, acknowledged_at, resolved_at 
+		FROM alerts 
+		WHERE id = ?
+	`
+
+	alert, err := scanAlert(r.db.QueryRowContext(ctx, query, alertID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("alerta no encontrada")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return alert, nil
 }
 
 func (r *sensorRepository) MarkAlertAsRead(ctx context.Context, alertID uint) error {
@@ -226,3 +412,312 @@ func (r *sensorRepository) MarkAlertAsRead(ctx context.Context, alertID uint) er
 	_, err := r.db.ExecContext(ctx, query, alertID)
 	return err
 }
+
+func (r *sensorRepository) UpdateAlertState(ctx context.Context, alertID uint, state string) error {
+	var query string
+	now := time.Now()
+	args := []interface{}{now, alertID}
+
+	switch state {
+	case domain.AlertStateAcknowledged:
+		// Una alerta resuelta no vuelve al estado reconocida
+		query = `
+			UPDATE alerts 
+			SET state = IF(state = 'resolved', state, 'acknowledged'), 
+				is_read = true, 
+				acknowledged_at = COALESCE(acknowledged_at, ?) 
+			WHERE id = ?
+		`
+	case domain.AlertStateResolved:
+		query = `
+			UPDATE alerts 
+			SET state = 'resolved', 
+				is_read = true, 
+				acknowledged_at = COALESCE(acknowledged_at, ?), 
+				resolved_at = COALESCE(resolved_at, ?) 
+			WHERE id = ?
+		`
+		args = []interface{}{now, now, alertID}
+	default:
+		return errors.New("estado de alerta inválido")
+	}
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (r *sensorRepository) ResolveActiveAlerts(ctx context.Context, deviceID, rule string) error {
+	query := `
+		UPDATE alerts 
+		SET state = 'resolved', resolved_at = ? 
+		WHERE device_id = ? AND rule = ? AND state <> 'resolved'
+	`
+
+	_, err := r.db.ExecContext(ctx, query, time.Now(), deviceID, rule)
+	return err
+}
+
+// alertFilterClause construye las condiciones WHERE para los filtros de alertas
+func alertFilterClause(filter domain.AlertFilter) (string, []interface{}) {
+	var clause string
+	var args []interface{}
+
+	if filter.SensorType != "" {
+		clause += " AND sensor_type = ?"
+		args = append(args, filter.SensorType)
+	}
+	if filter.DeviceID != "" {
+		clause += " AND device_id = ?"
+		args = append(args, filter.DeviceID)
+	}
+	if filter.Severity != "" {
+		clause += " AND severity = ?"
+		args = append(args, filter.Severity)
+	}
+	if filter.State != "" {
+		clause += " AND state = ?"
+		args = append(args, filter.State)
+	}
+	// Filtrar por estado de lectura si se especifica
+	if filter.IsRead != nil {
+		clause += " AND is_read = ?"
+		args = append(args, *filter.IsRead)
+	}
+	if filter.Silenced != nil {
+		clause += " AND silenced = ?"
+		args = append(args, *filter.Silenced)
+	}
+	if filter.From != nil {
+		clause += " AND created_at >= ?"
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		clause += " AND created_at < ?"
+		args = append(args, *filter.To)
+	}
+	accessClause, accessArgs := deviceAccessClause(filter.Access)
+	clause += accessClause
+	args = append(args, accessArgs...)
+
+	return clause, args
+}
+
+// deviceAccessClause limita la consulta a los dispositivos visibles; sin permisos no se
+// restringe
+func deviceAccessClause(access *domain.DeviceAccess) (string, []interface{}) {
+	if access == nil || access.All {
+		return "", nil
+	}
+
+	ids := access.IDs()
+	if len(ids) == 0 {
+		return " AND 1=0", nil
+	}
+	return " AND device_id IN (" + placeholders(len(ids)) + ")", stringArgs(ids)
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAlert lee una fila de la tabla alerts
+func scanAlert(row rowScanner) (*domain.Alert, error) {
+	var alert domain.Alert
+	var sensorID sql.NullInt64
+	var acknowledgedAt, resolvedAt sql.NullTime
+	var params sql.NullString
+
+	err := row.Scan(
+		&alert.ID,
+		&sensorID,
+		&alert.DeviceID,
+		&alert.SensorType,
+		&alert.Rule,
+		&alert.Severity,
+		&alert.State,
+		&alert.Value,
+		&alert.Message,
+		&alert.MessageKey,
+		&params,
+		&alert.IsRead,
+		&alert.Silenced,
+		&alert.CreatedAt,
+		&acknowledgedAt,
+		&resolvedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	alert.SensorID = uint(sensorID.Int64)
+
+	if params.Valid && params.String != "" {
+		if err := json.Unmarshal([]byte(params.String), &alert.MessageParams); err != nil {
+			return nil, err
+		}
+	}
+	if acknowledgedAt.Valid {
+		alert.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	if resolvedAt.Valid {
+		alert.ResolvedAt = &resolvedAt.Time
+	}
+
+	return &alert, nil
+}
+
+func (r *sensorRepository) CountAlertsByType(ctx context.Context, access *domain.DeviceAccess, from, to time.Time) ([]domain.AlertTypeCount, error) {
+	where, args := alertFilterClause(domain.AlertFilter{From: &from, To: &to, Access: access})
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT sensor_type, COUNT(*) AS total 
+		FROM alerts 
+		WHERE 1=1`+where+`
+		GROUP BY sensor_type 
+		ORDER BY total DESC, sensor_type
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []domain.AlertTypeCount{}
+	for rows.Next() {
+		var count domain.AlertTypeCount
+		if err := rows.Scan(&count.SensorType, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (r *sensorRepository) GetMetricSummaries(ctx context.Context, access *domain.DeviceAccess, from, to time.Time) ([]domain.MetricSummary, error) {
+	where, args := deviceAccessClause(access)
+	query := `
+		SELECT COUNT(*), 
+			COALESCE(MIN(temperatura_dht), 0), COALESCE(MAX(temperatura_dht), 0), COALESCE(AVG(temperatura_dht), 0), 
+			COALESCE(MIN(luz), 0), COALESCE(MAX(luz), 0), COALESCE(AVG(luz), 0), 
+			COALESCE(MIN(humedad), 0), COALESCE(MAX(humedad), 0), COALESCE(AVG(humedad), 0), 
+			COALESCE(MIN(humo), 0), COALESCE(MAX(humo), 0), COALESCE(AVG(humo), 0) 
+		FROM sensor_data 
+		WHERE created_at >= ? AND created_at < ?` + where
+
+	var count int
+	temperatura := domain.MetricSummary{Metric: "temperatura"}
+	luz := domain.MetricSummary{Metric: "luz"}
+	humedad := domain.MetricSummary{Metric: "humedad"}
+	humo := domain.MetricSummary{Metric: "humo"}
+
+	err := r.db.QueryRowContext(ctx, query, append([]interface{}{from, to}, args...)...).Scan(
+		&count,
+		&temperatura.Min, &temperatura.Max, &temperatura.Avg,
+		&luz.Min, &luz.Max, &luz.Avg,
+		&humedad.Min, &humedad.Max, &humedad.Avg,
+		&humo.Min, &humo.Max, &humo.Avg,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if count == 0 {
+		return []domain.MetricSummary{}, nil
+	}
+
+	return []domain.MetricSummary{temperatura, luz, humedad, humo}, nil
+}
+
+func (r *sensorRepository) GetDeviceActivity(ctx context.Context, access *domain.DeviceAccess, from, to time.Time) ([]domain.DeviceActivity, error) {
+	where, args := deviceAccessClause(access)
+
+	// Incluye los dispositivos sin lecturas en el periodo para detectar los inactivos
+	query := `
+		SELECT device_id, SUM(created_at >= ?), MAX(created_at) 
+		FROM sensor_data 
+		WHERE created_at < ?` + where + `
+		GROUP BY device_id 
+		ORDER BY device_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, append([]interface{}{from, to}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []domain.DeviceActivity{}
+
+	for rows.Next() {
+		var device domain.DeviceActivity
+		if err := rows.Scan(&device.DeviceID, &device.ReadingCount, &device.LastReadingAt); err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// Columnas de sensor_data con cada métrica
+var sensorDataColumns = map[string]string{
+	"temperatura": "temperatura_dht",
+	"luz":         "luz",
+	"humedad":     "humedad",
+	"humo":        "humo",
+}
+
+func (r *sensorRepository) GetLatestMetricReading(ctx context.Context, deviceID, sensorType string) (*domain.MetricReading, error) {
+	var latest *domain.MetricReading
+
+	if column, ok := sensorDataColumns[sensorType]; ok {
+		query := `
+			SELECT id, ` + column + `, created_at 
+			FROM sensor_data 
+			WHERE device_id = ? 
+			ORDER BY created_at DESC, id DESC 
+			LIMIT 1
+		`
+
+		var reading domain.MetricReading
+		err := r.db.QueryRowContext(ctx, query, deviceID).Scan(&reading.ID, &reading.Value, &reading.CreatedAt)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if err == nil {
+			latest = &reading
+		}
+	}
+
+	query := `
+		SELECT id, value, unit, created_at 
+		FROM metric_readings 
+		WHERE device_id = ? AND sensor_type = ? 
+		ORDER BY created_at DESC, id DESC 
+		LIMIT 1
+	`
+
+	var reading domain.MetricReading
+	err := r.db.QueryRowContext(ctx, query, deviceID, sensorType).Scan(&reading.ID, &reading.Value, &reading.Unit, &reading.CreatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil && (latest == nil || reading.CreatedAt.After(latest.CreatedAt)) {
+		latest = &reading
+	}
+
+	if latest == nil {
+		return nil, domain.ErrNoReadings
+	}
+
+	latest.DeviceID = deviceID
+	latest.SensorType = sensorType
+	return latest, nil
+}
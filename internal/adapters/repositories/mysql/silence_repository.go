@@ -0,0 +1,294 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type silenceRepository struct {
+	db *sql.DB
+}
+
+func NewSilenceRepository(db *sql.DB) ports.SilenceRepository {
+	return &silenceRepository{
+		db: db,
+	}
+}
+
+func (r *silenceRepository) CreateSilence(ctx context.Context, silence *domain.Silence) error {
+	query := `
+		INSERT INTO silences (device_id, sensor_type, rule, starts_at, ends_at, comment, created_by, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	silence.CreatedAt = now
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		silence.DeviceID,
+		silence.SensorType,
+		silence.Rule,
+		silence.StartsAt,
+		silence.EndsAt,
+		silence.Comment,
+		silence.CreatedBy,
+		now,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	silence.ID = uint(id)
+	return nil
+}
+
+func (r *silenceRepository) FindSilences(ctx context.Context, activeAt *time.Time) ([]domain.Silence, error) {
+	query := `
+		SELECT id, device_id, sensor_type, rule, starts_at, ends_at, comment, created_by, created_at 
+		FROM silences
+	`
+	var args []interface{}
+
+	// Solo los silencios vigentes en el instante indicado
+	if activeAt != nil {
+		query += " WHERE starts_at <= ? AND ends_at > ?"
+		args = append(args, *activeAt, *activeAt)
+	}
+	query += " ORDER BY starts_at DESC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	silences := []domain.Silence{}
+
+	for rows.Next() {
+		silence, err := scanSilence(rows)
+		if err != nil {
+			return nil, err
+		}
+		silences = append(silences, *silence)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return silences, nil
+}
+
+func (r *silenceRepository) FindSilenceByID(ctx context.Context, id uint) (*domain.Silence, error) {
+	query := `
+		SELECT id, device_id, sensor_type, rule, starts_at, ends_at, comment, created_by, created_at 
+		FROM silences
+		WHERE id = ?
+	`
+
+	silence, err := scanSilence(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("silencio no encontrado")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return silence, nil
+}
+
+func (r *silenceRepository) ExpireSilence(ctx context.Context, id uint, at time.Time) error {
+	result, err := r.db.ExecContext(ctx, `UPDATE silences SET ends_at = LEAST(ends_at, ?) WHERE id = ?`, at, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("silencio no encontrado o ya expirado")
+	}
+
+	return nil
+}
+
+func (r *silenceRepository) CreateMaintenanceWindow(ctx context.Context, window *domain.MaintenanceWindow) error {
+	query := `
+		INSERT INTO maintenance_windows 
+			(name, device_id, sensor_type, rule, days_of_week, start_time, duration_minutes, timezone, created_by, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	window.CreatedAt = now
+
+	days := make([]string, len(window.DaysOfWeek))
+	for i, day := range window.DaysOfWeek {
+		days[i] = strconv.Itoa(day)
+	}
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		window.Name,
+		window.DeviceID,
+		window.SensorType,
+		window.Rule,
+		strings.Join(days, ","),
+		window.StartTime,
+		window.DurationMinutes,
+		window.Timezone,
+		window.CreatedBy,
+		now,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	window.ID = uint(id)
+	return nil
+}
+
+func (r *silenceRepository) FindMaintenanceWindows(ctx context.Context) ([]domain.MaintenanceWindow, error) {
+	query := `
+		SELECT id, name, device_id, sensor_type, rule, days_of_week, start_time, duration_minutes, timezone, created_by, created_at 
+		FROM maintenance_windows 
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []domain.MaintenanceWindow{}
+
+	for rows.Next() {
+		window, err := scanMaintenanceWindow(rows)
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, *window)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return windows, nil
+}
+
+func (r *silenceRepository) FindMaintenanceWindowByID(ctx context.Context, id uint) (*domain.MaintenanceWindow, error) {
+	query := `
+		SELECT id, name, device_id, sensor_type, rule, days_of_week, start_time, duration_minutes, timezone, created_by, created_at 
+		FROM maintenance_windows 
+		WHERE id = ?
+	`
+
+	window, err := scanMaintenanceWindow(r.db.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errors.New("ventana de mantenimiento no encontrada")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return window, nil
+}
+
+func (r *silenceRepository) DeleteMaintenanceWindow(ctx context.Context, id uint) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM maintenance_windows WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("ventana de mantenimiento no encontrada")
+	}
+
+	return nil
+}
+
+// scanSilence lee una fila de la tabla silences
+func scanSilence(row rowScanner) (*domain.Silence, error) {
+	var silence domain.Silence
+
+	err := row.Scan(
+		&silence.ID,
+		&silence.DeviceID,
+		&silence.SensorType,
+		&silence.Rule,
+		&silence.StartsAt,
+		&silence.EndsAt,
+		&silence.Comment,
+		&silence.CreatedBy,
+		&silence.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &silence, nil
+}
+
+// scanMaintenanceWindow lee una fila de la tabla maintenance_windows
+func scanMaintenanceWindow(row rowScanner) (*domain.MaintenanceWindow, error) {
+	var window domain.MaintenanceWindow
+	var days string
+
+	err := row.Scan(
+		&window.ID,
+		&window.Name,
+		&window.DeviceID,
+		&window.SensorType,
+		&window.Rule,
+		&days,
+		&window.StartTime,
+		&window.DurationMinutes,
+		&window.Timezone,
+		&window.CreatedBy,
+		&window.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	window.DaysOfWeek = []int{}
+	for _, day := range splitList(days) {
+		value, err := strconv.Atoi(day)
+		if err != nil {
+			return nil, err
+		}
+		window.DaysOfWeek = append(window.DaysOfWeek, value)
+	}
+
+	return &window, nil
+}
@@ -22,8 +22,8 @@ func NewUserRepository(db *sql.DB) ports.UserRepository {
 
 func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (username, email, password, created_at, updated_at) 
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO users (username, email, password, role, locale, created_at, updated_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 
 	result, err := r.db.ExecContext(
@@ -32,6 +32,8 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 		user.Username,
 		user.Email,
 		user.Password,
+		user.Role,
+		user.Locale,
 		user.CreatedAt,
 		user.UpdatedAt,
 	)
@@ -51,7 +53,7 @@ func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at 
+		SELECT id, username, email, password, role, locale, created_at, updated_at 
 		FROM users 
 		WHERE email = ?
 	`
@@ -66,6 +68,8 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*domain
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
+		&user.Locale,
 		&createdAt,
 		&updatedAt,
 	)
@@ -86,7 +90,7 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*domain
 
 func (r *userRepository) FindByID(ctx context.Context, id uint) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password, created_at, updated_at 
+		SELECT id, username, email, password, role, locale, created_at, updated_at 
 		FROM users 
 		WHERE id = ?
 	`
@@ -101,6 +105,8 @@ func (r *userRepository) FindByID(ctx context.Context, id uint) (*domain.User, e
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.Role,
+		&user.Locale,
 		&createdAt,
 		&updatedAt,
 	)
@@ -118,3 +124,35 @@ func (r *userRepository) FindByID(ctx context.Context, id uint) (*domain.User, e
 
 	return &user, nil
 }
+
+func (r *userRepository) UpdateLocale(ctx context.Context, id uint, locale string) error {
+	query := `UPDATE users SET locale = ?, updated_at = ? WHERE id = ?`
+
+	_, err := r.db.ExecContext(ctx, query, locale, time.Now(), id)
+	return err
+}
+
+func (r *userRepository) UpdateRole(ctx context.Context, id uint, role string) error {
+	query := `UPDATE users SET role = ?, updated_at = ? WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, role, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("usuario no encontrado")
+	}
+
+	return nil
+}
+
+func (r *userRepository) CountByRole(ctx context.Context, role string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = ?`, role).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,268 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type webhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) ports.WebhookRepository {
+	return &webhookRepository{
+		db: db,
+	}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *domain.Webhook) error {
+	query := `
+		INSERT INTO webhooks (user_id, url, event_types, secret, active, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	webhook.CreatedAt = now
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		webhook.UserID,
+		webhook.URL,
+		strings.Join(webhook.EventTypes, ","),
+		webhook.Secret,
+		webhook.Active,
+		now,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	webhook.ID = uint(id)
+	return nil
+}
+
+func (r *webhookRepository) FindByID(ctx context.Context, userID, id uint) (*domain.Webhook, error) {
+	query := `
+		SELECT id, user_id, url, event_types, secret, active, created_at 
+		FROM webhooks 
+		WHERE id = ? AND user_id = ?
+	`
+
+	webhook, err := scanWebhook(r.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("webhook no encontrado")
+		}
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (r *webhookRepository) FindByUser(ctx context.Context, userID uint) ([]domain.Webhook, error) {
+	query := `
+		SELECT id, user_id, url, event_types, secret, active, created_at 
+		FROM webhooks 
+		WHERE user_id = ?
+		ORDER BY id
+	`
+
+	return r.queryWebhooks(ctx, query, userID)
+}
+
+func (r *webhookRepository) FindActiveByEventType(ctx context.Context, eventType string) ([]domain.Webhook, error) {
+	query := `
+		SELECT id, user_id, url, event_types, secret, active, created_at 
+		FROM webhooks 
+		WHERE active = true AND FIND_IN_SET(?, event_types) > 0
+	`
+
+	return r.queryWebhooks(ctx, query, eventType)
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, userID, id uint) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = ? AND user_id = ?`, id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("webhook no encontrado")
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) SaveDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries 
+			(webhook_id, event_type, payload, attempt, status_code, error, success, duration_ms, created_at) 
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	delivery.CreatedAt = now
+
+	result, err := r.db.ExecContext(
+		ctx,
+		query,
+		delivery.WebhookID,
+		delivery.EventType,
+		delivery.Payload,
+		delivery.Attempt,
+		delivery.StatusCode,
+		delivery.Error,
+		delivery.Success,
+		delivery.DurationMs,
+		now,
+	)
+
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	delivery.ID = uint(id)
+	return nil
+}
+
+func (r *webhookRepository) FindDeliveryByID(ctx context.Context, userID, id uint) (*domain.WebhookDelivery, error) {
+	query := `
+		SELECT d.id, d.webhook_id, d.event_type, d.payload, d.attempt, d.status_code, d.error, d.success, d.duration_ms, d.created_at 
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.id = ? AND w.user_id = ?
+	`
+
+	delivery, err := scanWebhookDelivery(r.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errors.New("entrega no encontrada")
+		}
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (r *webhookRepository) FindDeliveries(ctx context.Context, webhookID uint, limit int) ([]domain.WebhookDelivery, error) {
+	query := `
+		SELECT id, webhook_id, event_type, payload, attempt, status_code, error, success, duration_ms, created_at 
+		FROM webhook_deliveries 
+		WHERE webhook_id = ? 
+		ORDER BY id DESC 
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []domain.WebhookDelivery{}
+
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) queryWebhooks(ctx context.Context, query string, args ...interface{}) ([]domain.Webhook, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []domain.Webhook{}
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+// scanWebhook lee una fila de la tabla webhooks
+func scanWebhook(row rowScanner) (*domain.Webhook, error) {
+	var webhook domain.Webhook
+	var eventTypes string
+
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&eventTypes,
+		&webhook.Secret,
+		&webhook.Active,
+		&webhook.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.EventTypes = splitList(eventTypes)
+	return &webhook, nil
+}
+
+// scanWebhookDelivery lee una fila de la tabla webhook_deliveries
+func scanWebhookDelivery(row rowScanner) (*domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Attempt,
+		&delivery.StatusCode,
+		&delivery.Error,
+		&delivery.Success,
+		&delivery.DurationMs,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Tipos de actuador
+const (
+	ActuatorTypePump  = "pump"
+	ActuatorTypeFan   = "fan"
+	ActuatorTypeLight = "light"
+)
+
+// Estados de un actuador
+const (
+	ActuatorStateOn  = "on"
+	ActuatorStateOff = "off"
+)
+
+// Acciones de los comandos
+const (
+	CommandActionOn        = "on"
+	CommandActionOff       = "off"
+	CommandActionDutyCycle = "duty_cycle" // Potencia en porcentaje; 0 apaga
+	CommandActionRun       = "run"        // Encender durante DurationSeconds y apagar
+)
+
+// Estados de un comando
+const (
+	CommandStatusPending = "pending" // Emitido, sin entregar ni confirmar
+	CommandStatusSent    = "sent"    // Entregado al dispositivo, sin confirmar
+	CommandStatusAcked   = "acked"   // El dispositivo lo ejecutó
+	CommandStatusFailed  = "failed"  // El dispositivo informó de un error
+	CommandStatusTimeout = "timeout" // No se confirmó a tiempo
+)
+
+// ErrCommandExpired se devuelve al confirmar un comando que ya no espera confirmación
+var ErrCommandExpired = errors.New("el comando ya no espera confirmación")
+
+// Actuator es una salida de un dispositivo (bomba, ventilador o luz) en un canal
+type Actuator struct {
+	ID         uint       `json:"id"`
+	DeviceID   string     `json:"device_id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Channel    int        `json:"channel"`
+	State      string     `json:"state"`
+	DutyCycle  int        `json:"duty_cycle"`            // Potencia en porcentaje
+	StateUntil *time.Time `json:"state_until,omitempty"` // Fin del encendido temporal en curso
+	UpdatedAt  time.Time  `json:"updated_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateActuatorRequest struct {
+	DeviceID string `json:"device_id" binding:"required"`
+	Name     string `json:"name" binding:"required"`
+	Type     string `json:"type" binding:"required,oneof=pump fan light"`
+	Channel  int    `json:"channel" binding:"min=0"`
+}
+
+// ActuatorCommand es una orden para un actuador; el estado refleja si el dispositivo la
+// recibió y confirmó antes de ExpiresAt
+type ActuatorCommand struct {
+	ID              uint       `json:"id"`
+	ActuatorID      uint       `json:"actuator_id"`
+	DeviceID        string     `json:"device_id"`
+	Channel         int        `json:"channel"`
+	Action          string     `json:"action"`
+	DutyCycle       *int       `json:"duty_cycle,omitempty"`
+	DurationSeconds *int       `json:"duration_seconds,omitempty"`
+	Status          string     `json:"status"`
+	Error           string     `json:"error,omitempty"`
+	IssuedBy        uint       `json:"issued_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	SentAt          *time.Time `json:"sent_at,omitempty"`
+	AckedAt         *time.Time `json:"acked_at,omitempty"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+}
+
+type ActuatorCommandRequest struct {
+	Action          string `json:"action" binding:"required,oneof=on off duty_cycle run"`
+	DutyCycle       *int   `json:"duty_cycle" binding:"omitempty,min=0,max=100"`
+	DurationSeconds *int   `json:"duration_seconds" binding:"omitempty,min=1"`
+}
+
+// CommandAck es la confirmación de un comando que envía el dispositivo
+type CommandAck struct {
+	CommandID uint   `json:"command_id"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+}
@@ -0,0 +1,112 @@
+package domain
+
+import "time"
+
+// Operadores de comparación de las condiciones de automatización
+const (
+	OperatorBelow        = "lt"
+	OperatorBelowOrEqual = "lte"
+	OperatorAbove        = "gt"
+	OperatorAboveOrEqual = "gte"
+)
+
+// Resultados registrados en el historial de ejecuciones
+const (
+	AutomationTriggered = "triggered" // Se emitió el comando
+	AutomationFailed    = "failed"    // No se pudo emitir el comando
+)
+
+// Automation emite un comando a un actuador cuando una métrica de un dispositivo cumple
+// la condición durante ForSeconds, respetando el tiempo de espera entre ejecuciones y el
+// máximo diario
+type Automation struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+
+	// Condición
+	DeviceID   string  `json:"device_id"`
+	SensorType string  `json:"sensor_type"` // "temperatura", "luz", "humedad", "humo", "ph"
+	Operator   string  `json:"operator"`
+	Threshold  float64 `json:"threshold"`
+	ForSeconds int     `json:"for_seconds"` // Tiempo que debe mantenerse; 0 actúa en la primera lectura
+
+	// Acción
+	ActuatorID      uint   `json:"actuator_id"`
+	Action          string `json:"action"`
+	DutyCycle       *int   `json:"duty_cycle,omitempty"`
+	DurationSeconds *int   `json:"duration_seconds,omitempty"`
+
+	// Límites
+	CooldownSeconds int `json:"cooldown_seconds"`
+	MaxPerDay       int `json:"max_per_day"` // 0 sin límite
+
+	// Estado de la evaluación
+	ConditionSince  *time.Time `json:"condition_since,omitempty"` // Desde cuándo se cumple la condición
+	LastTriggeredAt *time.Time `json:"last_triggered_at,omitempty"`
+
+	CreatedBy uint      `json:"created_by"` // Los comandos se emiten en su nombre
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Matches indica si el valor cumple la condición
+func (a Automation) Matches(value float64) bool {
+	return compare(a.Operator, value, a.Threshold)
+}
+
+// compare aplica un operador de comparación al valor y el umbral
+func compare(operator string, value, threshold float64) bool {
+	switch operator {
+	case OperatorBelow:
+		return value < threshold
+	case OperatorBelowOrEqual:
+		return value <= threshold
+	case OperatorAbove:
+		return value > threshold
+	case OperatorAboveOrEqual:
+		return value >= threshold
+	default:
+		return false
+	}
+}
+
+// CommandRequest devuelve el comando que emite la automatización
+func (a Automation) CommandRequest() ActuatorCommandRequest {
+	return ActuatorCommandRequest{
+		Action:          a.Action,
+		DutyCycle:       a.DutyCycle,
+		DurationSeconds: a.DurationSeconds,
+	}
+}
+
+type AutomationRequest struct {
+	Name            string  `json:"name" binding:"required"`
+	Enabled         *bool   `json:"enabled"`
+	DeviceID        string  `json:"device_id" binding:"required"`
+	SensorType      string  `json:"sensor_type" binding:"required"`
+	Operator        string  `json:"operator" binding:"required,oneof=lt lte gt gte"`
+	Threshold       float64 `json:"threshold"`
+	ForSeconds      int     `json:"for_seconds" binding:"min=0"`
+	ActuatorID      uint    `json:"actuator_id" binding:"required"`
+	Action          string  `json:"action" binding:"required,oneof=on off duty_cycle run"`
+	DutyCycle       *int    `json:"duty_cycle" binding:"omitempty,min=0,max=100"`
+	DurationSeconds *int    `json:"duration_seconds" binding:"omitempty,min=1"`
+	CooldownSeconds int     `json:"cooldown_seconds" binding:"min=0"`
+	MaxPerDay       int     `json:"max_per_day" binding:"min=0"`
+}
+
+type SetAutomationEnabledRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// AutomationExecution es una entrada del historial de ejecuciones
+type AutomationExecution struct {
+	ID           uint      `json:"id"`
+	AutomationID uint      `json:"automation_id"`
+	Status       string    `json:"status"`
+	Value        float64   `json:"value"` // Valor de la lectura que disparó la ejecución
+	CommandID    *uint     `json:"command_id,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
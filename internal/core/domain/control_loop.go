@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// ControlLoop regula una métrica de un dispositivo hacia un valor objetivo con un
+// controlador PID cuya salida es la potencia de un actuador
+type ControlLoop struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+
+	// Entrada
+	DeviceID   string  `json:"device_id"`
+	SensorType string  `json:"sensor_type"` // "temperatura", "luz", "humedad", "humo", "ph"
+	Setpoint   float64 `json:"setpoint"`
+
+	// Ganancias; Ki y Kd están en unidades por segundo
+	Kp float64 `json:"kp"`
+	Ki float64 `json:"ki"`
+	Kd float64 `json:"kd"`
+	// Reverse indica que aumentar la salida reduce la métrica, como un ventilador que enfría
+	Reverse bool `json:"reverse"`
+
+	// Salida, en porcentaje de potencia del actuador
+	ActuatorID    uint    `json:"actuator_id"`
+	OutputMin     float64 `json:"output_min"`
+	OutputMax     float64 `json:"output_max"`
+	SampleSeconds int     `json:"sample_seconds"` // Tiempo mínimo entre dos pasos del controlador
+
+	// Estado del controlador
+	Integral    float64    `json:"integral"`
+	LastInput   *float64   `json:"last_input,omitempty"`
+	LastInputAt *time.Time `json:"last_input_at,omitempty"` // Hora de la última lectura procesada
+	LastOutput  *int       `json:"last_output,omitempty"`   // Última potencia enviada al actuador
+	// Comando con el que se envió LastOutput; si no llega a aplicarse, se reenvía
+	LastCommandID *uint      `json:"last_command_id,omitempty"`
+	LastUpdateAt  *time.Time `json:"last_update_at,omitempty"`
+
+	CreatedBy uint      `json:"created_by"` // Los comandos se emiten en su nombre
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Step calcula la salida del controlador para una nueva lectura tomada dt segundos después
+// de la anterior, y actualiza el término integral del lazo. La derivada se calcula sobre la
+// lectura para que los cambios del objetivo no produzcan picos, y el término integral se
+// limita para que la salida no sature (anti-windup)
+func (l *ControlLoop) Step(input, dt float64) ControlLoopSample {
+	err := l.Setpoint - input
+	if l.Reverse {
+		err = -err
+	}
+
+	proportional := l.Kp * err
+	integral := l.Integral + l.Ki*err*dt
+
+	derivative := 0.0
+	if l.LastInput != nil && dt > 0 {
+		derivative = -l.Kd * (input - *l.LastInput) / dt
+		if l.Reverse {
+			derivative = -derivative
+		}
+	}
+
+	output := proportional + integral + derivative
+	if output > l.OutputMax {
+		integral -= output - l.OutputMax
+		output = l.OutputMax
+	} else if output < l.OutputMin {
+		integral += l.OutputMin - output
+		output = l.OutputMin
+	}
+	integral = math.Max(l.OutputMin, math.Min(l.OutputMax, integral))
+
+	l.Integral = integral
+	l.LastInput = &input
+
+	return ControlLoopSample{
+		LoopID:       l.ID,
+		Input:        input,
+		Setpoint:     l.Setpoint,
+		Error:        err,
+		Proportional: proportional,
+		Integral:     integral,
+		Derivative:   derivative,
+		Output:       output,
+		DutyCycle:    int(math.Round(output)),
+	}
+}
+
+// ResetState olvida el estado del controlador, al cambiar su definición o reactivarlo
+func (l *ControlLoop) ResetState() {
+	l.Integral = 0
+	l.LastInput = nil
+	l.LastInputAt = nil
+	l.LastOutput = nil
+	l.LastCommandID = nil
+	l.LastUpdateAt = nil
+}
+
+type ControlLoopRequest struct {
+	Name          string  `json:"name" binding:"required"`
+	Enabled       *bool   `json:"enabled"`
+	DeviceID      string  `json:"device_id" binding:"required"`
+	SensorType    string  `json:"sensor_type" binding:"required"`
+	Setpoint      float64 `json:"setpoint"`
+	Kp            float64 `json:"kp" binding:"min=0"`
+	Ki            float64 `json:"ki" binding:"min=0"`
+	Kd            float64 `json:"kd" binding:"min=0"`
+	Reverse       bool    `json:"reverse"`
+	ActuatorID    uint    `json:"actuator_id" binding:"required"`
+	OutputMin     float64 `json:"output_min" binding:"min=0,max=100"`
+	OutputMax     float64 `json:"output_max" binding:"required,min=0,max=100"`
+	SampleSeconds int     `json:"sample_seconds" binding:"omitempty,min=5"` // Por defecto, 30
+}
+
+// TuneControlLoopRequest cambia el objetivo y las ganancias sin reiniciar el controlador
+type TuneControlLoopRequest struct {
+	Setpoint *float64 `json:"setpoint"`
+	Kp       *float64 `json:"kp" binding:"omitempty,min=0"`
+	Ki       *float64 `json:"ki" binding:"omitempty,min=0"`
+	Kd       *float64 `json:"kd" binding:"omitempty,min=0"`
+}
+
+type SetControlLoopEnabledRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// ControlLoopSample es una entrada del registro del controlador, con los términos de cada
+// paso para ajustar las ganancias
+type ControlLoopSample struct {
+	ID           uint      `json:"id"`
+	LoopID       uint      `json:"loop_id"`
+	Input        float64   `json:"input"`
+	Setpoint     float64   `json:"setpoint"`
+	Error        float64   `json:"error"`
+	Proportional float64   `json:"p"`
+	Integral     float64   `json:"i"`
+	Derivative   float64   `json:"d"`
+	Output       float64   `json:"output"`
+	DutyCycle    int       `json:"duty_cycle"`
+	CommandID    *uint     `json:"command_id,omitempty"` // Solo si la potencia cambió
+	CommandError string    `json:"command_error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// Estados de conexión de un dispositivo
+const (
+	DeviceStatusOnline = "online"
+	DeviceStatusSilent = "silent"
+)
+
+// Tipo y regla de las alertas de dispositivo sin datos
+const (
+	DeviceSensorType       = "dispositivo"
+	DeviceSilentRule       = "device_silent"
+	DeviceSilentMessageKey = "alert.device_silent"
+)
+
+// Intervalo esperado entre lecturas para los dispositivos nuevos
+const DefaultDeviceIntervalSeconds = 60
+
+type Device struct {
+	ID                      string     `json:"id"`
+	Name                    string     `json:"name"`
+	GardenID                *uint      `json:"garden_id,omitempty"`
+	ExpectedIntervalSeconds int        `json:"expected_interval_seconds"`
+	Status                  string     `json:"status"`
+	LastSeenAt              *time.Time `json:"last_seen_at,omitempty"`
+	LastReadingID           uint       `json:"last_reading_id"`
+	CreatedAt               time.Time  `json:"created_at"`
+}
+
+type UpdateDeviceRequest struct {
+	Name                    string `json:"name"`
+	ExpectedIntervalSeconds int    `json:"expected_interval_seconds" binding:"required,min=1"`
+}
+
+// Eventos del historial de presencia de los dispositivos
+const (
+	PresenceConnected    = "connected"    // Abrió una conexión en tiempo real
+	PresenceDisconnected = "disconnected" // Cerró su conexión en tiempo real
+	PresenceOnline       = "online"       // Pasó a estar presente
+	PresenceOffline      = "offline"      // Dejó de estar presente
+)
+
+// Estados globales del sistema
+const (
+	SystemStatusOK       = "ok"
+	SystemStatusDegraded = "degraded"
+	SystemStatusDown     = "down"
+	SystemStatusEmpty    = "empty" // No hay dispositivos registrados
+)
+
+type PresenceEvent struct {
+	ID        uint      `json:"id"`
+	DeviceID  string    `json:"device_id"`
+	Event     string    `json:"event"`
+	Transport string    `json:"transport,omitempty"` // "websocket" en conexiones y desconexiones
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DevicePresence indica si un dispositivo está presente: conectado en tiempo real o con
+// lecturas recientes dentro de su intervalo esperado
+type DevicePresence struct {
+	DeviceID    string     `json:"device_id"`
+	Name        string     `json:"name"`
+	Online      bool       `json:"online"`
+	Connections int        `json:"connections"`
+	LastSeenAt  *time.Time `json:"last_seen_at,omitempty"`
+}
+
+// SystemStatus es el estado del sistema calculado por el servidor
+type SystemStatus struct {
+	Status         string           `json:"status"`
+	SensorsOnline  int              `json:"sensors_online"`
+	TotalSensors   int              `json:"total_sensors"`
+	LastUpdateTime time.Time        `json:"last_update_time"`
+	Devices        []DevicePresence `json:"devices"`
+}
@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrTwinVersionConflict se devuelve al guardar una configuración a partir de una versión
+// que ya no es la actual
+var ErrTwinVersionConflict = errors.New("la versión de la configuración no es la actual")
+
+// ErrTwinReportInvalid se devuelve cuando el informe del dispositivo no es válido
+var ErrTwinReportInvalid = errors.New("informe de configuración inválido")
+
+// DeviceConfig es la configuración que el dispositivo aplica sin reflashearlo. Los campos
+// vacíos no se gestionan desde el servidor
+type DeviceConfig struct {
+	SamplingIntervalSeconds *int `json:"sampling_interval_seconds,omitempty" binding:"omitempty,min=1"`
+	// Umbrales que el dispositivo evalúa por sí mismo, por métrica
+	Thresholds map[string]DeviceThreshold `json:"thresholds,omitempty" binding:"omitempty,dive"`
+	// Corrección de las lecturas, por métrica: valor * scale + offset
+	Calibration map[string]DeviceCalibration `json:"calibration,omitempty" binding:"omitempty,dive"`
+}
+
+type DeviceThreshold struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+type DeviceCalibration struct {
+	Offset float64 `json:"offset"`
+	Scale  float64 `json:"scale" binding:"required"`
+}
+
+// Delta devuelve la parte de la configuración deseada que no coincide con la comunicada
+// por el dispositivo
+func (c DeviceConfig) Delta(reported DeviceConfig) DeviceConfig {
+	var delta DeviceConfig
+
+	if c.SamplingIntervalSeconds != nil && !sameInt(c.SamplingIntervalSeconds, reported.SamplingIntervalSeconds) {
+		delta.SamplingIntervalSeconds = c.SamplingIntervalSeconds
+	}
+
+	for metric, threshold := range c.Thresholds {
+		current, ok := reported.Thresholds[metric]
+		if ok && sameFloat(threshold.Min, current.Min) && sameFloat(threshold.Max, current.Max) {
+			continue
+		}
+		if delta.Thresholds == nil {
+			delta.Thresholds = make(map[string]DeviceThreshold)
+		}
+		delta.Thresholds[metric] = threshold
+	}
+
+	for metric, calibration := range c.Calibration {
+		if current, ok := reported.Calibration[metric]; ok && current == calibration {
+			continue
+		}
+		if delta.Calibration == nil {
+			delta.Calibration = make(map[string]DeviceCalibration)
+		}
+		delta.Calibration[metric] = calibration
+	}
+
+	return delta
+}
+
+// IsEmpty indica si la configuración no tiene ningún valor
+func (c DeviceConfig) IsEmpty() bool {
+	return c.SamplingIntervalSeconds == nil && len(c.Thresholds) == 0 && len(c.Calibration) == 0
+}
+
+func sameInt(a, b *int) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func sameFloat(a, b *float64) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// DeviceTwin reúne la configuración deseada de un dispositivo, fijada desde la API, y la
+// que el dispositivo comunica tener aplicada. Cada parte tiene su propia versión, que
+// asigna el servidor y aumenta con cada cambio
+type DeviceTwin struct {
+	DeviceID string `json:"device_id"`
+
+	Desired          DeviceConfig `json:"desired"`
+	DesiredVersion   int          `json:"desired_version"`
+	DesiredUpdatedAt *time.Time   `json:"desired_updated_at,omitempty"`
+
+	Reported        DeviceConfig `json:"reported"`
+	ReportedVersion int          `json:"reported_version"`
+	// Versión deseada que el dispositivo aplicó en su último informe
+	AppliedVersion    int        `json:"applied_version"`
+	ReportedUpdatedAt *time.Time `json:"reported_updated_at,omitempty"`
+
+	// Calculados al leer el gemelo
+	Delta  DeviceConfig `json:"delta"`
+	InSync bool         `json:"in_sync"`
+}
+
+// ComputeDelta calcula lo que falta por aplicar en el dispositivo
+func (t *DeviceTwin) ComputeDelta() {
+	t.Delta = t.Desired.Delta(t.Reported)
+	t.InSync = t.Delta.IsEmpty()
+}
+
+// DeviceTwinDelta es lo que se envía al dispositivo para que aplique la configuración
+// deseada; debe indicar DesiredVersion en su siguiente informe
+type DeviceTwinDelta struct {
+	DeviceID       string       `json:"device_id"`
+	DesiredVersion int          `json:"desired_version"`
+	Delta          DeviceConfig `json:"delta"`
+	// Configuración deseada completa, para los dispositivos que prefieren reemplazarla
+	Desired DeviceConfig `json:"desired"`
+}
+
+// TwinDelta devuelve la diferencia pendiente del gemelo
+func (t *DeviceTwin) TwinDelta() DeviceTwinDelta {
+	return DeviceTwinDelta{
+		DeviceID:       t.DeviceID,
+		DesiredVersion: t.DesiredVersion,
+		Delta:          t.Desired.Delta(t.Reported),
+		Desired:        t.Desired,
+	}
+}
+
+// UpdateDesiredConfigRequest reemplaza la configuración deseada. Version es la versión
+// deseada sobre la que se hizo el cambio; si otro cambio la ha superado, se rechaza
+type UpdateDesiredConfigRequest struct {
+	Version *int         `json:"version" binding:"required,min=0"`
+	Config  DeviceConfig `json:"config"`
+}
+
+// ReportConfigRequest es el informe de la configuración aplicada en el dispositivo.
+// AppliedVersion es la versión deseada que recibió del servidor, así que no depende de
+// contadores del dispositivo que se reinician al arrancar. Se descartan los informes de
+// una versión anterior a la ya comunicada y se rechazan los de una posterior a la deseada
+type ReportConfigRequest struct {
+	AppliedVersion int          `json:"applied_version" binding:"min=0"`
+	Config         DeviceConfig `json:"config"`
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+// Frecuencias de los resúmenes periódicos
+const (
+	DigestHourly = "hourly"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// Suscripción de un usuario a resúmenes periódicos de alertas
+type DigestSubscription struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	Frequency  string     `json:"frequency"`
+	TimeOfDay  string     `json:"time_of_day"` // HH:MM local; en los horarios solo se usan los minutos
+	Weekday    int        `json:"weekday"`     // 0 = domingo, solo para los semanales
+	Timezone   string     `json:"timezone"`
+	Channels   []string   `json:"channels"`
+	Enabled    bool       `json:"enabled"`
+	LastSentAt *time.Time `json:"last_sent_at,omitempty"`
+	NextRunAt  time.Time  `json:"next_run_at"`
+}
+
+type UpdateDigestSubscriptionRequest struct {
+	Frequency string   `json:"frequency" binding:"required,oneof=hourly daily weekly"`
+	TimeOfDay string   `json:"time_of_day" binding:"required"`
+	Weekday   int      `json:"weekday" binding:"min=0,max=6"`
+	Timezone  string   `json:"timezone"`
+	Channels  []string `json:"channels" binding:"required,min=1,dive,oneof=email push"`
+	Enabled   bool     `json:"enabled"`
+}
+
+// Valores mínimo, máximo y medio de una métrica en un periodo
+type MetricSummary struct {
+	Metric string  `json:"metric"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Avg    float64 `json:"avg"`
+}
+
+// Actividad de un dispositivo en un periodo
+type DeviceActivity struct {
+	DeviceID      string    `json:"device_id"`
+	ReadingCount  int       `json:"reading_count"`
+	LastReadingAt time.Time `json:"last_reading_at"`
+}
+
+// Número de alertas de un tipo en el periodo del resumen
+type AlertTypeCount struct {
+	SensorType string `json:"sensor_type"`
+	Count      int    `json:"count"`
+}
+
+// Resumen de alertas, lecturas y estado de los dispositivos en un periodo
+type Digest struct {
+	Frequency   string           `json:"frequency"`
+	PeriodStart time.Time        `json:"period_start"`
+	PeriodEnd   time.Time        `json:"period_end"`
+	TotalAlerts int              `json:"total_alerts"`
+	AlertCounts []AlertTypeCount `json:"alert_counts"`
+	Readings    []MetricSummary  `json:"readings"`
+	Devices     []DeviceActivity `json:"devices"`
+}
@@ -0,0 +1,92 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Roles de los miembros de un jardín
+const (
+	GardenRoleOwner  = "owner"
+	GardenRoleMember = "member"
+)
+
+// ErrGardenAccessDenied se devuelve cuando el usuario no puede ver o gestionar el jardín
+var ErrGardenAccessDenied = errors.New("no tienes acceso a este jardín")
+
+// Garden agrupa dispositivos; solo sus miembros reciben los eventos en tiempo real. La
+// zona horaria y la ubicación determinan cuándo se ejecutan sus programaciones
+type Garden struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   uint      `json:"owner_id"`
+	Role      string    `json:"role,omitempty"` // Rol del usuario que consulta
+	Timezone  string    `json:"timezone"`
+	Latitude  *float64  `json:"latitude,omitempty"`
+	Longitude *float64  `json:"longitude,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Location devuelve la zona horaria del jardín, o UTC si no es válida
+func (g Garden) Location() *time.Location {
+	loc, err := time.LoadLocation(g.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+type GardenMember struct {
+	GardenID  uint      `json:"garden_id"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateGardenRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Timezone  string   `json:"timezone"` // Por defecto, UTC
+	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+}
+
+type UpdateGardenRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Timezone  string   `json:"timezone"` // Por defecto, UTC
+	Latitude  *float64 `json:"latitude" binding:"omitempty,min=-90,max=90"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,min=-180,max=180"`
+}
+
+type AddGardenMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// DeviceAccess indica qué dispositivos puede ver un usuario
+type DeviceAccess struct {
+	All       bool // Administradores: todos los dispositivos
+	DeviceIDs map[string]bool
+}
+
+// Allows indica si el usuario puede ver los eventos del dispositivo
+func (a *DeviceAccess) Allows(deviceID string) bool {
+	return a != nil && (a.All || a.DeviceIDs[deviceID])
+}
+
+// IDs devuelve los dispositivos visibles ordenados; no tiene sentido con All
+func (a *DeviceAccess) IDs() []string {
+	if a == nil {
+		return nil
+	}
+
+	ids := make([]string, 0, len(a.DeviceIDs))
+	for id, allowed := range a.DeviceIDs {
+		if allowed {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// Idioma usado cuando no hay preferencia del usuario ni Accept-Language compatible
+const DefaultLocale = "es"
+
+// Idiomas con catálogo de mensajes
+var SupportedLocales = []string{"es", "en"}
+
+// Plantilla de un mensaje en un idioma
+type MessageTemplate struct {
+	Locale     string     `json:"locale"`
+	Key        string     `json:"key"`
+	Template   string     `json:"template"`
+	Overridden bool       `json:"overridden"` // Modificada por un administrador
+	UpdatedBy  uint       `json:"updated_by,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+type UpdateMessageTemplateRequest struct {
+	Template string `json:"template" binding:"required"`
+}
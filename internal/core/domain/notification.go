@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Canales de notificación disponibles
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelPush  = "push"
+)
+
+// Preferencias de un usuario para un canal de notificación
+type NotificationPreference struct {
+	ID          uint      `json:"id"`
+	UserID      uint      `json:"user_id"`
+	Channel     string    `json:"channel"`
+	Enabled     bool      `json:"enabled"`
+	MinSeverity string    `json:"min_severity"`
+	SensorTypes []string  `json:"sensor_types"` // Vacío = todas las métricas
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type UpdateNotificationPreferenceRequest struct {
+	Enabled     bool     `json:"enabled"`
+	MinSeverity string   `json:"min_severity" binding:"omitempty,oneof=info warning critical"`
+	SensorTypes []string `json:"sensor_types"`
+}
+
+// ErrPushEndpointInvalid indica que el endpoint de la suscripción no es una URL https pública
+var ErrPushEndpointInvalid = errors.New("endpoint de push inválido")
+
+// ErrPushEndpointTaken indica que el endpoint ya está suscrito por otro usuario
+var ErrPushEndpointTaken = errors.New("el endpoint de push pertenece a otro usuario")
+
+// Suscripción Web Push de un navegador
+type PushSubscription struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"user_id"`
+	Endpoint  string    `json:"endpoint"`
+	P256dh    string    `json:"-"`
+	Auth      string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Formato de PushSubscription.toJSON() en el navegador
+type PushSubscribeRequest struct {
+	Endpoint string `json:"endpoint" binding:"required,url"`
+	Keys     struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+}
+
+type PushUnsubscribeRequest struct {
+	Endpoint string `json:"endpoint" binding:"required"`
+}
@@ -0,0 +1,111 @@
+package domain
+
+import "time"
+
+// Tipos de programación
+const (
+	ScheduleKindCron = "cron" // Expresión cron de cinco campos
+	ScheduleKindSun  = "sun"  // Salida o puesta del sol más un desfase
+)
+
+// Eventos solares de las programaciones de tipo sun
+const (
+	SunEventSunrise = "sunrise"
+	SunEventSunset  = "sunset"
+)
+
+// Resultados registrados en el historial de ejecuciones de las programaciones
+const (
+	ScheduleRunExecuted = "executed" // Se emitió el comando
+	ScheduleRunSkipped  = "skipped"  // Se cumplió la condición de omisión o la ejecución se perdió
+	ScheduleRunFailed   = "failed"   // No se pudo emitir el comando
+)
+
+// Schedule emite un comando a un actuador de forma periódica. Las horas se calculan en la
+// zona horaria y la ubicación del jardín del dispositivo del actuador
+type Schedule struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+
+	// Cuándo
+	Kind          string `json:"kind"`
+	Cron          string `json:"cron,omitempty"`
+	SunEvent      string `json:"sun_event,omitempty"`
+	OffsetMinutes int    `json:"offset_minutes"` // Desfase sobre el evento solar; negativo para antes
+
+	// Acción
+	ActuatorID      uint   `json:"actuator_id"`
+	Action          string `json:"action"`
+	DutyCycle       *int   `json:"duty_cycle,omitempty"`
+	DurationSeconds *int   `json:"duration_seconds,omitempty"`
+
+	// Se omite la ejecución si la última lectura cumple la condición
+	SkipIf *ScheduleSkipCondition `json:"skip_if,omitempty"`
+
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+
+	CreatedBy uint      `json:"created_by"` // Los comandos se emiten en su nombre
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CommandRequest devuelve el comando que emite la programación
+func (s Schedule) CommandRequest() ActuatorCommandRequest {
+	return ActuatorCommandRequest{
+		Action:          s.Action,
+		DutyCycle:       s.DutyCycle,
+		DurationSeconds: s.DurationSeconds,
+	}
+}
+
+// ScheduleSkipCondition compara la última lectura de una métrica de un dispositivo
+type ScheduleSkipCondition struct {
+	DeviceID   string  `json:"device_id" binding:"required"`
+	SensorType string  `json:"sensor_type" binding:"required"`
+	Operator   string  `json:"operator" binding:"required,oneof=lt lte gt gte"`
+	Threshold  float64 `json:"threshold"`
+}
+
+// Matches indica si el valor cumple la condición
+func (c ScheduleSkipCondition) Matches(value float64) bool {
+	return compare(c.Operator, value, c.Threshold)
+}
+
+type ScheduleRequest struct {
+	Name            string                 `json:"name" binding:"required"`
+	Enabled         *bool                  `json:"enabled"`
+	Kind            string                 `json:"kind" binding:"required,oneof=cron sun"`
+	Cron            string                 `json:"cron"`
+	SunEvent        string                 `json:"sun_event" binding:"omitempty,oneof=sunrise sunset"`
+	OffsetMinutes   int                    `json:"offset_minutes" binding:"min=-720,max=720"`
+	ActuatorID      uint                   `json:"actuator_id" binding:"required"`
+	Action          string                 `json:"action" binding:"required,oneof=on off duty_cycle run"`
+	DutyCycle       *int                   `json:"duty_cycle" binding:"omitempty,min=0,max=100"`
+	DurationSeconds *int                   `json:"duration_seconds" binding:"omitempty,min=1"`
+	SkipIf          *ScheduleSkipCondition `json:"skip_if"`
+}
+
+type SetScheduleEnabledRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// ScheduleRun es una entrada del historial de ejecuciones de una programación
+type ScheduleRun struct {
+	ID           uint      `json:"id"`
+	ScheduleID   uint      `json:"schedule_id"`
+	Status       string    `json:"status"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	CommandID    *uint     `json:"command_id,omitempty"`
+	Reason       string    `json:"reason,omitempty"` // Motivo de la omisión o del fallo
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// SchedulePreview lista las próximas ejecuciones de una programación, sin evaluar la
+// condición de omisión
+type SchedulePreview struct {
+	ScheduleID uint        `json:"schedule_id"`
+	Timezone   string      `json:"timezone"`
+	Runs       []time.Time `json:"runs"`
+}
@@ -1,9 +1,19 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// Dispositivo asignado a las lecturas que no indican device_id
+const DefaultDeviceID = "default"
+
+// ErrNoReadings se devuelve cuando un dispositivo aún no ha enviado lecturas de una métrica
+var ErrNoReadings = errors.New("no hay lecturas de la métrica")
 
 type SensorData struct {
 	ID             uint      `json:"id"`
+	DeviceID       string    `json:"device_id"`
 	TemperaturaDHT float64   `json:"temperaturaDHT"`
 	Luz            float64   `json:"luz"`
 	Humedad        float64   `json:"humedad"`
@@ -11,14 +21,93 @@ type SensorData struct {
 	CreatedAt      time.Time `json:"created_at"`
 }
 
+// Niveles de severidad de las alertas
+const (
+	AlertSeverityInfo     = "info"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityCritical = "critical"
+)
+
+// Estados del ciclo de vida de una alerta
+const (
+	AlertStateActive       = "active"
+	AlertStateAcknowledged = "acknowledged"
+	AlertStateResolved     = "resolved"
+)
+
 type Alert struct {
+	ID             uint                   `json:"id"`
+	SensorID       uint                   `json:"sensor_id"` // 0 si la alerta no procede de una lectura guardada
+	DeviceID       string                 `json:"device_id"`
+	SensorType     string                 `json:"sensor_type"` // "temperatura", "luz", "humedad", "humo", "ph"
+	Rule           string                 `json:"rule"`        // Regla que generó la alerta, p. ej. "temperatura_max"
+	Severity       string                 `json:"severity"`
+	State          string                 `json:"state"`
+	Value          float64                `json:"value"`
+	Message        string                 `json:"message"`
+	MessageKey     string                 `json:"message_key"`
+	MessageParams  map[string]interface{} `json:"message_params,omitempty"`
+	IsRead         bool                   `json:"is_read"`
+	Silenced       bool                   `json:"silenced"` // Registrada durante un silencio o mantenimiento, sin notificar
+	CreatedAt      time.Time              `json:"created_at"`
+	AcknowledgedAt *time.Time             `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time             `json:"resolved_at,omitempty"`
+}
+
+// Criterios de búsqueda de alertas; los campos vacíos no filtran
+type AlertFilter struct {
+	SensorType string
+	DeviceID   string
+	Severity   string
+	State      string
+	IsRead     *bool
+	Silenced   *bool
+	From       *time.Time
+	To         *time.Time
+	Cursor     uint // ID de la última alerta de la página anterior
+	Limit      int
+	// Dispositivos visibles para quien consulta; sin él no se restringe
+	Access *DeviceAccess
+}
+
+// Página de resultados de alertas
+type AlertPage struct {
+	Alerts     []Alert `json:"alerts"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// Número de alertas de un tipo en un día
+type AlertDailyCount struct {
+	SensorType string `json:"sensor_type"`
+	Day        string `json:"day"`
+	Count      int    `json:"count"`
+}
+
+// Número de alertas generadas por un dispositivo
+type DeviceAlertCount struct {
+	DeviceID string `json:"device_id"`
+	Count    int    `json:"count"`
+}
+
+// Estadísticas agregadas de las alertas
+type AlertStats struct {
+	Total                        int                `json:"total"`
+	CountsByTypeAndDay           []AlertDailyCount  `json:"counts_by_type_and_day"`
+	MeanTimeToAcknowledgeSeconds float64            `json:"mean_time_to_acknowledge_seconds"`
+	MeanTimeToResolveSeconds     float64            `json:"mean_time_to_resolve_seconds"`
+	TopDevices                   []DeviceAlertCount `json:"top_devices"`
+}
+
+// Lectura de una sola métrica, como las que llegan por WebSocket
+type MetricReading struct {
 	ID         uint      `json:"id"`
-	SensorID   uint      `json:"sensor_id"`
-	SensorType string    `json:"sensor_type"` // "temperatura", "luz", "humedad", "humo"
+	DeviceID   string    `json:"device_id"`
+	SensorType string    `json:"sensor_type"` // "temperatura", "luz", "humedad", "humo", "ph"
 	Value      float64   `json:"value"`
-	Message    string    `json:"message"`
-	IsRead     bool      `json:"is_read"`
+	Unit       string    `json:"unit,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
+	// Hora indicada por el dispositivo, si la envió
+	DeviceTime *time.Time `json:"device_time,omitempty"`
 }
 
 // Umbrales para las alertas
@@ -30,6 +119,19 @@ type AlertThresholds struct {
 	HumedadMax     float64
 	HumedadMin     float64
 	HumoMax        float64
+	PHMin          float64
+	PHMax          float64
+	// Límites de variación, evaluados sobre el historial reciente de cada dispositivo
+	RateRules []RateOfChangeRule
+}
+
+// Regla sobre la velocidad de cambio de una métrica: alerta cuando la variación dentro
+// de la ventana supera MaxChange por cada Per (un minuto, una hora...)
+type RateOfChangeRule struct {
+	SensorType string        // "temperatura", "luz", "humedad", "humo", "ph"
+	MaxChange  float64       // Variación máxima permitida, en valor absoluto
+	Per        time.Duration // Unidad de tiempo de MaxChange
+	Window     time.Duration // Historial considerado para calcular la variación
 }
 
 // Valores predeterminados para los umbrales de alertas
@@ -41,4 +143,10 @@ var DefaultAlertThresholds = AlertThresholds{
 	HumedadMax:     80.0,
 	HumedadMin:     30.0,
 	HumoMax:        50.0,
+	PHMin:          5.5,
+	PHMax:          7.5,
+	RateRules: []RateOfChangeRule{
+		{SensorType: "temperatura", MaxChange: 0.8, Per: time.Minute, Window: 10 * time.Minute},
+		{SensorType: "humedad", MaxChange: 2.0, Per: time.Minute, Window: 10 * time.Minute},
+	},
 }
@@ -0,0 +1,51 @@
+package domain
+
+import "time"
+
+// Silencio temporal de alertas. Los campos de coincidencia vacíos aplican a cualquier valor
+type Silence struct {
+	ID         uint      `json:"id"`
+	DeviceID   string    `json:"device_id"`
+	SensorType string    `json:"sensor_type"`
+	Rule       string    `json:"rule"`
+	StartsAt   time.Time `json:"starts_at"`
+	EndsAt     time.Time `json:"ends_at"`
+	Comment    string    `json:"comment"`
+	CreatedBy  uint      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Ventana de mantenimiento que se repite en los días de la semana indicados
+type MaintenanceWindow struct {
+	ID              uint      `json:"id"`
+	Name            string    `json:"name"`
+	DeviceID        string    `json:"device_id"`
+	SensorType      string    `json:"sensor_type"`
+	Rule            string    `json:"rule"`
+	DaysOfWeek      []int     `json:"days_of_week"` // 0 = domingo; vacío = todos los días
+	StartTime       string    `json:"start_time"`   // Hora local en formato HH:MM
+	DurationMinutes int       `json:"duration_minutes"`
+	Timezone        string    `json:"timezone"`
+	CreatedBy       uint      `json:"created_by"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type CreateSilenceRequest struct {
+	DeviceID   string     `json:"device_id"`
+	SensorType string     `json:"sensor_type"`
+	Rule       string     `json:"rule"`
+	StartsAt   *time.Time `json:"starts_at"` // Por defecto, ahora
+	EndsAt     time.Time  `json:"ends_at" binding:"required"`
+	Comment    string     `json:"comment"`
+}
+
+type CreateMaintenanceWindowRequest struct {
+	Name            string `json:"name" binding:"required"`
+	DeviceID        string `json:"device_id"`
+	SensorType      string `json:"sensor_type"`
+	Rule            string `json:"rule"`
+	DaysOfWeek      []int  `json:"days_of_week" binding:"dive,min=0,max=6"`
+	StartTime       string `json:"start_time" binding:"required"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=1,max=10080"`
+	Timezone        string `json:"timezone"` // Por defecto, UTC
+}
@@ -2,11 +2,19 @@ package domain
 
 import "time"
 
+// Roles de usuario
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	ID        uint      `json:"id"`
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	Password  string    `json:"-"` // No se muestra en las respuestas JSON
+	Role      string    `json:"role"`
+	Locale    string    `json:"locale"` // Vacío = según Accept-Language
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -27,3 +35,12 @@ type AuthResponse struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 }
+
+// UpdateUserRoleRequest cambia el rol de un usuario; solo lo usan los administradores
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
+type UpdateUserPreferencesRequest struct {
+	Locale string `json:"locale" binding:"omitempty,oneof=es en"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// Tipos de eventos que se pueden enviar a los webhooks
+const (
+	EventAlertCreated   = "alert.created"
+	EventReadingCreated = "reading.created"
+)
+
+// Eventos aceptados al registrar un webhook
+var WebhookEventTypes = []string{EventAlertCreated, EventReadingCreated}
+
+type Webhook struct {
+	ID         uint      `json:"id"`
+	UserID     uint      `json:"user_id"` // Solo recibe eventos de los dispositivos que ve su propietario
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"-"` // Solo se devuelve al crear el webhook
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Intento de entrega de un evento a un webhook
+type WebhookDelivery struct {
+	ID         uint      `json:"id"`
+	WebhookID  uint      `json:"webhook_id"`
+	EventType  string    `json:"event_type"`
+	Payload    string    `json:"payload"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error,omitempty"`
+	Success    bool      `json:"success"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" binding:"required,url"`
+	EventTypes []string `json:"event_types" binding:"required,min=1"`
+	Secret     string   `json:"secret"`
+}
+
+// Cuerpo enviado en cada petición a un webhook
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
@@ -1,6 +1,11 @@
 package websocket
 
-import "time"
+import (
+	"strings"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+)
 
 // EventType representa el tipo de evento WebSocket
 type EventType string
@@ -11,7 +16,10 @@ const (
 	TemperatureEvent EventType = "temperature"
 	LightEvent       EventType = "light"
 	PHEvent          EventType = "ph"
-	SystemEvent      EventType = "system_status"
+	// Ya no se acepta de los clientes; se responde con nack
+	SystemEvent EventType = "system_status"
+	// Lectura completa, con el mismo formato que POST /sensores
+	ReadingEvent EventType = "reading"
 )
 
 // SensorEvent representa un evento de sensor
@@ -21,12 +29,192 @@ type SensorEvent struct {
 	Unit      string                 `json:"unit"`
 	Timestamp time.Time              `json:"timestamp"`
 	Details   map[string]interface{} `json:"details,omitempty"`
+	Reading   *domain.SensorData     `json:"reading,omitempty"`
+	// Identificador opcional que el dispositivo recibe de vuelta en la confirmación
+	MessageID string `json:"message_id,omitempty"`
 }
 
-// SystemStatus representa el estado del sistema
+// SystemStatus representa el estado del sistema calculado por el servidor
 type SystemStatus struct {
-	Status         string `json:"status"`
-	SensorsOnline  int    `json:"sensors_online"`
-	TotalSensors   int    `json:"total_sensors"`
-	LastUpdateTime string `json:"last_update_time"`
+	Type MessageType `json:"type"`
+	domain.SystemStatus
+}
+
+// PresencePayload difunde un cambio de presencia de un dispositivo
+type PresencePayload struct {
+	Type     MessageType           `json:"type"`
+	Presence domain.DevicePresence `json:"presence"`
+}
+
+// Temas a los que se suscriben los clientes. Los mensajes de un dispositivo se publican
+// siempre en su tema "device:<id>" y, según su contenido, en los de métrica, alertas o sistema
+const (
+	TopicAll       = "*" // Todos los mensajes; suscripción inicial de los clientes
+	TopicAlerts    = "alerts"
+	TopicSystem    = "system"
+	TopicActuators = "actuators"
+
+	deviceTopicPrefix = "device:"
+	metricTopicPrefix = "metric:"
+)
+
+// DeviceTopic devuelve el tema de los mensajes de un dispositivo
+func DeviceTopic(deviceID string) string {
+	return deviceTopicPrefix + deviceID
+}
+
+// MetricTopic devuelve el tema de los mensajes de una métrica ("temperatura", "humedad"...)
+func MetricTopic(sensorType string) string {
+	return metricTopicPrefix + sensorType
+}
+
+// ValidTopic indica si el tema tiene un formato reconocido
+func ValidTopic(topic string) bool {
+	switch {
+	case topic == TopicAll, topic == TopicAlerts, topic == TopicSystem, topic == TopicActuators:
+		return true
+	case strings.HasPrefix(topic, deviceTopicPrefix):
+		return len(topic) > len(deviceTopicPrefix)
+	case strings.HasPrefix(topic, metricTopicPrefix):
+		return len(topic) > len(metricTopicPrefix)
+	default:
+		return false
+	}
+}
+
+// Mensajes de control que envían los clientes para gestionar sus suscripciones
+const (
+	SubscribeControl   = "subscribe"
+	UnsubscribeControl = "unsubscribe"
+	// Confirmación de un comando de actuador; solo la envían los dispositivos
+	CommandAckControl = "command_ack"
+	// Informe de la configuración aplicada; solo lo envían los dispositivos
+	TwinReportedControl = "twin_reported"
+)
+
+// ControlMessage cambia las suscripciones del cliente
+type ControlMessage struct {
+	Type   string   `json:"type"`
+	Topics []string `json:"topics"`
+}
+
+// MessageType representa el tipo de los mensajes que el servidor envía a los clientes
+type MessageType string
+
+const (
+	ReadingMessage       MessageType = "reading"
+	AlertMessage         MessageType = "alert"
+	SubscriptionsMessage MessageType = "subscriptions"
+	SnapshotMessage      MessageType = "snapshot"
+	ResyncMessage        MessageType = "resync_required"
+	AckMessage           MessageType = "ack"
+	NackMessage          MessageType = "nack"
+	SystemStatusMessage  MessageType = "system_status"
+	PresenceMessage      MessageType = "presence"
+	CommandMessage       MessageType = "command"
+	ActuatorMessage      MessageType = "actuator"
+	TwinDeltaMessage     MessageType = "twin_delta"
+)
+
+// ReadingPayload difunde una lectura guardada
+type ReadingPayload struct {
+	Type    MessageType       `json:"type"`
+	Reading domain.SensorData `json:"reading"`
+}
+
+// SnapshotPayload envía al conectar la última lectura de cada dispositivo visible. Seq es el
+// último mensaje ya reflejado; los siguientes llegan a continuación
+type SnapshotPayload struct {
+	Type     MessageType         `json:"type"`
+	Seq      uint64              `json:"seq"`
+	Readings []domain.SensorData `json:"readings"`
+}
+
+// ResyncPayload avisa de que los mensajes posteriores a LastSeq ya no se pueden reenviar
+type ResyncPayload struct {
+	Type      MessageType `json:"type"`
+	LastSeq   uint64      `json:"last_seq"`
+	OldestSeq uint64      `json:"oldest_seq"`
+	LatestSeq uint64      `json:"latest_seq"`
+}
+
+// AckPayload confirma a un dispositivo si su evento se guardó
+type AckPayload struct {
+	Type      MessageType `json:"type"`
+	MessageID string      `json:"message_id,omitempty"`
+	ReadingID uint        `json:"reading_id,omitempty"`
+	CommandID uint        `json:"command_id,omitempty"`
+	Version   int         `json:"version,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// SubscriptionsPayload confirma los temas a los que queda suscrito el cliente
+type SubscriptionsPayload struct {
+	Type   MessageType `json:"type"`
+	Topics []string    `json:"topics"`
+	// Temas del mensaje de control que no se reconocieron
+	Invalid []string `json:"invalid,omitempty"`
+}
+
+// CommandPayload envía un comando de actuador al dispositivo. Un comando enviado y sin
+// confirmar se reenvía al reconectar, así que el dispositivo debe ignorar los IDs que ya
+// ejecutó y volver a confirmarlos
+type CommandPayload struct {
+	Type    MessageType            `json:"type"`
+	Command domain.ActuatorCommand `json:"command"`
+}
+
+// CommandAckMessage es la confirmación de un comando que envía el dispositivo
+type CommandAckMessage struct {
+	Type string `json:"type"`
+	domain.CommandAck
+}
+
+// TwinDeltaPayload envía al dispositivo la configuración que debe aplicar
+type TwinDeltaPayload struct {
+	Type  MessageType            `json:"type"`
+	Delta domain.DeviceTwinDelta `json:"delta"`
+}
+
+// TwinReportMessage es el informe de la configuración aplicada que envía el dispositivo
+type TwinReportMessage struct {
+	Type string `json:"type"`
+	domain.ReportConfigRequest
+}
+
+// ActuatorPayload difunde el estado de un actuador y el comando que lo cambió, si lo hay
+type ActuatorPayload struct {
+	Type     MessageType             `json:"type"`
+	Actuator domain.Actuator         `json:"actuator"`
+	Command  *domain.ActuatorCommand `json:"command,omitempty"`
+}
+
+// AlertPayload difunde una alerta generada
+type AlertPayload struct {
+	Type    MessageType  `json:"type"`
+	Message string       `json:"message"`
+	Alert   domain.Alert `json:"alert"`
+}
+
+// Políticas para los clientes que no consumen los mensajes a tiempo
+const (
+	SlowConsumerDisconnect = "disconnect"  // Cerrar la conexión al llenarse la cola
+	SlowConsumerDropOldest = "drop_oldest" // Descartar el mensaje más antiguo de la cola
+)
+
+// HubMetrics resume la actividad del servidor WebSocket
+type HubMetrics struct {
+	ConnectedClients        int    `json:"connected_clients"`
+	Topics                  int    `json:"topics"`
+	SlowConsumerPolicy      string `json:"slow_consumer_policy"`
+	MessagesBroadcast       uint64 `json:"messages_broadcast"`
+	MessagesQueued          uint64 `json:"messages_queued"`
+	MessagesDropped         uint64 `json:"messages_dropped"`
+	SlowConsumerDisconnects uint64 `json:"slow_consumer_disconnects"`
+	HeartbeatTimeouts       uint64 `json:"heartbeat_timeouts"`
+	// Mensajes publicados en el backplane, fallos al publicar o suscribirse y saltos en
+	// la numeración que obligaron a resincronizar a los clientes
+	BackplanePublished uint64 `json:"backplane_published"`
+	BackplaneErrors    uint64 `json:"backplane_errors"`
+	BackplaneGaps      uint64 `json:"backplane_gaps"`
 }
@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"ApiSmart/internal/core/domain"
 )
@@ -10,13 +11,222 @@ type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	FindByEmail(ctx context.Context, email string) (*domain.User, error)
 	FindByID(ctx context.Context, id uint) (*domain.User, error)
+	UpdateLocale(ctx context.Context, id uint, locale string) error
+	UpdateRole(ctx context.Context, id uint, role string) error
+	CountByRole(ctx context.Context, role string) (int, error)
 }
 
 type SensorRepository interface {
 	SaveSensorData(ctx context.Context, data *domain.SensorData) error
 	GetAllSensorData(ctx context.Context) ([]domain.SensorData, error)
 	GetLatestSensorData(ctx context.Context) (*domain.SensorData, error)
+	// GetLatestSensorDataByDevice devuelve la última lectura de cada dispositivo
+	GetLatestSensorDataByDevice(ctx context.Context) ([]domain.SensorData, error)
+	SaveMetricReading(ctx context.Context, reading *domain.MetricReading) error
 	SaveAlert(ctx context.Context, alert *domain.Alert) error
-	GetAlerts(ctx context.Context, isRead *bool) ([]domain.Alert, error)
+	GetAlerts(ctx context.Context, filter domain.AlertFilter) (*domain.AlertPage, error)
+	GetAlertStats(ctx context.Context, filter domain.AlertFilter, topDevices int) (*domain.AlertStats, error)
+	FindAlertByID(ctx context.Context, alertID uint) (*domain.Alert, error)
 	MarkAlertAsRead(ctx context.Context, alertID uint) error
+	UpdateAlertState(ctx context.Context, alertID uint, state string) error
+	ResolveActiveAlerts(ctx context.Context, deviceID, rule string) error
+	// Los resúmenes solo incluyen los dispositivos de access; nil no restringe
+	CountAlertsByType(ctx context.Context, access *domain.DeviceAccess, from, to time.Time) ([]domain.AlertTypeCount, error)
+	GetMetricSummaries(ctx context.Context, access *domain.DeviceAccess, from, to time.Time) ([]domain.MetricSummary, error)
+	GetDeviceActivity(ctx context.Context, access *domain.DeviceAccess, from, to time.Time) ([]domain.DeviceActivity, error)
+	// GetLatestMetricReading devuelve la última lectura de la métrica del dispositivo, tanto
+	// de las lecturas completas como de las de una sola métrica
+	GetLatestMetricReading(ctx context.Context, deviceID, sensorType string) (*domain.MetricReading, error)
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *domain.Webhook) error
+	// FindByID y FindByUser solo devuelven los webhooks del usuario
+	FindByID(ctx context.Context, userID, id uint) (*domain.Webhook, error)
+	FindByUser(ctx context.Context, userID uint) ([]domain.Webhook, error)
+	FindActiveByEventType(ctx context.Context, eventType string) ([]domain.Webhook, error)
+	Delete(ctx context.Context, userID, id uint) error
+	SaveDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error
+	// FindDeliveryByID solo devuelve las entregas de los webhooks del usuario
+	FindDeliveryByID(ctx context.Context, userID, id uint) (*domain.WebhookDelivery, error)
+	FindDeliveries(ctx context.Context, webhookID uint, limit int) ([]domain.WebhookDelivery, error)
+}
+
+type NotificationPreferenceRepository interface {
+	Upsert(ctx context.Context, pref *domain.NotificationPreference) error
+	FindByUser(ctx context.Context, userID uint) ([]domain.NotificationPreference, error)
+	FindEnabledByChannel(ctx context.Context, channel string) ([]domain.NotificationPreference, error)
+}
+
+type PushRepository interface {
+	SaveSubscription(ctx context.Context, sub *domain.PushSubscription) error
+	FindSubscriptionsByUser(ctx context.Context, userID uint) ([]domain.PushSubscription, error)
+	DeleteSubscription(ctx context.Context, userID uint, endpoint string) error
+	DeleteSubscriptionByID(ctx context.Context, id uint) error
+	GetVAPIDKeys(ctx context.Context) (publicKey, privateKey string, err error)
+	SaveVAPIDKeys(ctx context.Context, publicKey, privateKey string) error
+}
+
+type SilenceRepository interface {
+	CreateSilence(ctx context.Context, silence *domain.Silence) error
+	FindSilences(ctx context.Context, activeAt *time.Time) ([]domain.Silence, error)
+	FindSilenceByID(ctx context.Context, id uint) (*domain.Silence, error)
+	ExpireSilence(ctx context.Context, id uint, at time.Time) error
+	CreateMaintenanceWindow(ctx context.Context, window *domain.MaintenanceWindow) error
+	FindMaintenanceWindows(ctx context.Context) ([]domain.MaintenanceWindow, error)
+	FindMaintenanceWindowByID(ctx context.Context, id uint) (*domain.MaintenanceWindow, error)
+	DeleteMaintenanceWindow(ctx context.Context, id uint) error
+}
+
+type DigestRepository interface {
+	Upsert(ctx context.Context, sub *domain.DigestSubscription) error
+	FindByUser(ctx context.Context, userID uint) (*domain.DigestSubscription, error)
+	FindDue(ctx context.Context, at time.Time) ([]domain.DigestSubscription, error)
+	// Claim aplaza la ejecución a next solo si la pendiente sigue siendo previous, para que
+	// cada resumen lo envíe una sola instancia
+	Claim(ctx context.Context, id uint, previous, next time.Time) (bool, error)
+	MarkSent(ctx context.Context, id uint, sentAt, nextRunAt time.Time) error
+	// Reschedule cambia la siguiente ejecución sin marcar el resumen como enviado
+	Reschedule(ctx context.Context, id uint, nextRunAt time.Time) error
+	Delete(ctx context.Context, userID uint) error
+}
+
+type MessageTemplateRepository interface {
+	FindAll(ctx context.Context) ([]domain.MessageTemplate, error)
+	Upsert(ctx context.Context, tmpl *domain.MessageTemplate) error
+	Delete(ctx context.Context, locale, key string) error
+}
+
+type DeviceRepository interface {
+	// Touch registra una lectura del dispositivo, creándolo si no existe, y devuelve su estado
+	// anterior. readingID 0 conserva la última lectura completa conocida
+	Touch(ctx context.Context, deviceID string, readingID uint, at time.Time, defaultInterval int) (string, error)
+	FindAll(ctx context.Context) ([]domain.Device, error)
+	FindByID(ctx context.Context, id string) (*domain.Device, error)
+	Update(ctx context.Context, id string, req domain.UpdateDeviceRequest) error
+	SetGarden(ctx context.Context, id string, gardenID *uint) error
+	// SetStatus cambia el estado solo si era otro y devuelve si lo cambió, para que cada
+	// transición la registre una sola instancia
+	SetStatus(ctx context.Context, id, status string) (bool, error)
+	// SetTokenHash guarda el hash del token de conexión, creando el dispositivo si no existe
+	SetTokenHash(ctx context.Context, id, tokenHash string, defaultInterval int) error
+	// GetTokenHash devuelve el hash del token, o "" si el dispositivo no tiene token
+	GetTokenHash(ctx context.Context, id string) (string, error)
+}
+
+type GardenRepository interface {
+	// Create guarda el jardín y registra a su propietario como miembro
+	Create(ctx context.Context, garden *domain.Garden) error
+	FindByUser(ctx context.Context, userID uint) ([]domain.Garden, error)
+	FindByID(ctx context.Context, id uint) (*domain.Garden, error)
+	Update(ctx context.Context, garden *domain.Garden) error
+	// GetMemberRole devuelve el rol del usuario en el jardín, o "" si no es miembro
+	GetMemberRole(ctx context.Context, gardenID, userID uint) (string, error)
+	FindMembers(ctx context.Context, gardenID uint) ([]domain.GardenMember, error)
+	AddMember(ctx context.Context, gardenID, userID uint, role string) error
+	RemoveMember(ctx context.Context, gardenID, userID uint) error
+	FindDeviceIDsByUser(ctx context.Context, userID uint) ([]string, error)
+}
+
+type PresenceRepository interface {
+	RecordEvent(ctx context.Context, event *domain.PresenceEvent) error
+	FindEvents(ctx context.Context, deviceID string, limit int) ([]domain.PresenceEvent, error)
+	// SetConnections guarda las conexiones abiertas con el dispositivo en una instancia;
+	// con 0 se eliminan
+	SetConnections(ctx context.Context, instanceID, deviceID string, connections int, at time.Time) error
+	// TouchConnections confirma que las conexiones de la instancia siguen abiertas
+	TouchConnections(ctx context.Context, instanceID string, at time.Time) error
+	// CountConnections suma por dispositivo las conexiones confirmadas desde since
+	CountConnections(ctx context.Context, since time.Time) (map[string]int, error)
+	DeleteStaleConnections(ctx context.Context, before time.Time) error
+	// SetOnline guarda la presencia del dispositivo e indica si ha cambiado; solo una
+	// instancia obtiene cada cambio
+	SetOnline(ctx context.Context, deviceID string, online bool) (bool, error)
+}
+
+type ActuatorRepository interface {
+	Create(ctx context.Context, actuator *domain.Actuator) error
+	FindAll(ctx context.Context) ([]domain.Actuator, error)
+	FindByDevices(ctx context.Context, deviceIDs []string) ([]domain.Actuator, error)
+	FindByID(ctx context.Context, id uint) (*domain.Actuator, error)
+	Delete(ctx context.Context, id uint) error
+	UpdateState(ctx context.Context, actuator *domain.Actuator) error
+	// EndTimedRuns apaga los actuadores cuyo encendido temporal terminó antes de now y
+	// devuelve los que apagó
+	EndTimedRuns(ctx context.Context, now time.Time) ([]domain.Actuator, error)
+
+	CreateCommand(ctx context.Context, command *domain.ActuatorCommand) error
+	FindCommandByID(ctx context.Context, id uint) (*domain.ActuatorCommand, error)
+	FindCommands(ctx context.Context, actuatorID uint, limit int) ([]domain.ActuatorCommand, error)
+	// FindOpenCommands devuelve los comandos del dispositivo pendientes de confirmar y sin expirar
+	FindOpenCommands(ctx context.Context, deviceID string, now time.Time) ([]domain.ActuatorCommand, error)
+	// FindExpiredCommands devuelve los comandos sin confirmar cuyo plazo venció antes de now
+	FindExpiredCommands(ctx context.Context, now time.Time) ([]domain.ActuatorCommand, error)
+	MarkCommandsSent(ctx context.Context, ids []uint, at time.Time) error
+	// CloseCommand guarda el resultado de un comando si aún esperaba confirmación e indica
+	// si lo hizo, para que una confirmación y su expiración no se pisen
+	CloseCommand(ctx context.Context, command *domain.ActuatorCommand) (bool, error)
+}
+
+type AutomationRepository interface {
+	Create(ctx context.Context, automation *domain.Automation) error
+	// Update guarda la definición y el interruptor, y reinicia la evaluación de la condición
+	Update(ctx context.Context, automation *domain.Automation) error
+	FindAll(ctx context.Context) ([]domain.Automation, error)
+	FindByDevices(ctx context.Context, deviceIDs []string) ([]domain.Automation, error)
+	FindByID(ctx context.Context, id uint) (*domain.Automation, error)
+	FindEnabledByDevice(ctx context.Context, deviceID string) ([]domain.Automation, error)
+	Delete(ctx context.Context, id uint) error
+	SetConditionSince(ctx context.Context, id uint, since *time.Time) error
+	// MarkTriggered registra la ejecución solo si la última sigue siendo previous, para
+	// que dos lecturas simultáneas no la disparen dos veces
+	MarkTriggered(ctx context.Context, id uint, previous *time.Time, at time.Time) (bool, error)
+	RecordExecution(ctx context.Context, execution *domain.AutomationExecution) error
+	FindExecutions(ctx context.Context, automationID uint, limit int) ([]domain.AutomationExecution, error)
+	CountExecutionsSince(ctx context.Context, automationID uint, status string, since time.Time) (int, error)
+}
+
+type ScheduleRepository interface {
+	Create(ctx context.Context, schedule *domain.Schedule) error
+	Update(ctx context.Context, schedule *domain.Schedule) error
+	FindAll(ctx context.Context) ([]domain.Schedule, error)
+	FindByID(ctx context.Context, id uint) (*domain.Schedule, error)
+	Delete(ctx context.Context, id uint) error
+	FindDue(ctx context.Context, at time.Time) ([]domain.Schedule, error)
+	// Advance pasa a la siguiente ejecución solo si la pendiente sigue siendo previous, para
+	// que cada ejecución la realice una sola instancia
+	Advance(ctx context.Context, id uint, previous time.Time, next *time.Time, ranAt time.Time) (bool, error)
+	RecordRun(ctx context.Context, run *domain.ScheduleRun) error
+	FindRuns(ctx context.Context, scheduleID uint, limit int) ([]domain.ScheduleRun, error)
+}
+
+type ControlLoopRepository interface {
+	Create(ctx context.Context, loop *domain.ControlLoop) error
+	// Update guarda la definición, el interruptor y el estado del controlador
+	Update(ctx context.Context, loop *domain.ControlLoop) error
+	// UpdateTuning guarda el objetivo y las ganancias sin tocar el estado del controlador
+	UpdateTuning(ctx context.Context, loop *domain.ControlLoop) error
+	FindAll(ctx context.Context) ([]domain.ControlLoop, error)
+	FindByDevices(ctx context.Context, deviceIDs []string) ([]domain.ControlLoop, error)
+	FindByID(ctx context.Context, id uint) (*domain.ControlLoop, error)
+	FindEnabled(ctx context.Context) ([]domain.ControlLoop, error)
+	Delete(ctx context.Context, id uint) error
+	// SaveState guarda el estado tras un paso solo si la última lectura procesada sigue
+	// siendo previous, para que cada lectura la procese una sola instancia
+	SaveState(ctx context.Context, loop *domain.ControlLoop, previous *time.Time) (bool, error)
+	// SetOutput guarda la potencia enviada al actuador y el comando que la lleva
+	SetOutput(ctx context.Context, id uint, output int, commandID uint) error
+	RecordSample(ctx context.Context, sample *domain.ControlLoopSample) error
+	FindSamples(ctx context.Context, loopID uint, limit int) ([]domain.ControlLoopSample, error)
+}
+
+type DeviceTwinRepository interface {
+	// Find devuelve el gemelo del dispositivo, vacío y en la versión 0 si aún no tiene
+	Find(ctx context.Context, deviceID string) (*domain.DeviceTwin, error)
+	// UpdateDesired guarda la configuración deseada y aumenta su versión solo si la versión
+	// sigue siendo expectedVersion
+	UpdateDesired(ctx context.Context, deviceID string, desired domain.DeviceConfig, expectedVersion int, at time.Time) (bool, error)
+	// UpdateReported guarda la configuración comunicada y numera el informe, solo si la
+	// versión aplicada no es anterior a la guardada ni posterior a la deseada
+	UpdateReported(ctx context.Context, deviceID string, req domain.ReportConfigRequest, at time.Time) (bool, error)
 }
@@ -2,24 +2,251 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"ApiSmart/internal/core/domain"
+	"ApiSmart/pkg/email"
 )
 
 type AuthService interface {
 	Register(ctx context.Context, req domain.RegisterRequest) (*domain.AuthResponse, error)
 	Login(ctx context.Context, req domain.LoginRequest) (*domain.AuthResponse, error)
 	ValidateToken(token string) (uint, error)
+	GetUser(ctx context.Context, userID uint) (*domain.User, error)
+	UpdatePreferences(ctx context.Context, userID uint, req domain.UpdateUserPreferencesRequest) (*domain.User, error)
+	// BootstrapAdmins da el rol de administrador a las cuentas ya registradas con los correos
+	// configurados, solo mientras no haya ningún administrador
+	BootstrapAdmins(ctx context.Context) error
+	SetRole(ctx context.Context, userID uint, role string) (*domain.User, error)
 }
 
 type SensorService interface {
 	SaveSensorData(ctx context.Context, data *domain.SensorData) error
+	// SaveMetricReading guarda una lectura de una sola métrica y genera sus alertas
+	SaveMetricReading(ctx context.Context, reading *domain.MetricReading) error
+	RecordAlerts(ctx context.Context, alerts []domain.Alert) error
 	GetAllSensorData(ctx context.Context) ([]domain.SensorData, error)
 	GetLatestSensorData(ctx context.Context) (*domain.SensorData, error)
-	GetAlerts(ctx context.Context, isRead *bool) ([]domain.Alert, error)
-	MarkAlertAsRead(ctx context.Context, alertID uint) error
+	GetLatestSensorDataByDevice(ctx context.Context) ([]domain.SensorData, error)
+	// Las consultas y cambios de alertas se limitan a los dispositivos visibles para el usuario
+	GetAlerts(ctx context.Context, userID uint, filter domain.AlertFilter) (*domain.AlertPage, error)
+	GetAlertStats(ctx context.Context, userID uint, from, to *time.Time) (*domain.AlertStats, error)
+	MarkAlertAsRead(ctx context.Context, userID, alertID uint) error
+	AcknowledgeAlert(ctx context.Context, userID, alertID uint) error
+	ResolveAlert(ctx context.Context, userID, alertID uint) error
 }
 
 type AlertService interface {
 	CheckAndCreateAlerts(data *domain.SensorData) []domain.Alert
+	// CheckMetric evalúa una lectura aislada de una métrica con los mismos umbrales
+	CheckMetric(reading domain.MetricReading) []domain.Alert
+}
+
+// Notifier recibe los eventos generados en la ingesta de lecturas
+type Notifier interface {
+	NotifyReading(ctx context.Context, data domain.SensorData)
+	NotifyAlert(ctx context.Context, alert domain.Alert)
+	// Drain deja de aceptar notificaciones y espera a que terminen las que están en curso
+	Drain(ctx context.Context) error
+}
+
+// EventPublisher difunde en tiempo real las lecturas guardadas y las alertas no
+// silenciadas a los paneles conectados
+type EventPublisher interface {
+	PublishReading(ctx context.Context, data domain.SensorData)
+	PublishAlert(ctx context.Context, alert domain.Alert)
+	PublishPresence(ctx context.Context, presence domain.DevicePresence)
+	PublishSystemStatus(ctx context.Context, status domain.SystemStatus)
+	// PublishCommand envía un comando solo a las conexiones del dispositivo
+	PublishCommand(ctx context.Context, command domain.ActuatorCommand)
+	// PublishActuator difunde el estado de un actuador y, si lo hay, el comando que lo cambió
+	PublishActuator(ctx context.Context, actuator domain.Actuator, command *domain.ActuatorCommand)
+	// PublishTwinDelta envía la configuración pendiente solo a las conexiones del dispositivo
+	PublishTwinDelta(ctx context.Context, delta domain.DeviceTwinDelta)
+}
+
+// Backplane reparte los mensajes del servidor WebSocket entre todas las instancias de la
+// API. Publish asigna a cada mensaje un número de secuencia global y lo entrega una vez
+// a cada instancia suscrita, incluida la que lo publicó, en el mismo orden para todas
+type Backplane interface {
+	Publish(ctx context.Context, message []byte) error
+	// Subscribe entrega los mensajes al handler hasta que se cancela el contexto o se
+	// pierde la conexión
+	Subscribe(ctx context.Context, handler func(seq uint64, message []byte)) error
+	Close() error
+}
+
+// ChannelNotifier es un canal de notificación a usuarios que también entrega resúmenes
+type ChannelNotifier interface {
+	Notifier
+	Channel() string
+	DeliverDigest(ctx context.Context, user domain.User, digest domain.Digest) error
+}
+
+type WebhookService interface {
+	Notifier
+	CreateWebhook(ctx context.Context, userID uint, req domain.CreateWebhookRequest) (*domain.Webhook, error)
+	GetWebhooks(ctx context.Context, userID uint) ([]domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, id uint) error
+	GetDeliveries(ctx context.Context, userID, webhookID uint) ([]domain.WebhookDelivery, error)
+	Redeliver(ctx context.Context, userID, deliveryID uint) error
+}
+
+type NotificationService interface {
+	GetPreferences(ctx context.Context, userID uint) ([]domain.NotificationPreference, error)
+	UpdatePreference(ctx context.Context, userID uint, channel string, req domain.UpdateNotificationPreferenceRequest) (*domain.NotificationPreference, error)
+}
+
+// Mailer envía mensajes de correo electrónico
+type Mailer interface {
+	Send(msg email.Message) error
+}
+
+type PushService interface {
+	ChannelNotifier
+	VAPIDPublicKey() string
+	Subscribe(ctx context.Context, userID uint, req domain.PushSubscribeRequest) (*domain.PushSubscription, error)
+	Unsubscribe(ctx context.Context, userID uint, endpoint string) error
+}
+
+type SilenceService interface {
+	CreateSilence(ctx context.Context, userID uint, req domain.CreateSilenceRequest) (*domain.Silence, error)
+	GetSilences(ctx context.Context, userID uint, activeOnly bool) ([]domain.Silence, error)
+	ExpireSilence(ctx context.Context, userID, id uint) error
+	CreateMaintenanceWindow(ctx context.Context, userID uint, req domain.CreateMaintenanceWindowRequest) (*domain.MaintenanceWindow, error)
+	GetMaintenanceWindows(ctx context.Context, userID uint) ([]domain.MaintenanceWindow, error)
+	DeleteMaintenanceWindow(ctx context.Context, userID, id uint) error
+	// MarkSilenced marca las alertas que coinciden con un silencio vigente o una ventana de
+	// mantenimiento activa; los silencios se cargan una vez para todo el lote
+	MarkSilenced(ctx context.Context, alerts []domain.Alert, at time.Time) error
+}
+
+type DigestService interface {
+	GetSubscription(ctx context.Context, userID uint) (*domain.DigestSubscription, error)
+	UpdateSubscription(ctx context.Context, userID uint, req domain.UpdateDigestSubscriptionRequest) (*domain.DigestSubscription, error)
+	DeleteSubscription(ctx context.Context, userID uint) error
+	// BuildDigest resume el periodo con los dispositivos que el usuario puede ver
+	BuildDigest(ctx context.Context, userID uint, frequency string, from, to time.Time) (*domain.Digest, error)
+	Run(ctx context.Context)
+}
+
+// MessageService genera los textos de las alertas a partir de plantillas por idioma
+type MessageService interface {
+	Render(locale, key string, params map[string]interface{}) string
+	LocalizeAlerts(locale string, alerts []domain.Alert)
+	ResolveLocale(ctx context.Context, userID uint, acceptLanguage string) string
+	LoadOverrides(ctx context.Context) error
+	GetTemplates(locale string) ([]domain.MessageTemplate, error)
+	UpdateTemplate(ctx context.Context, userID uint, locale, key string, req domain.UpdateMessageTemplateRequest) (*domain.MessageTemplate, error)
+	ResetTemplate(ctx context.Context, locale, key string) error
+}
+
+type DeviceService interface {
+	// GetDevices devuelve los dispositivos de los jardines del usuario
+	GetDevices(ctx context.Context, userID uint) ([]domain.Device, error)
+	GetDevice(ctx context.Context, userID uint, id string) (*domain.Device, error)
+	UpdateDevice(ctx context.Context, userID uint, id string, req domain.UpdateDeviceRequest) (*domain.Device, error)
+	// GenerateToken crea un nuevo token de conexión para el dispositivo; solo se muestra una vez
+	GenerateToken(ctx context.Context, id string) (string, error)
+	AuthenticateDevice(ctx context.Context, id, token string) error
+	Run(ctx context.Context)
+}
+
+type GardenService interface {
+	CreateGarden(ctx context.Context, userID uint, req domain.CreateGardenRequest) (*domain.Garden, error)
+	GetGardens(ctx context.Context, userID uint) ([]domain.Garden, error)
+	GetGarden(ctx context.Context, userID, gardenID uint) (*domain.Garden, error)
+	UpdateGarden(ctx context.Context, userID, gardenID uint, req domain.UpdateGardenRequest) (*domain.Garden, error)
+	GetMembers(ctx context.Context, userID, gardenID uint) ([]domain.GardenMember, error)
+	AddMember(ctx context.Context, userID, gardenID uint, req domain.AddGardenMemberRequest) error
+	RemoveMember(ctx context.Context, userID, gardenID, memberID uint) error
+	AssignDevice(ctx context.Context, userID, gardenID uint, deviceID string) error
+	UnassignDevice(ctx context.Context, userID, gardenID uint, deviceID string) error
+	// DeviceAccess calcula los dispositivos cuyos eventos puede recibir el usuario
+	DeviceAccess(ctx context.Context, userID uint) (*domain.DeviceAccess, error)
+}
+
+// PresenceService calcula la presencia de los dispositivos a partir de sus conexiones en
+// tiempo real y de sus lecturas recientes
+type PresenceService interface {
+	DeviceConnected(ctx context.Context, deviceID, transport string)
+	DeviceDisconnected(ctx context.Context, deviceID, transport string)
+	// GetStatus calcula el estado del sistema con los dispositivos visibles para el usuario
+	GetStatus(ctx context.Context, access *domain.DeviceAccess) (*domain.SystemStatus, error)
+	GetHistory(ctx context.Context, deviceID string, limit int) ([]domain.PresenceEvent, error)
+	Run(ctx context.Context)
+}
+
+// ActuatorService gestiona los actuadores y los comandos que se envían a los dispositivos
+type ActuatorService interface {
+	CreateActuator(ctx context.Context, req domain.CreateActuatorRequest) (*domain.Actuator, error)
+	DeleteActuator(ctx context.Context, id uint) error
+	GetActuators(ctx context.Context, userID uint) ([]domain.Actuator, error)
+	GetActuator(ctx context.Context, userID, id uint) (*domain.Actuator, error)
+	SendCommand(ctx context.Context, userID, actuatorID uint, req domain.ActuatorCommandRequest) (*domain.ActuatorCommand, error)
+	GetCommands(ctx context.Context, userID, actuatorID uint) ([]domain.ActuatorCommand, error)
+	// PendingCommands entrega al dispositivo los comandos que aún debe ejecutar. Incluye los
+	// enviados que no ha confirmado, por si no llegaron; el dispositivo descarta los que ya
+	// ejecutó por su ID
+	PendingCommands(ctx context.Context, deviceID string) ([]domain.ActuatorCommand, error)
+	// MarkCommandSent registra que el comando se entregó a una conexión del dispositivo
+	MarkCommandSent(ctx context.Context, commandID uint) error
+	AcknowledgeCommand(ctx context.Context, deviceID string, ack domain.CommandAck) (*domain.ActuatorCommand, error)
+	// Run expira los comandos sin confirmar y apaga los encendidos temporales terminados
+	Run(ctx context.Context)
+}
+
+// AutomationService gestiona las automatizaciones y las evalúa con las lecturas recibidas
+type AutomationService interface {
+	CreateAutomation(ctx context.Context, userID uint, req domain.AutomationRequest) (*domain.Automation, error)
+	GetAutomations(ctx context.Context, userID uint) ([]domain.Automation, error)
+	GetAutomation(ctx context.Context, userID, id uint) (*domain.Automation, error)
+	UpdateAutomation(ctx context.Context, userID, id uint, req domain.AutomationRequest) (*domain.Automation, error)
+	SetEnabled(ctx context.Context, userID, id uint, enabled bool) (*domain.Automation, error)
+	DeleteAutomation(ctx context.Context, userID, id uint) error
+	GetExecutions(ctx context.Context, userID, id uint) ([]domain.AutomationExecution, error)
+	// EvaluateReading y EvaluateMetric evalúan las automatizaciones del dispositivo; los
+	// errores se registran sin interrumpir la ingesta
+	EvaluateReading(ctx context.Context, data domain.SensorData)
+	EvaluateMetric(ctx context.Context, reading domain.MetricReading)
+}
+
+// ScheduleService gestiona las programaciones y las ejecuciones periódicas de los actuadores
+type ScheduleService interface {
+	CreateSchedule(ctx context.Context, userID uint, req domain.ScheduleRequest) (*domain.Schedule, error)
+	GetSchedules(ctx context.Context, userID uint) ([]domain.Schedule, error)
+	GetSchedule(ctx context.Context, userID, id uint) (*domain.Schedule, error)
+	UpdateSchedule(ctx context.Context, userID, id uint, req domain.ScheduleRequest) (*domain.Schedule, error)
+	SetEnabled(ctx context.Context, userID, id uint, enabled bool) (*domain.Schedule, error)
+	DeleteSchedule(ctx context.Context, userID, id uint) error
+	GetRuns(ctx context.Context, userID, id uint) ([]domain.ScheduleRun, error)
+	// Preview calcula las próximas count ejecuciones
+	Preview(ctx context.Context, userID, id uint, count int) (*domain.SchedulePreview, error)
+	// Run ejecuta las programaciones pendientes hasta que se cancele el contexto
+	Run(ctx context.Context)
+}
+
+// ControlLoopService gestiona los lazos de control PID y los ejecuta en segundo plano
+type ControlLoopService interface {
+	CreateControlLoop(ctx context.Context, userID uint, req domain.ControlLoopRequest) (*domain.ControlLoop, error)
+	GetControlLoops(ctx context.Context, userID uint) ([]domain.ControlLoop, error)
+	GetControlLoop(ctx context.Context, userID, id uint) (*domain.ControlLoop, error)
+	UpdateControlLoop(ctx context.Context, userID, id uint, req domain.ControlLoopRequest) (*domain.ControlLoop, error)
+	// TuneControlLoop cambia el objetivo y las ganancias sin reiniciar el controlador
+	TuneControlLoop(ctx context.Context, userID, id uint, req domain.TuneControlLoopRequest) (*domain.ControlLoop, error)
+	SetEnabled(ctx context.Context, userID, id uint, enabled bool) (*domain.ControlLoop, error)
+	DeleteControlLoop(ctx context.Context, userID, id uint) error
+	// GetSamples devuelve el registro del controlador, del paso más reciente al más antiguo
+	GetSamples(ctx context.Context, userID, id uint, limit int) ([]domain.ControlLoopSample, error)
+	// Run ejecuta los lazos activos hasta que se cancele el contexto
+	Run(ctx context.Context)
+}
+
+// DeviceTwinService gestiona la configuración deseada de los dispositivos y la que comunican
+type DeviceTwinService interface {
+	GetTwin(ctx context.Context, userID uint, deviceID string) (*domain.DeviceTwin, error)
+	UpdateDesired(ctx context.Context, userID uint, deviceID string, req domain.UpdateDesiredConfigRequest) (*domain.DeviceTwin, error)
+	// PendingDelta entrega al dispositivo la configuración que aún debe aplicar
+	PendingDelta(ctx context.Context, deviceID string) (*domain.DeviceTwinDelta, error)
+	ReportConfig(ctx context.Context, deviceID string, req domain.ReportConfigRequest) (*domain.DeviceTwin, error)
 }
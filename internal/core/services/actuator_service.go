@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+const (
+	// Comandos devueltos en el historial de un actuador
+	actuatorCommandLimit = 100
+	// Frecuencia de la revisión de comandos sin confirmar y encendidos temporales
+	actuatorCheckInterval = 5 * time.Second
+)
+
+type actuatorService struct {
+	actuatorRepo  ports.ActuatorRepository
+	deviceRepo    ports.DeviceRepository
+	gardenService ports.GardenService
+	publisher     ports.EventPublisher
+	ackTimeout    time.Duration
+}
+
+// NewActuatorService crea el servicio de actuadores; los comandos que el dispositivo no
+// confirma en ackTimeout se dan por fallidos
+func NewActuatorService(actuatorRepo ports.ActuatorRepository, deviceRepo ports.DeviceRepository, gardenService ports.GardenService, publisher ports.EventPublisher, ackTimeout time.Duration) ports.ActuatorService {
+	return &actuatorService{
+		actuatorRepo:  actuatorRepo,
+		deviceRepo:    deviceRepo,
+		gardenService: gardenService,
+		publisher:     publisher,
+		ackTimeout:    ackTimeout,
+	}
+}
+
+func (s *actuatorService) CreateActuator(ctx context.Context, req domain.CreateActuatorRequest) (*domain.Actuator, error) {
+	if _, err := s.deviceRepo.FindByID(ctx, req.DeviceID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	actuator := &domain.Actuator{
+		DeviceID:  req.DeviceID,
+		Name:      req.Name,
+		Type:      req.Type,
+		Channel:   req.Channel,
+		State:     domain.ActuatorStateOff,
+		UpdatedAt: now,
+		CreatedAt: now,
+	}
+
+	if err := s.actuatorRepo.Create(ctx, actuator); err != nil {
+		return nil, err
+	}
+
+	return actuator, nil
+}
+
+func (s *actuatorService) DeleteActuator(ctx context.Context, id uint) error {
+	return s.actuatorRepo.Delete(ctx, id)
+}
+
+func (s *actuatorService) GetActuators(ctx context.Context, userID uint) ([]domain.Actuator, error) {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if access.All {
+		return s.actuatorRepo.FindAll(ctx)
+	}
+	return s.actuatorRepo.FindByDevices(ctx, access.IDs())
+}
+
+func (s *actuatorService) GetActuator(ctx context.Context, userID, id uint) (*domain.Actuator, error) {
+	actuator, err := s.actuatorRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.authorize(ctx, userID, actuator); err != nil {
+		return nil, err
+	}
+
+	return actuator, nil
+}
+
+func (s *actuatorService) SendCommand(ctx context.Context, userID, actuatorID uint, req domain.ActuatorCommandRequest) (*domain.ActuatorCommand, error) {
+	if err := validateCommandRequest(req); err != nil {
+		return nil, err
+	}
+
+	actuator, err := s.GetActuator(ctx, userID, actuatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	command := &domain.ActuatorCommand{
+		ActuatorID: actuator.ID,
+		DeviceID:   actuator.DeviceID,
+		Channel:    actuator.Channel,
+		Action:     req.Action,
+		Status:     domain.CommandStatusPending,
+		IssuedBy:   userID,
+	}
+
+	switch req.Action {
+	case domain.CommandActionDutyCycle:
+		command.DutyCycle = req.DutyCycle
+	case domain.CommandActionRun:
+		command.DurationSeconds = req.DurationSeconds
+	}
+
+	command.CreatedAt = time.Now()
+	command.ExpiresAt = command.CreatedAt.Add(s.ackTimeout)
+
+	if err := s.actuatorRepo.CreateCommand(ctx, command); err != nil {
+		return nil, err
+	}
+
+	// Si el dispositivo no está conectado, lo recibirá al conectar o al consultar sus comandos
+	s.publisher.PublishCommand(ctx, *command)
+	s.publisher.PublishActuator(ctx, *actuator, command)
+
+	return command, nil
+}
+
+func (s *actuatorService) GetCommands(ctx context.Context, userID, actuatorID uint) ([]domain.ActuatorCommand, error) {
+	if _, err := s.GetActuator(ctx, userID, actuatorID); err != nil {
+		return nil, err
+	}
+
+	return s.actuatorRepo.FindCommands(ctx, actuatorID, actuatorCommandLimit)
+}
+
+func (s *actuatorService) PendingCommands(ctx context.Context, deviceID string) ([]domain.ActuatorCommand, error) {
+	now := time.Now()
+
+	commands, err := s.actuatorRepo.FindOpenCommands(ctx, deviceID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(commands))
+	for i := range commands {
+		ids = append(ids, commands[i].ID)
+		commands[i].Status = domain.CommandStatusSent
+		if commands[i].SentAt == nil {
+			commands[i].SentAt = &now
+		}
+	}
+
+	if err := s.actuatorRepo.MarkCommandsSent(ctx, ids, now); err != nil {
+		return nil, err
+	}
+
+	return commands, nil
+}
+
+func (s *actuatorService) MarkCommandSent(ctx context.Context, commandID uint) error {
+	return s.actuatorRepo.MarkCommandsSent(ctx, []uint{commandID}, time.Now())
+}
+
+// AcknowledgeCommand registra el resultado de un comando y, si se ejecutó, actualiza el
+// estado del actuador. Repetir la confirmación de un comando ya cerrado no tiene efecto
+func (s *actuatorService) AcknowledgeCommand(ctx context.Context, deviceID string, ack domain.CommandAck) (*domain.ActuatorCommand, error) {
+	command, err := s.actuatorRepo.FindCommandByID(ctx, ack.CommandID)
+	if err != nil {
+		return nil, err
+	}
+	if command.DeviceID != deviceID {
+		return nil, errors.New("comando no encontrado")
+	}
+
+	switch command.Status {
+	case domain.CommandStatusAcked, domain.CommandStatusFailed:
+		return command, nil
+	case domain.CommandStatusTimeout:
+		return nil, domain.ErrCommandExpired
+	}
+
+	now := time.Now()
+	command.AckedAt = &now
+	command.Status = domain.CommandStatusAcked
+	command.Error = ""
+	if !ack.Success {
+		command.Status = domain.CommandStatusFailed
+		command.Error = ack.Error
+		if command.Error == "" {
+			command.Error = "el dispositivo no pudo ejecutar el comando"
+		}
+	}
+
+	closed, err := s.actuatorRepo.CloseCommand(ctx, command)
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		// Expiró o se confirmó a la vez por otro camino
+		return nil, domain.ErrCommandExpired
+	}
+
+	actuator, err := s.actuatorRepo.FindByID(ctx, command.ActuatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if command.Status == domain.CommandStatusAcked {
+		applyCommand(actuator, command, now)
+		if err := s.actuatorRepo.UpdateState(ctx, actuator); err != nil {
+			return nil, err
+		}
+	}
+
+	s.publisher.PublishActuator(ctx, *actuator, command)
+
+	return command, nil
+}
+
+// Run revisa periódicamente los comandos y los encendidos temporales hasta que se
+// cancele el contexto
+func (s *actuatorService) Run(ctx context.Context) {
+	ticker := time.NewTicker(actuatorCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// La revisión en curso termina aunque se detenga la API
+			s.expireCommands(context.WithoutCancel(ctx), now)
+			s.endTimedRuns(context.WithoutCancel(ctx), now)
+		}
+	}
+}
+
+// expireCommands da por fallidos los comandos que el dispositivo no confirmó a tiempo
+func (s *actuatorService) expireCommands(ctx context.Context, now time.Time) {
+	commands, err := s.actuatorRepo.FindExpiredCommands(ctx, now)
+	if err != nil {
+		log.Printf("Error al buscar comandos expirados: %v", err)
+		return
+	}
+
+	for _, command := range commands {
+		command.Status = domain.CommandStatusTimeout
+		command.Error = "el dispositivo no confirmó el comando a tiempo"
+
+		closed, err := s.actuatorRepo.CloseCommand(ctx, &command)
+		if err != nil {
+			log.Printf("Error al expirar el comando %d: %v", command.ID, err)
+			continue
+		}
+		if !closed {
+			continue
+		}
+
+		actuator, err := s.actuatorRepo.FindByID(ctx, command.ActuatorID)
+		if err != nil {
+			log.Printf("Error al obtener el actuador %d: %v", command.ActuatorID, err)
+			continue
+		}
+		s.publisher.PublishActuator(ctx, *actuator, &command)
+	}
+}
+
+// endTimedRuns apaga los actuadores cuyo encendido temporal terminó; el dispositivo ya
+// los apaga por su cuenta al acabar la duración del comando
+func (s *actuatorService) endTimedRuns(ctx context.Context, now time.Time) {
+	actuators, err := s.actuatorRepo.EndTimedRuns(ctx, now)
+	if err != nil {
+		log.Printf("Error al terminar los encendidos temporales: %v", err)
+		return
+	}
+
+	for _, actuator := range actuators {
+		s.publisher.PublishActuator(ctx, actuator, nil)
+	}
+}
+
+// authorize comprueba que el usuario puede ver el dispositivo del actuador
+func (s *actuatorService) authorize(ctx context.Context, userID uint, actuator *domain.Actuator) error {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !access.Allows(actuator.DeviceID) {
+		return domain.ErrGardenAccessDenied
+	}
+	return nil
+}
+
+// validateCommandRequest comprueba que la acción lleva los parámetros que necesita
+func validateCommandRequest(req domain.ActuatorCommandRequest) error {
+	switch req.Action {
+	case domain.CommandActionDutyCycle:
+		if req.DutyCycle == nil {
+			return errors.New("la acción duty_cycle requiere duty_cycle")
+		}
+	case domain.CommandActionRun:
+		if req.DurationSeconds == nil {
+			return errors.New("la acción run requiere duration_seconds")
+		}
+	}
+	return nil
+}
+
+// applyCommand actualiza el estado del actuador con un comando ejecutado
+func applyCommand(actuator *domain.Actuator, command *domain.ActuatorCommand, at time.Time) {
+	actuator.StateUntil = nil
+	actuator.UpdatedAt = at
+
+	switch command.Action {
+	case domain.CommandActionOn:
+		actuator.State = domain.ActuatorStateOn
+		actuator.DutyCycle = 100
+	case domain.CommandActionOff:
+		actuator.State = domain.ActuatorStateOff
+		actuator.DutyCycle = 0
+	case domain.CommandActionDutyCycle:
+		actuator.DutyCycle = *command.DutyCycle
+		actuator.State = domain.ActuatorStateOff
+		if actuator.DutyCycle > 0 {
+			actuator.State = domain.ActuatorStateOn
+		}
+	case domain.CommandActionRun:
+		until := at.Add(time.Duration(*command.DurationSeconds) * time.Second)
+		actuator.State = domain.ActuatorStateOn
+		actuator.DutyCycle = 100
+		actuator.StateUntil = &until
+	}
+}
@@ -1,92 +1,279 @@
 package services
 
 import (
-	"fmt"
+	"math"
+	"sync"
+	"time"
 
 	"ApiSmart/internal/core/domain"
 	"ApiSmart/internal/core/ports"
 )
 
+const (
+	// Desviación relativa respecto al umbral a partir de la cual una alerta es crítica
+	criticalDeviation = 0.25
+	// Fracción de la ventana de una regla de velocidad que debe cubrir el historial antes de
+	// calcular la variación, para que el ruido entre lecturas seguidas no dispare alertas
+	rateMinWindowFraction = 0.5
+	// Frecuencia con la que se descartan los historiales de los dispositivos sin lecturas
+	rateHistorySweepInterval = 10 * time.Minute
+)
+
+// Muestra del historial usado por las reglas de velocidad de cambio
+type rateSample struct {
+	at    time.Time
+	value float64
+}
+
+// rateHistory guarda las muestras recientes de una métrica de un dispositivo y las reglas
+// que ya tienen una alerta abierta, que no se repite hasta que la variación vuelve al límite
+type rateHistory struct {
+	samples  []rateSample
+	alerting map[domain.RateOfChangeRule]bool
+}
+
 type alertService struct {
 	thresholds domain.AlertThresholds
+	messages   ports.MessageService
+
+	// Historial reciente por dispositivo y métrica para las reglas de velocidad de cambio
+	mu        sync.Mutex
+	history   map[string]*rateHistory
+	lastSweep time.Time
 }
 
-func NewAlertService() ports.AlertService {
+func NewAlertService(messages ports.MessageService) ports.AlertService {
 	return &alertService{
 		thresholds: domain.DefaultAlertThresholds,
+		messages:   messages,
+		history:    make(map[string]*rateHistory),
 	}
 }
 
 func (s *alertService) CheckAndCreateAlerts(data *domain.SensorData) []domain.Alert {
 	alerts := []domain.Alert{}
 
-	// Verificar temperatura
-	if data.TemperaturaDHT > s.thresholds.TemperaturaMax {
-		alerts = append(alerts, domain.Alert{
-			SensorID:   data.ID,
-			SensorType: "temperatura",
-			Value:      data.TemperaturaDHT,
-			Message:    fmt.Sprintf("Temperatura alta: %.2f°C - Ha superado el umbral de %.2f°C", data.TemperaturaDHT, s.thresholds.TemperaturaMax),
-			IsRead:     false,
-		})
-	} else if data.TemperaturaDHT < s.thresholds.TemperaturaMin {
-		alerts = append(alerts, domain.Alert{
-			SensorID:   data.ID,
-			SensorType: "temperatura",
-			Value:      data.TemperaturaDHT,
-			Message:    fmt.Sprintf("Temperatura baja: %.2f°C - Por debajo del umbral de %.2f°C", data.TemperaturaDHT, s.thresholds.TemperaturaMin),
-			IsRead:     false,
-		})
-	}
-
-	// Verificar luz
-	if data.Luz > s.thresholds.LuzMax {
-		alerts = append(alerts, domain.Alert{
-			SensorID:   data.ID,
-			SensorType: "luz",
-			Value:      data.Luz,
-			Message:    fmt.Sprintf("Nivel de luz alto: %.2f%% - Ha superado el umbral de %.2f%%", data.Luz, s.thresholds.LuzMax),
-			IsRead:     false,
-		})
-	} else if data.Luz < s.thresholds.LuzMin {
-		alerts = append(alerts, domain.Alert{
-			SensorID:   data.ID,
-			SensorType: "luz",
-			Value:      data.Luz,
-			Message:    fmt.Sprintf("Nivel de luz bajo: %.2f%% - Por debajo del umbral de %.2f%%", data.Luz, s.thresholds.LuzMin),
-			IsRead:     false,
-		})
-	}
-
-	// Verificar humedad
-	if data.Humedad > s.thresholds.HumedadMax {
-		alerts = append(alerts, domain.Alert{
-			SensorID:   data.ID,
-			SensorType: "humedad",
-			Value:      data.Humedad,
-			Message:    fmt.Sprintf("Nivel de humedad alto: %.2f%% - Ha superado el umbral de %.2f%%", data.Humedad, s.thresholds.HumedadMax),
-			IsRead:     false,
-		})
-	} else if data.Humedad < s.thresholds.HumedadMin {
-		alerts = append(alerts, domain.Alert{
-			SensorID:   data.ID,
-			SensorType: "humedad",
-			Value:      data.Humedad,
-			Message:    fmt.Sprintf("Nivel de humedad bajo: %.2f%% - Por debajo del umbral de %.2f%%", data.Humedad, s.thresholds.HumedadMin),
-			IsRead:     false,
-		})
-	}
-
-	// Verificar humo
-	if data.Humo > s.thresholds.HumoMax {
-		alerts = append(alerts, domain.Alert{
-			SensorID:   data.ID,
-			SensorType: "humo",
-			Value:      data.Humo,
-			Message:    fmt.Sprintf("Nivel de humo alto: %.2f%% - Ha superado el umbral de %.2f%%", data.Humo, s.thresholds.HumoMax),
-			IsRead:     false,
-		})
+	alerts = append(alerts, s.evaluate(data.ID, data.DeviceID, "temperatura", data.TemperaturaDHT, data.CreatedAt)...)
+	alerts = append(alerts, s.evaluate(data.ID, data.DeviceID, "luz", data.Luz, data.CreatedAt)...)
+	alerts = append(alerts, s.evaluate(data.ID, data.DeviceID, "humedad", data.Humedad, data.CreatedAt)...)
+	alerts = append(alerts, s.evaluate(data.ID, data.DeviceID, "humo", data.Humo, data.CreatedAt)...)
+
+	return alerts
+}
+
+func (s *alertService) CheckMetric(reading domain.MetricReading) []domain.Alert {
+	if reading.DeviceID == "" {
+		reading.DeviceID = domain.DefaultDeviceID
+	}
+	if reading.CreatedAt.IsZero() {
+		reading.CreatedAt = time.Now()
+	}
+
+	return s.evaluate(0, reading.DeviceID, reading.SensorType, reading.Value, reading.CreatedAt)
+}
+
+// evaluate aplica los umbrales absolutos y las reglas de velocidad de cambio a un valor
+func (s *alertService) evaluate(sensorID uint, deviceID, sensorType string, value float64, at time.Time) []domain.Alert {
+	alerts := []domain.Alert{}
+
+	if alert := s.checkThresholds(sensorID, deviceID, sensorType, value); alert != nil {
+		alerts = append(alerts, *alert)
+	}
+
+	return append(alerts, s.checkRates(sensorID, deviceID, sensorType, value, at)...)
+}
+
+// checkThresholds compara el valor con los umbrales absolutos de su métrica
+func (s *alertService) checkThresholds(sensorID uint, deviceID, sensorType string, value float64) *domain.Alert {
+	var min, max *float64
+
+	switch sensorType {
+	case "temperatura":
+		min, max = &s.thresholds.TemperaturaMin, &s.thresholds.TemperaturaMax
+	case "luz":
+		min, max = &s.thresholds.LuzMin, &s.thresholds.LuzMax
+	case "humedad":
+		min, max = &s.thresholds.HumedadMin, &s.thresholds.HumedadMax
+	case "humo":
+		max = &s.thresholds.HumoMax
+	case "ph":
+		min, max = &s.thresholds.PHMin, &s.thresholds.PHMax
+	default:
+		return nil
+	}
+
+	var alert domain.Alert
+	switch {
+	case max != nil && value > *max:
+		alert = s.newAlert(sensorID, deviceID, sensorType, sensorType+"_max", value, *max)
+	case min != nil && value < *min:
+		alert = s.newAlert(sensorID, deviceID, sensorType, sensorType+"_min", value, *min)
+	default:
+		return nil
+	}
+
+	return &alert
+}
+
+// checkRates añade el valor al historial del dispositivo y compara, para cada regla de la
+// métrica, la variación desde la muestra más antigua de la ventana con el límite permitido.
+// Solo se alerta al superar el límite, no en cada lectura mientras siga superado
+func (s *alertService) checkRates(sensorID uint, deviceID, sensorType string, value float64, at time.Time) []domain.Alert {
+	alerts := []domain.Alert{}
+
+	rules := []domain.RateOfChangeRule{}
+	for _, rule := range s.thresholds.RateRules {
+		if rule.SensorType == sensorType && rule.Per > 0 {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return alerts
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	window := longestWindow(rules)
+	s.sweepHistory(at)
+	history := s.recordHistory(deviceID+"/"+sensorType, window, rateSample{at: at, value: value})
+
+	for _, rule := range rules {
+		// La muestra más antigua dentro de la ventana de la regla
+		var oldest *rateSample
+		for i := range history.samples {
+			if at.Sub(history.samples[i].at) <= rule.Window {
+				oldest = &history.samples[i]
+				break
+			}
+		}
+		if oldest == nil {
+			continue
+		}
+
+		elapsed := at.Sub(oldest.at)
+		if elapsed < time.Duration(float64(rule.Window)*rateMinWindowFraction) {
+			continue
+		}
+
+		rate := (value - oldest.value) / float64(elapsed) * float64(rule.Per)
+		if math.Abs(rate) <= rule.MaxChange {
+			delete(history.alerting, rule)
+			continue
+		}
+		if history.alerting[rule] {
+			continue
+		}
+
+		history.alerting[rule] = true
+		alerts = append(alerts, s.newRateAlert(sensorID, deviceID, rule, rate))
 	}
 
 	return alerts
 }
+
+// recordHistory guarda la muestra y descarta las que ya quedan fuera de todas las ventanas
+func (s *alertService) recordHistory(key string, window time.Duration, sample rateSample) *rateHistory {
+	history, ok := s.history[key]
+	if !ok {
+		history = &rateHistory{alerting: make(map[domain.RateOfChangeRule]bool)}
+		s.history[key] = history
+	}
+
+	start := 0
+	for start < len(history.samples) && sample.at.Sub(history.samples[start].at) > window {
+		start++
+	}
+	history.samples = append(history.samples[start:], sample)
+
+	return history
+}
+
+// sweepHistory descarta cada cierto tiempo los historiales cuya última muestra ya queda
+// fuera de todas las ventanas, de dispositivos que dejaron de enviar lecturas
+func (s *alertService) sweepHistory(now time.Time) {
+	if now.Sub(s.lastSweep) < rateHistorySweepInterval {
+		return
+	}
+	s.lastSweep = now
+	window := longestWindow(s.thresholds.RateRules)
+
+	for key, history := range s.history {
+		last := history.samples[len(history.samples)-1]
+		if now.Sub(last.at) > window {
+			delete(s.history, key)
+		}
+	}
+}
+
+// longestWindow devuelve la ventana más larga de las reglas
+func longestWindow(rules []domain.RateOfChangeRule) time.Duration {
+	var window time.Duration
+	for _, rule := range rules {
+		if rule.Window > window {
+			window = rule.Window
+		}
+	}
+	return window
+}
+
+// newAlert construye una alerta activa para el valor indicado. El mensaje se guarda
+// en el idioma predeterminado junto con su clave y parámetros para traducirlo al leerlo
+func (s *alertService) newAlert(sensorID uint, deviceID, sensorType, rule string, value, threshold float64) domain.Alert {
+	key := "alert." + rule
+	params := map[string]interface{}{
+		"value":     value,
+		"threshold": threshold,
+	}
+
+	return domain.Alert{
+		SensorID:      sensorID,
+		DeviceID:      deviceID,
+		SensorType:    sensorType,
+		Rule:          rule,
+		Severity:      severityFor(value, threshold),
+		State:         domain.AlertStateActive,
+		Value:         value,
+		Message:       s.messages.Render(domain.DefaultLocale, key, params),
+		MessageKey:    key,
+		MessageParams: params,
+		IsRead:        false,
+	}
+}
+
+// newRateAlert construye la alerta de una regla de velocidad de cambio; el valor es la
+// variación medida en la unidad de la regla
+func (s *alertService) newRateAlert(sensorID uint, deviceID string, rule domain.RateOfChangeRule, rate float64) domain.Alert {
+	alert := s.newAlert(sensorID, deviceID, rule.SensorType, rule.SensorType+"_rate", rate, rule.MaxChange)
+
+	alert.Severity = severityFor(math.Abs(rate), rule.MaxChange)
+	alert.MessageParams["unit"] = rateUnit(rule.Per)
+	alert.Message = s.messages.Render(domain.DefaultLocale, alert.MessageKey, alert.MessageParams)
+
+	return alert
+}
+
+// rateUnit abrevia la unidad de tiempo de una regla para los mensajes
+func rateUnit(per time.Duration) string {
+	switch per {
+	case time.Second:
+		return "s"
+	case time.Minute:
+		return "min"
+	case time.Hour:
+		return "h"
+	default:
+		return per.String()
+	}
+}
+
+// severityFor clasifica la alerta según cuánto se aleja el valor del umbral
+func severityFor(value, threshold float64) string {
+	if threshold == 0 {
+		return domain.AlertSeverityWarning
+	}
+	if math.Abs(value-threshold)/math.Abs(threshold) >= criticalDeviation {
+		return domain.AlertSeverityCritical
+	}
+	return domain.AlertSeverityWarning
+}
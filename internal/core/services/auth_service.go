@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"ApiSmart/internal/core/domain"
@@ -12,12 +13,16 @@ import (
 )
 
 type authService struct {
-	userRepo ports.UserRepository
+	userRepo    ports.UserRepository
+	adminEmails []string
 }
 
-func NewAuthService(userRepo ports.UserRepository) ports.AuthService {
+// NewAuthService recibe los correos de las cuentas que BootstrapAdmins convierte en
+// administradores. El registro nunca concede el rol, porque el correo no se verifica
+func NewAuthService(userRepo ports.UserRepository, adminEmails []string) ports.AuthService {
 	return &authService{
-		userRepo: userRepo,
+		userRepo:    userRepo,
+		adminEmails: adminEmails,
 	}
 }
 
@@ -40,6 +45,7 @@ func (s *authService) Register(ctx context.Context, req domain.RegisterRequest)
 		Username:  req.Username,
 		Email:     req.Email,
 		Password:  string(hashedPassword),
+		Role:      domain.RoleUser,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -91,3 +97,63 @@ func (s *authService) Login(ctx context.Context, req domain.LoginRequest) (*doma
 func (s *authService) ValidateToken(token string) (uint, error) {
 	return auth.ValidateJWT(token)
 }
+
+func (s *authService) GetUser(ctx context.Context, userID uint) (*domain.User, error) {
+	return s.userRepo.FindByID(ctx, userID)
+}
+
+func (s *authService) UpdatePreferences(ctx context.Context, userID uint, req domain.UpdateUserPreferencesRequest) (*domain.User, error) {
+	if err := s.userRepo.UpdateLocale(ctx, userID, req.Locale); err != nil {
+		return nil, err
+	}
+	return s.userRepo.FindByID(ctx, userID)
+}
+
+func (s *authService) BootstrapAdmins(ctx context.Context) error {
+	if len(s.adminEmails) == 0 {
+		return nil
+	}
+
+	// Una vez hay administradores, los demás se nombran desde la API
+	admins, err := s.userRepo.CountByRole(ctx, domain.RoleAdmin)
+	if err != nil || admins > 0 {
+		return err
+	}
+
+	for _, email := range s.adminEmails {
+		user, err := s.userRepo.FindByEmail(ctx, email)
+		if err != nil {
+			log.Printf("Administrador inicial %s sin cuenta registrada", email)
+			continue
+		}
+		if err := s.userRepo.UpdateRole(ctx, user.ID, domain.RoleAdmin); err != nil {
+			return err
+		}
+		log.Printf("Usuario %d (%s) promovido a administrador", user.ID, email)
+	}
+
+	return nil
+}
+
+func (s *authService) SetRole(ctx context.Context, userID uint, role string) (*domain.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Role == domain.RoleAdmin && role != domain.RoleAdmin {
+		admins, err := s.userRepo.CountByRole(ctx, domain.RoleAdmin)
+		if err != nil {
+			return nil, err
+		}
+		if admins <= 1 {
+			return nil, errors.New("no se puede quitar el rol al último administrador")
+		}
+	}
+
+	if err := s.userRepo.UpdateRole(ctx, userID, role); err != nil {
+		return nil, err
+	}
+
+	return s.userRepo.FindByID(ctx, userID)
+}
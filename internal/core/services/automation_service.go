@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+const (
+	// Ejecuciones devueltas en el historial de una automatización
+	automationExecutionLimit = 100
+	// Longitud máxima de los errores guardados en los historiales de ejecuciones
+	historyErrorMaxLength = 255
+)
+
+type automationService struct {
+	automationRepo  ports.AutomationRepository
+	deviceRepo      ports.DeviceRepository
+	gardenRepo      ports.GardenRepository
+	actuatorService ports.ActuatorService
+	gardenService   ports.GardenService
+}
+
+// NewAutomationService crea el servicio de automatizaciones; los comandos se emiten a
+// través del servicio de actuadores en nombre de quien creó la automatización
+func NewAutomationService(automationRepo ports.AutomationRepository, deviceRepo ports.DeviceRepository, gardenRepo ports.GardenRepository, actuatorService ports.ActuatorService, gardenService ports.GardenService) ports.AutomationService {
+	return &automationService{
+		automationRepo:  automationRepo,
+		deviceRepo:      deviceRepo,
+		gardenRepo:      gardenRepo,
+		actuatorService: actuatorService,
+		gardenService:   gardenService,
+	}
+}
+
+func (s *automationService) CreateAutomation(ctx context.Context, userID uint, req domain.AutomationRequest) (*domain.Automation, error) {
+	if err := s.validate(ctx, userID, req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	automation := &domain.Automation{
+		Enabled:   true,
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	applyAutomationRequest(automation, req)
+
+	if err := s.automationRepo.Create(ctx, automation); err != nil {
+		return nil, err
+	}
+
+	return automation, nil
+}
+
+func (s *automationService) GetAutomations(ctx context.Context, userID uint) ([]domain.Automation, error) {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if access.All {
+		return s.automationRepo.FindAll(ctx)
+	}
+	return s.automationRepo.FindByDevices(ctx, access.IDs())
+}
+
+func (s *automationService) GetAutomation(ctx context.Context, userID, id uint) (*domain.Automation, error) {
+	automation, err := s.automationRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !access.Allows(automation.DeviceID) {
+		return nil, domain.ErrGardenAccessDenied
+	}
+
+	return automation, nil
+}
+
+func (s *automationService) UpdateAutomation(ctx context.Context, userID, id uint, req domain.AutomationRequest) (*domain.Automation, error) {
+	automation, err := s.GetAutomation(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validate(ctx, userID, req); err != nil {
+		return nil, err
+	}
+
+	applyAutomationRequest(automation, req)
+	automation.UpdatedAt = time.Now()
+
+	if err := s.automationRepo.Update(ctx, automation); err != nil {
+		return nil, err
+	}
+
+	return automation, nil
+}
+
+func (s *automationService) SetEnabled(ctx context.Context, userID, id uint, enabled bool) (*domain.Automation, error) {
+	automation, err := s.GetAutomation(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Al reactivarla la condición vuelve a contar desde la siguiente lectura
+	automation.Enabled = enabled
+	automation.UpdatedAt = time.Now()
+
+	if err := s.automationRepo.Update(ctx, automation); err != nil {
+		return nil, err
+	}
+
+	return automation, nil
+}
+
+func (s *automationService) DeleteAutomation(ctx context.Context, userID, id uint) error {
+	if _, err := s.GetAutomation(ctx, userID, id); err != nil {
+		return err
+	}
+
+	return s.automationRepo.Delete(ctx, id)
+}
+
+func (s *automationService) GetExecutions(ctx context.Context, userID, id uint) ([]domain.AutomationExecution, error) {
+	if _, err := s.GetAutomation(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	return s.automationRepo.FindExecutions(ctx, id, automationExecutionLimit)
+}
+
+func (s *automationService) EvaluateReading(ctx context.Context, data domain.SensorData) {
+	s.evaluate(ctx, data.DeviceID, map[string]float64{
+		"temperatura": data.TemperaturaDHT,
+		"luz":         data.Luz,
+		"humedad":     data.Humedad,
+		"humo":        data.Humo,
+	})
+}
+
+func (s *automationService) EvaluateMetric(ctx context.Context, reading domain.MetricReading) {
+	s.evaluate(ctx, reading.DeviceID, map[string]float64{
+		reading.SensorType: reading.Value,
+	})
+}
+
+// evaluate aplica las automatizaciones activas del dispositivo a las métricas recibidas
+func (s *automationService) evaluate(ctx context.Context, deviceID string, values map[string]float64) {
+	automations, err := s.automationRepo.FindEnabledByDevice(ctx, deviceID)
+	if err != nil {
+		log.Printf("Error al obtener las automatizaciones del dispositivo %s: %v", deviceID, err)
+		return
+	}
+
+	now := time.Now()
+	for _, automation := range automations {
+		value, ok := values[automation.SensorType]
+		if !ok {
+			continue
+		}
+		if err := s.evaluateAutomation(ctx, automation, value, now); err != nil {
+			log.Printf("Error al evaluar la automatización %d: %v", automation.ID, err)
+		}
+	}
+}
+
+// evaluateAutomation lleva la cuenta de cuánto tiempo se cumple la condición y emite el
+// comando cuando se ha mantenido lo suficiente y lo permiten la espera y el límite diario
+func (s *automationService) evaluateAutomation(ctx context.Context, automation domain.Automation, value float64, now time.Time) error {
+	if !automation.Matches(value) {
+		if automation.ConditionSince == nil {
+			return nil
+		}
+		return s.automationRepo.SetConditionSince(ctx, automation.ID, nil)
+	}
+
+	since := automation.ConditionSince
+	if since == nil {
+		since = &now
+		if err := s.automationRepo.SetConditionSince(ctx, automation.ID, since); err != nil {
+			return err
+		}
+	}
+
+	if now.Sub(*since) < time.Duration(automation.ForSeconds)*time.Second {
+		return nil
+	}
+
+	cooldown := time.Duration(automation.CooldownSeconds) * time.Second
+	if last := automation.LastTriggeredAt; last != nil && now.Sub(*last) < cooldown {
+		return nil
+	}
+
+	if automation.MaxPerDay > 0 {
+		// El día se cuenta en la zona horaria del jardín
+		loc, err := s.location(ctx, automation.DeviceID)
+		if err != nil {
+			return err
+		}
+		local := now.In(loc)
+		midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+		count, err := s.automationRepo.CountExecutionsSince(ctx, automation.ID, domain.AutomationTriggered, midnight)
+		if err != nil {
+			return err
+		}
+		if count >= automation.MaxPerDay {
+			return nil
+		}
+	}
+
+	// Otra lectura simultánea pudo dispararla ya
+	marked, err := s.automationRepo.MarkTriggered(ctx, automation.ID, automation.LastTriggeredAt, now)
+	if err != nil || !marked {
+		return err
+	}
+
+	execution := &domain.AutomationExecution{
+		AutomationID: automation.ID,
+		Status:       domain.AutomationTriggered,
+		Value:        value,
+		CreatedAt:    now,
+	}
+
+	command, err := s.actuatorService.SendCommand(ctx, automation.CreatedBy, automation.ActuatorID, automation.CommandRequest())
+	if err != nil {
+		execution.Status = domain.AutomationFailed
+		execution.Error = historyError(err)
+	} else {
+		execution.CommandID = &command.ID
+	}
+
+	return s.automationRepo.RecordExecution(ctx, execution)
+}
+
+// location devuelve la zona horaria del jardín del dispositivo; los dispositivos sin
+// jardín usan UTC
+func (s *automationService) location(ctx context.Context, deviceID string) (*time.Location, error) {
+	device, err := s.deviceRepo.FindByID(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.GardenID == nil {
+		return time.UTC, nil
+	}
+
+	garden, err := s.gardenRepo.FindByID(ctx, *device.GardenID)
+	if err != nil {
+		return nil, err
+	}
+	return garden.Location(), nil
+}
+
+// validate comprueba el comando y que el usuario puede ver el dispositivo y el actuador
+func (s *automationService) validate(ctx context.Context, userID uint, req domain.AutomationRequest) error {
+	err := validateCommandRequest(domain.ActuatorCommandRequest{
+		Action:          req.Action,
+		DutyCycle:       req.DutyCycle,
+		DurationSeconds: req.DurationSeconds,
+	})
+	if err != nil {
+		return err
+	}
+
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !access.Allows(req.DeviceID) {
+		return domain.ErrGardenAccessDenied
+	}
+
+	_, err = s.actuatorService.GetActuator(ctx, userID, req.ActuatorID)
+	return err
+}
+
+// historyError recorta el error para guardarlo en un historial de ejecuciones, sin partir
+// ningún carácter UTF-8
+func historyError(err error) string {
+	message := err.Error()
+	if len(message) <= historyErrorMaxLength {
+		return message
+	}
+
+	end := historyErrorMaxLength
+	for end > 0 && !utf8.RuneStart(message[end]) {
+		end--
+	}
+	return message[:end]
+}
+
+// applyAutomationRequest copia la definición de la petición en la automatización
+func applyAutomationRequest(automation *domain.Automation, req domain.AutomationRequest) {
+	automation.Name = req.Name
+	if req.Enabled != nil {
+		automation.Enabled = *req.Enabled
+	}
+	automation.DeviceID = req.DeviceID
+	automation.SensorType = req.SensorType
+	automation.Operator = req.Operator
+	automation.Threshold = req.Threshold
+	automation.ForSeconds = req.ForSeconds
+	automation.ActuatorID = req.ActuatorID
+	automation.Action = req.Action
+	automation.DutyCycle = nil
+	automation.DurationSeconds = nil
+	switch req.Action {
+	case domain.CommandActionDutyCycle:
+		automation.DutyCycle = req.DutyCycle
+	case domain.CommandActionRun:
+		automation.DurationSeconds = req.DurationSeconds
+	}
+	automation.CooldownSeconds = req.CooldownSeconds
+	automation.MaxPerDay = req.MaxPerDay
+}
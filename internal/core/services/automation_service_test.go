@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestHistoryError(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"corto", "sin conexión", "sin conexión"},
+		{"justo el máximo", strings.Repeat("a", historyErrorMaxLength), strings.Repeat("a", historyErrorMaxLength)},
+		{"ascii largo", strings.Repeat("a", historyErrorMaxLength+10), strings.Repeat("a", historyErrorMaxLength)},
+		// "ó" ocupa dos bytes y el límite cae en su segundo byte
+		{"carácter partido", strings.Repeat("a", historyErrorMaxLength-1) + "ón", strings.Repeat("a", historyErrorMaxLength-1)},
+		// "€" ocupa tres bytes y el límite cae en su tercer byte
+		{"carácter de tres bytes", strings.Repeat("a", historyErrorMaxLength-2) + "€€", strings.Repeat("a", historyErrorMaxLength-2)},
+		{"carácter completo", strings.Repeat("a", historyErrorMaxLength-2) + "ón", strings.Repeat("a", historyErrorMaxLength-2) + "ó"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := historyError(errors.New(tt.message))
+			if got != tt.want {
+				t.Errorf("historyError = %q (%d bytes), se esperaba %q (%d bytes)", got, len(got), tt.want, len(tt.want))
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("historyError devolvió UTF-8 inválido: %q", got)
+			}
+		})
+	}
+}
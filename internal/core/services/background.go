@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"sync"
+)
+
+// background lleva la cuenta de los envíos que un servicio hace en segundo plano, para
+// poder esperarlos al detener la API
+type background struct {
+	mutex    sync.Mutex
+	tasks    sync.WaitGroup
+	draining bool
+	stopping chan struct{}
+}
+
+func newBackground() *background {
+	return &background{stopping: make(chan struct{})}
+}
+
+// start ejecuta la tarea en una goroutine; una vez que se empieza a detener el servicio
+// no se aceptan tareas nuevas y devuelve false
+func (b *background) start(task func()) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.draining {
+		return false
+	}
+	b.tasks.Add(1)
+	go func() {
+		defer b.tasks.Done()
+		task()
+	}()
+	return true
+}
+
+// stopped se cierra al empezar a detener el servicio, para que las tareas largas como
+// los reintentos terminen cuanto antes
+func (b *background) stopped() <-chan struct{} {
+	return b.stopping
+}
+
+// drain deja de aceptar tareas y espera a que terminen las que están en curso o a que
+// venza el contexto
+func (b *background) drain(ctx context.Context) error {
+	b.mutex.Lock()
+	if !b.draining {
+		b.draining = true
+		close(b.stopping)
+	}
+	b.mutex.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		b.tasks.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
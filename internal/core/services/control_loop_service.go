@@ -0,0 +1,341 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+const (
+	// Frecuencia con la que se revisan los lazos de control
+	controlCheckInterval = 5 * time.Second
+	// Periodo de muestreo por defecto de los lazos de control
+	defaultControlSampleSeconds = 30
+	// Antigüedad a partir de la cual una lectura no se usa para controlar; el actuador
+	// conserva la última potencia hasta que lleguen lecturas nuevas
+	controlInputMaxAge = 5 * time.Minute
+	// Pasos devueltos como máximo del registro de un lazo
+	maxControlLoopSamples = 1000
+)
+
+type controlLoopService struct {
+	loopRepo        ports.ControlLoopRepository
+	sensorRepo      ports.SensorRepository
+	actuatorRepo    ports.ActuatorRepository
+	actuatorService ports.ActuatorService
+	gardenService   ports.GardenService
+}
+
+// NewControlLoopService crea el servicio de lazos de control; los comandos se emiten a
+// través del servicio de actuadores en nombre de quien creó el lazo
+func NewControlLoopService(loopRepo ports.ControlLoopRepository, sensorRepo ports.SensorRepository, actuatorRepo ports.ActuatorRepository, actuatorService ports.ActuatorService, gardenService ports.GardenService) ports.ControlLoopService {
+	return &controlLoopService{
+		loopRepo:        loopRepo,
+		sensorRepo:      sensorRepo,
+		actuatorRepo:    actuatorRepo,
+		actuatorService: actuatorService,
+		gardenService:   gardenService,
+	}
+}
+
+func (s *controlLoopService) CreateControlLoop(ctx context.Context, userID uint, req domain.ControlLoopRequest) (*domain.ControlLoop, error) {
+	if err := s.validate(ctx, userID, req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	loop := &domain.ControlLoop{
+		Enabled:   true,
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	applyControlLoopRequest(loop, req)
+
+	if err := s.loopRepo.Create(ctx, loop); err != nil {
+		return nil, err
+	}
+
+	return loop, nil
+}
+
+func (s *controlLoopService) GetControlLoops(ctx context.Context, userID uint) ([]domain.ControlLoop, error) {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if access.All {
+		return s.loopRepo.FindAll(ctx)
+	}
+	return s.loopRepo.FindByDevices(ctx, access.IDs())
+}
+
+func (s *controlLoopService) GetControlLoop(ctx context.Context, userID, id uint) (*domain.ControlLoop, error) {
+	loop, err := s.loopRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !access.Allows(loop.DeviceID) {
+		return nil, domain.ErrGardenAccessDenied
+	}
+
+	return loop, nil
+}
+
+func (s *controlLoopService) UpdateControlLoop(ctx context.Context, userID, id uint, req domain.ControlLoopRequest) (*domain.ControlLoop, error) {
+	loop, err := s.GetControlLoop(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.validate(ctx, userID, req); err != nil {
+		return nil, err
+	}
+
+	// Con otra entrada, salida o límites el estado anterior no sirve
+	applyControlLoopRequest(loop, req)
+	loop.ResetState()
+	loop.UpdatedAt = time.Now()
+
+	if err := s.loopRepo.Update(ctx, loop); err != nil {
+		return nil, err
+	}
+
+	return loop, nil
+}
+
+func (s *controlLoopService) TuneControlLoop(ctx context.Context, userID, id uint, req domain.TuneControlLoopRequest) (*domain.ControlLoop, error) {
+	loop, err := s.GetControlLoop(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// El término integral ya incluye Ki, así que cambiar las ganancias no provoca saltos
+	if req.Setpoint != nil {
+		loop.Setpoint = *req.Setpoint
+	}
+	if req.Kp != nil {
+		loop.Kp = *req.Kp
+	}
+	if req.Ki != nil {
+		loop.Ki = *req.Ki
+	}
+	if req.Kd != nil {
+		loop.Kd = *req.Kd
+	}
+	loop.UpdatedAt = time.Now()
+
+	if err := s.loopRepo.UpdateTuning(ctx, loop); err != nil {
+		return nil, err
+	}
+
+	return loop, nil
+}
+
+func (s *controlLoopService) SetEnabled(ctx context.Context, userID, id uint, enabled bool) (*domain.ControlLoop, error) {
+	loop, err := s.GetControlLoop(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Al desactivarlo el actuador conserva la última potencia; al reactivarlo el
+	// controlador empieza de cero
+	loop.Enabled = enabled
+	loop.ResetState()
+	loop.UpdatedAt = time.Now()
+
+	if err := s.loopRepo.Update(ctx, loop); err != nil {
+		return nil, err
+	}
+
+	return loop, nil
+}
+
+func (s *controlLoopService) DeleteControlLoop(ctx context.Context, userID, id uint) error {
+	if _, err := s.GetControlLoop(ctx, userID, id); err != nil {
+		return err
+	}
+
+	return s.loopRepo.Delete(ctx, id)
+}
+
+func (s *controlLoopService) GetSamples(ctx context.Context, userID, id uint, limit int) ([]domain.ControlLoopSample, error) {
+	if _, err := s.GetControlLoop(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	if limit > maxControlLoopSamples {
+		limit = maxControlLoopSamples
+	}
+
+	return s.loopRepo.FindSamples(ctx, id, limit)
+}
+
+// Run ejecuta los lazos de control hasta que se cancele el contexto
+func (s *controlLoopService) Run(ctx context.Context) {
+	ticker := time.NewTicker(controlCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// El paso en curso termina aunque se detenga la API
+			s.runDue(context.WithoutCancel(ctx), now)
+		}
+	}
+}
+
+func (s *controlLoopService) runDue(ctx context.Context, now time.Time) {
+	loops, err := s.loopRepo.FindEnabled(ctx)
+	if err != nil {
+		log.Printf("Error al obtener los lazos de control: %v", err)
+		return
+	}
+
+	for _, loop := range loops {
+		sample := time.Duration(loop.SampleSeconds) * time.Second
+		if loop.LastUpdateAt != nil && now.Sub(*loop.LastUpdateAt) < sample {
+			continue
+		}
+		if err := s.step(ctx, loop, now); err != nil {
+			log.Printf("Error en el lazo de control %d: %v", loop.ID, err)
+		}
+	}
+}
+
+// step procesa la última lectura de la entrada del lazo, si es nueva, y envía la potencia
+// al actuador cuando cambia o cuando el comando anterior no llegó a aplicarse
+func (s *controlLoopService) step(ctx context.Context, loop domain.ControlLoop, now time.Time) error {
+	reading, err := s.sensorRepo.GetLatestMetricReading(ctx, loop.DeviceID, loop.SensorType)
+	if errors.Is(err, domain.ErrNoReadings) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if now.Sub(reading.CreatedAt) > controlInputMaxAge {
+		return nil
+	}
+	previous := loop.LastInputAt
+	if previous != nil && !reading.CreatedAt.After(*previous) {
+		return nil
+	}
+
+	// Tras una interrupción larga no se acumula el tiempo sin lecturas
+	dt := 0.0
+	if previous != nil && reading.CreatedAt.Sub(*previous) <= controlInputMaxAge {
+		dt = reading.CreatedAt.Sub(*previous).Seconds()
+	} else {
+		loop.LastInput = nil
+	}
+
+	sample := loop.Step(reading.Value, dt)
+	loop.LastInputAt = &reading.CreatedAt
+	loop.LastUpdateAt = &now
+
+	// Otra instancia pudo procesar ya esta lectura
+	saved, err := s.loopRepo.SaveState(ctx, &loop, previous)
+	if err != nil || !saved {
+		return err
+	}
+
+	sample.CreatedAt = now
+	send, err := s.needsOutput(ctx, loop, sample.DutyCycle)
+	if err != nil {
+		return err
+	}
+	if send {
+		duty := sample.DutyCycle
+		command, err := s.actuatorService.SendCommand(ctx, loop.CreatedBy, loop.ActuatorID, domain.ActuatorCommandRequest{
+			Action:    domain.CommandActionDutyCycle,
+			DutyCycle: &duty,
+		})
+		if err != nil {
+			// Se reintenta en el siguiente paso
+			sample.CommandError = historyError(err)
+		} else {
+			sample.CommandID = &command.ID
+			if err := s.loopRepo.SetOutput(ctx, loop.ID, duty, command.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.loopRepo.RecordSample(ctx, &sample)
+}
+
+// needsOutput indica si hay que enviar la potencia al actuador: cuando cambia o cuando el
+// comando que la llevaba expiró o falló. Mientras espera confirmación no se repite
+func (s *controlLoopService) needsOutput(ctx context.Context, loop domain.ControlLoop, duty int) (bool, error) {
+	if loop.LastOutput == nil || *loop.LastOutput != duty {
+		return true, nil
+	}
+	if loop.LastCommandID == nil {
+		return false, nil
+	}
+
+	command, err := s.actuatorRepo.FindCommandByID(ctx, *loop.LastCommandID)
+	if err != nil {
+		return false, err
+	}
+
+	switch command.Status {
+	case domain.CommandStatusTimeout, domain.CommandStatusFailed:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// validate comprueba los límites de salida y que el usuario puede ver el dispositivo y el actuador
+func (s *controlLoopService) validate(ctx context.Context, userID uint, req domain.ControlLoopRequest) error {
+	if req.OutputMin >= req.OutputMax {
+		return errors.New("output_min debe ser menor que output_max")
+	}
+
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !access.Allows(req.DeviceID) {
+		return domain.ErrGardenAccessDenied
+	}
+
+	_, err = s.actuatorService.GetActuator(ctx, userID, req.ActuatorID)
+	return err
+}
+
+// applyControlLoopRequest copia la definición de la petición en el lazo
+func applyControlLoopRequest(loop *domain.ControlLoop, req domain.ControlLoopRequest) {
+	loop.Name = req.Name
+	if req.Enabled != nil {
+		loop.Enabled = *req.Enabled
+	}
+	loop.DeviceID = req.DeviceID
+	loop.SensorType = req.SensorType
+	loop.Setpoint = req.Setpoint
+	loop.Kp = req.Kp
+	loop.Ki = req.Ki
+	loop.Kd = req.Kd
+	loop.Reverse = req.Reverse
+	loop.ActuatorID = req.ActuatorID
+	loop.OutputMin = req.OutputMin
+	loop.OutputMax = req.OutputMax
+	loop.SampleSeconds = req.SampleSeconds
+	if loop.SampleSeconds == 0 {
+		loop.SampleSeconds = defaultControlSampleSeconds
+	}
+}
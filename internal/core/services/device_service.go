@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type deviceService struct {
+	deviceRepo    ports.DeviceRepository
+	sensorService ports.SensorService
+	gardenService ports.GardenService
+	messages      ports.MessageService
+	missedAllowed int
+	checkInterval time.Duration
+}
+
+// NewDeviceService crea el servicio de dispositivos; un dispositivo se considera sin datos
+// cuando pasan missedAllowed intervalos esperados sin recibir lecturas
+func NewDeviceService(deviceRepo ports.DeviceRepository, sensorService ports.SensorService, gardenService ports.GardenService, messages ports.MessageService, missedAllowed int, checkInterval time.Duration) ports.DeviceService {
+	return &deviceService{
+		deviceRepo:    deviceRepo,
+		sensorService: sensorService,
+		gardenService: gardenService,
+		messages:      messages,
+		missedAllowed: missedAllowed,
+		checkInterval: checkInterval,
+	}
+}
+
+func (s *deviceService) GetDevices(ctx context.Context, userID uint) ([]domain.Device, error) {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := s.deviceRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []domain.Device{}
+	for _, device := range devices {
+		if access.Allows(device.ID) {
+			visible = append(visible, device)
+		}
+	}
+
+	return visible, nil
+}
+
+func (s *deviceService) GetDevice(ctx context.Context, userID uint, id string) (*domain.Device, error) {
+	if err := s.checkAccess(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	return s.deviceRepo.FindByID(ctx, id)
+}
+
+func (s *deviceService) UpdateDevice(ctx context.Context, userID uint, id string, req domain.UpdateDeviceRequest) (*domain.Device, error) {
+	if err := s.checkAccess(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.deviceRepo.FindByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.deviceRepo.Update(ctx, id, req); err != nil {
+		return nil, err
+	}
+
+	return s.deviceRepo.FindByID(ctx, id)
+}
+
+func (s *deviceService) checkAccess(ctx context.Context, userID uint, deviceID string) error {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !access.Allows(deviceID) {
+		return domain.ErrGardenAccessDenied
+	}
+	return nil
+}
+
+func (s *deviceService) GenerateToken(ctx context.Context, id string) (string, error) {
+	token, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.deviceRepo.SetTokenHash(ctx, id, hashDeviceToken(token), domain.DefaultDeviceIntervalSeconds); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *deviceService) AuthenticateDevice(ctx context.Context, id, token string) error {
+	stored, err := s.deviceRepo.GetTokenHash(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if stored == "" || subtle.ConstantTimeCompare([]byte(stored), []byte(hashDeviceToken(token))) != 1 {
+		return errors.New("dispositivo o token inválido")
+	}
+
+	return nil
+}
+
+// hashDeviceToken calcula el hash con el que se guardan los tokens de los dispositivos
+func hashDeviceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Run revisa periódicamente los dispositivos hasta que se cancele el contexto
+func (s *deviceService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// La revisión en curso termina aunque se detenga la API, para no perder alertas
+			s.checkSilentDevices(context.WithoutCancel(ctx), now)
+		}
+	}
+}
+
+// checkSilentDevices genera una alerta por cada dispositivo que dejó de enviar datos
+func (s *deviceService) checkSilentDevices(ctx context.Context, now time.Time) {
+	devices, err := s.deviceRepo.FindAll(ctx)
+	if err != nil {
+		log.Printf("Error al obtener dispositivos: %v", err)
+		return
+	}
+
+	for _, device := range devices {
+		if device.Status == domain.DeviceStatusSilent || device.LastSeenAt == nil {
+			continue
+		}
+
+		limit := time.Duration(device.ExpectedIntervalSeconds*s.missedAllowed) * time.Second
+		silentFor := now.Sub(*device.LastSeenAt)
+		if silentFor <= limit {
+			continue
+		}
+
+		// Solo registra la alerta la instancia que consigue marcar el dispositivo como sin
+		// datos
+		claimed, err := s.deviceRepo.SetStatus(ctx, device.ID, domain.DeviceStatusSilent)
+		if err != nil {
+			log.Printf("Error al actualizar estado del dispositivo %s: %v", device.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		params := map[string]interface{}{
+			"device_id": device.ID,
+			"minutes":   silentFor.Minutes(),
+		}
+		alert := domain.Alert{
+			SensorID:      device.LastReadingID,
+			DeviceID:      device.ID,
+			SensorType:    domain.DeviceSensorType,
+			Rule:          domain.DeviceSilentRule,
+			Severity:      domain.AlertSeverityCritical,
+			State:         domain.AlertStateActive,
+			Value:         silentFor.Minutes(),
+			Message:       s.messages.Render(domain.DefaultLocale, domain.DeviceSilentMessageKey, params),
+			MessageKey:    domain.DeviceSilentMessageKey,
+			MessageParams: params,
+		}
+
+		if err := s.sensorService.RecordAlerts(ctx, []domain.Alert{alert}); err != nil {
+			log.Printf("Error al registrar alerta del dispositivo %s: %v", device.ID, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type deviceTwinService struct {
+	twinRepo      ports.DeviceTwinRepository
+	deviceRepo    ports.DeviceRepository
+	gardenService ports.GardenService
+	publisher     ports.EventPublisher
+}
+
+// NewDeviceTwinService crea el servicio de configuración de los dispositivos; los cambios de
+// la configuración deseada se envían a los dispositivos conectados a través del publisher
+func NewDeviceTwinService(twinRepo ports.DeviceTwinRepository, deviceRepo ports.DeviceRepository, gardenService ports.GardenService, publisher ports.EventPublisher) ports.DeviceTwinService {
+	return &deviceTwinService{
+		twinRepo:      twinRepo,
+		deviceRepo:    deviceRepo,
+		gardenService: gardenService,
+		publisher:     publisher,
+	}
+}
+
+func (s *deviceTwinService) GetTwin(ctx context.Context, userID uint, deviceID string) (*domain.DeviceTwin, error) {
+	if err := s.checkAccess(ctx, userID, deviceID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.deviceRepo.FindByID(ctx, deviceID); err != nil {
+		return nil, err
+	}
+
+	return s.find(ctx, deviceID)
+}
+
+func (s *deviceTwinService) UpdateDesired(ctx context.Context, userID uint, deviceID string, req domain.UpdateDesiredConfigRequest) (*domain.DeviceTwin, error) {
+	if err := s.checkAccess(ctx, userID, deviceID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.deviceRepo.FindByID(ctx, deviceID); err != nil {
+		return nil, err
+	}
+
+	if err := validateDeviceConfig(req.Config); err != nil {
+		return nil, err
+	}
+
+	// Otro cambio pudo guardarse desde que el usuario leyó la configuración
+	updated, err := s.twinRepo.UpdateDesired(ctx, deviceID, req.Config, *req.Version, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, domain.ErrTwinVersionConflict
+	}
+
+	twin, err := s.find(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los dispositivos sin conexión la reciben al conectar o al consultarla
+	if !twin.InSync {
+		s.publisher.PublishTwinDelta(ctx, twin.TwinDelta())
+	}
+
+	return twin, nil
+}
+
+func (s *deviceTwinService) PendingDelta(ctx context.Context, deviceID string) (*domain.DeviceTwinDelta, error) {
+	twin, err := s.twinRepo.Find(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	delta := twin.TwinDelta()
+	return &delta, nil
+}
+
+func (s *deviceTwinService) ReportConfig(ctx context.Context, deviceID string, req domain.ReportConfigRequest) (*domain.DeviceTwin, error) {
+	if err := validateReport(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrTwinReportInvalid, err)
+	}
+
+	// La versión deseada nunca disminuye, así que una aplicada posterior no puede existir
+	current, err := s.twinRepo.Find(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if req.AppliedVersion > current.DesiredVersion {
+		return nil, fmt.Errorf("%w: la versión aplicada es posterior a la deseada", domain.ErrTwinReportInvalid)
+	}
+
+	// Un informe retrasado no sobrescribe otro de una versión posterior
+	updated, err := s.twinRepo.UpdateReported(ctx, deviceID, req, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, domain.ErrTwinVersionConflict
+	}
+
+	if req.Config.SamplingIntervalSeconds != nil {
+		s.syncExpectedInterval(ctx, deviceID, *req.Config.SamplingIntervalSeconds)
+	}
+
+	return s.find(ctx, deviceID)
+}
+
+// syncExpectedInterval ajusta el intervalo esperado entre lecturas al de muestreo aplicado,
+// para que la presencia no marque como silencioso al dispositivo
+func (s *deviceTwinService) syncExpectedInterval(ctx context.Context, deviceID string, interval int) {
+	device, err := s.deviceRepo.FindByID(ctx, deviceID)
+	if err != nil || device.ExpectedIntervalSeconds == interval || interval < 1 {
+		return
+	}
+
+	err = s.deviceRepo.Update(ctx, deviceID, domain.UpdateDeviceRequest{
+		Name:                    device.Name,
+		ExpectedIntervalSeconds: interval,
+	})
+	if err != nil {
+		log.Printf("Error al actualizar el intervalo esperado del dispositivo %s: %v", deviceID, err)
+	}
+}
+
+func (s *deviceTwinService) find(ctx context.Context, deviceID string) (*domain.DeviceTwin, error) {
+	twin, err := s.twinRepo.Find(ctx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	twin.ComputeDelta()
+	return twin, nil
+}
+
+func (s *deviceTwinService) checkAccess(ctx context.Context, userID uint, deviceID string) error {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !access.Allows(deviceID) {
+		return domain.ErrGardenAccessDenied
+	}
+	return nil
+}
+
+// validateReport aplica las reglas que la API comprueba al leer la petición, ya que los
+// informes recibidos por WebSocket no pasan por ella
+func validateReport(req domain.ReportConfigRequest) error {
+	if req.AppliedVersion < 0 {
+		return errors.New("applied_version no puede ser negativa")
+	}
+	if interval := req.Config.SamplingIntervalSeconds; interval != nil && *interval < 1 {
+		return errors.New("sampling_interval_seconds debe ser al menos 1")
+	}
+	for metric, calibration := range req.Config.Calibration {
+		if calibration.Scale == 0 {
+			return fmt.Errorf("la calibración de %s requiere scale", metric)
+		}
+	}
+	return validateDeviceConfig(req.Config)
+}
+
+// validateDeviceConfig comprueba que los umbrales tienen sentido
+func validateDeviceConfig(config domain.DeviceConfig) error {
+	for metric, threshold := range config.Thresholds {
+		if threshold.Min == nil && threshold.Max == nil {
+			return fmt.Errorf("el umbral de %s no tiene mínimo ni máximo", metric)
+		}
+		if threshold.Min != nil && threshold.Max != nil && *threshold.Min >= *threshold.Max {
+			return fmt.Errorf("el mínimo del umbral de %s debe ser menor que el máximo", metric)
+		}
+	}
+	return nil
+}
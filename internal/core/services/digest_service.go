@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+const (
+	// Frecuencia con la que el planificador busca resúmenes pendientes
+	digestCheckInterval = time.Minute
+	// Espera antes de reintentar un resumen que no se pudo entregar
+	digestRetryDelay = 15 * time.Minute
+)
+
+var digestPeriods = map[string]time.Duration{
+	domain.DigestHourly: time.Hour,
+	domain.DigestDaily:  24 * time.Hour,
+	domain.DigestWeekly: 7 * 24 * time.Hour,
+}
+
+type digestService struct {
+	digestRepo    ports.DigestRepository
+	sensorRepo    ports.SensorRepository
+	userRepo      ports.UserRepository
+	gardenService ports.GardenService
+	channels      map[string]ports.ChannelNotifier
+}
+
+func NewDigestService(digestRepo ports.DigestRepository, sensorRepo ports.SensorRepository, userRepo ports.UserRepository, gardenService ports.GardenService, channels ...ports.ChannelNotifier) ports.DigestService {
+	byName := make(map[string]ports.ChannelNotifier, len(channels))
+	for _, channel := range channels {
+		byName[channel.Channel()] = channel
+	}
+
+	return &digestService{
+		digestRepo:    digestRepo,
+		sensorRepo:    sensorRepo,
+		userRepo:      userRepo,
+		gardenService: gardenService,
+		channels:      byName,
+	}
+}
+
+func (s *digestService) GetSubscription(ctx context.Context, userID uint) (*domain.DigestSubscription, error) {
+	return s.digestRepo.FindByUser(ctx, userID)
+}
+
+func (s *digestService) UpdateSubscription(ctx context.Context, userID uint, req domain.UpdateDigestSubscriptionRequest) (*domain.DigestSubscription, error) {
+	if _, err := time.Parse("15:04", req.TimeOfDay); err != nil {
+		return nil, errors.New("hora inválida, se espera HH:MM")
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, errors.New("zona horaria inválida")
+	}
+
+	sub := &domain.DigestSubscription{
+		UserID:    userID,
+		Frequency: req.Frequency,
+		TimeOfDay: req.TimeOfDay,
+		Weekday:   req.Weekday,
+		Timezone:  timezone,
+		Channels:  req.Channels,
+		Enabled:   req.Enabled,
+	}
+	sub.NextRunAt = nextDigestRun(*sub, time.Now())
+
+	if err := s.digestRepo.Upsert(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (s *digestService) DeleteSubscription(ctx context.Context, userID uint) error {
+	return s.digestRepo.Delete(ctx, userID)
+}
+
+func (s *digestService) BuildDigest(ctx context.Context, userID uint, frequency string, from, to time.Time) (*domain.Digest, error) {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.sensorRepo.CountAlertsByType(ctx, access, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	readings, err := s.sensorRepo.GetMetricSummaries(ctx, access, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := s.sensorRepo.GetDeviceActivity(ctx, access, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, count := range counts {
+		total += count.Count
+	}
+
+	return &domain.Digest{
+		Frequency:   frequency,
+		PeriodStart: from,
+		PeriodEnd:   to,
+		TotalAlerts: total,
+		AlertCounts: counts,
+		Readings:    readings,
+		Devices:     devices,
+	}, nil
+}
+
+// Run ejecuta el planificador de resúmenes hasta que se cancele el contexto
+func (s *digestService) Run(ctx context.Context) {
+	ticker := time.NewTicker(digestCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// Los envíos en curso terminan aunque se detenga la API
+			s.sendDue(context.WithoutCancel(ctx), now)
+		}
+	}
+}
+
+func (s *digestService) sendDue(ctx context.Context, now time.Time) {
+	subs, err := s.digestRepo.FindDue(ctx, now)
+	if err != nil {
+		log.Printf("Error al buscar resúmenes pendientes: %v", err)
+		return
+	}
+
+	for _, sub := range subs {
+		// Solo envía la instancia que consigue reservar la ejecución; si se detiene a mitad,
+		// el resumen se reintenta cuando vence la reserva
+		claimed, err := s.digestRepo.Claim(ctx, sub.ID, sub.NextRunAt, now.Add(digestRetryDelay))
+		if err != nil {
+			log.Printf("Error al reservar resumen %d: %v", sub.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		// Si el servidor estuvo detenido, el resumen cubre todo el tiempo desde el último envío
+		from := now.Add(-digestPeriods[sub.Frequency])
+		if sub.LastSentAt != nil {
+			from = *sub.LastSentAt
+		}
+
+		// Si no se pudo entregar, se reintenta más tarde y el siguiente intento cubre
+		// también este periodo
+		if err := s.deliver(ctx, sub, from, now); err != nil {
+			log.Printf("Error al enviar resumen al usuario %d: %v", sub.UserID, err)
+			if err := s.digestRepo.Reschedule(ctx, sub.ID, now.Add(digestRetryDelay)); err != nil {
+				log.Printf("Error al reprogramar resumen %d: %v", sub.ID, err)
+			}
+			continue
+		}
+
+		if err := s.digestRepo.MarkSent(ctx, sub.ID, now, nextDigestRun(sub, now)); err != nil {
+			log.Printf("Error al actualizar resumen %d: %v", sub.ID, err)
+		}
+	}
+}
+
+func (s *digestService) deliver(ctx context.Context, sub domain.DigestSubscription, from, to time.Time) error {
+	user, err := s.userRepo.FindByID(ctx, sub.UserID)
+	if err != nil {
+		return err
+	}
+
+	digest, err := s.BuildDigest(ctx, sub.UserID, sub.Frequency, from, to)
+	if err != nil {
+		return err
+	}
+
+	// Basta con que llegue por un canal; reintentar reenviaría el resumen por los demás
+	delivered := false
+	var lastErr error
+	for _, name := range sub.Channels {
+		channel, ok := s.channels[name]
+		if !ok {
+			log.Printf("Canal %s no configurado para el resumen del usuario %d", name, sub.UserID)
+			continue
+		}
+		if err := channel.DeliverDigest(ctx, *user, *digest); err != nil {
+			log.Printf("Error al entregar resumen por %s al usuario %d: %v", name, sub.UserID, err)
+			lastErr = err
+			continue
+		}
+		delivered = true
+	}
+
+	if delivered {
+		return nil
+	}
+	return lastErr
+}
+
+// nextDigestRun calcula la siguiente ejecución posterior a after en la zona horaria del usuario
+func nextDigestRun(sub domain.DigestSubscription, after time.Time) time.Time {
+	loc, err := time.LoadLocation(sub.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	at, err := time.Parse("15:04", sub.TimeOfDay)
+	if err != nil {
+		at = time.Time{}
+	}
+
+	local := after.In(loc)
+	var next time.Time
+
+	switch sub.Frequency {
+	case domain.DigestHourly:
+		next = time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), at.Minute(), 0, 0, loc)
+		if !next.After(local) {
+			next = next.Add(time.Hour)
+		}
+	case domain.DigestWeekly:
+		next = time.Date(local.Year(), local.Month(), local.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+		next = next.AddDate(0, 0, (sub.Weekday-int(local.Weekday())+7)%7)
+		if !next.After(local) {
+			next = next.AddDate(0, 0, 7)
+		}
+	default:
+		next = time.Date(local.Year(), local.Month(), local.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+		if !next.After(local) {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+
+	return next
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"log"
+	textTemplate "text/template"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"ApiSmart/pkg/email"
+)
+
+//go:embed templates/*
+var templateFiles embed.FS
+
+var (
+	alertTextTemplate  = textTemplate.Must(textTemplate.ParseFS(templateFiles, "templates/alert_email.txt"))
+	alertHTMLTemplate  = htmlTemplate.Must(htmlTemplate.ParseFS(templateFiles, "templates/alert_email.html"))
+	digestTextTemplate = textTemplate.Must(textTemplate.ParseFS(templateFiles, "templates/digest_email.txt"))
+	digestHTMLTemplate = htmlTemplate.Must(htmlTemplate.ParseFS(templateFiles, "templates/digest_email.html"))
+)
+
+// Datos disponibles en las plantillas de correo de alertas
+type alertEmailData struct {
+	Username string
+	Alert    domain.Alert
+}
+
+// Datos disponibles en las plantillas de correo de resúmenes
+type digestEmailData struct {
+	Username string
+	Digest   domain.Digest
+}
+
+type emailNotifier struct {
+	mailer        ports.Mailer
+	prefRepo      ports.NotificationPreferenceRepository
+	userRepo      ports.UserRepository
+	messages      ports.MessageService
+	gardenService ports.GardenService
+	sending       *background
+}
+
+func NewEmailNotifier(mailer ports.Mailer, prefRepo ports.NotificationPreferenceRepository, userRepo ports.UserRepository, messages ports.MessageService, gardenService ports.GardenService) ports.ChannelNotifier {
+	return &emailNotifier{
+		mailer:        mailer,
+		prefRepo:      prefRepo,
+		userRepo:      userRepo,
+		messages:      messages,
+		gardenService: gardenService,
+		sending:       newBackground(),
+	}
+}
+
+func (n *emailNotifier) Channel() string {
+	return domain.NotificationChannelEmail
+}
+
+// NotifyReading no envía correos: solo las alertas generan notificaciones
+func (n *emailNotifier) NotifyReading(ctx context.Context, data domain.SensorData) {}
+
+func (n *emailNotifier) NotifyAlert(ctx context.Context, alert domain.Alert) {
+	// El envío no debe retrasar la respuesta de la ingesta
+	if !n.sending.start(func() { n.sendAlert(context.Background(), alert) }) {
+		log.Printf("Correo de la alerta %d descartado: el servicio se está deteniendo", alert.ID)
+	}
+}
+
+func (n *emailNotifier) Drain(ctx context.Context) error {
+	return n.sending.drain(ctx)
+}
+
+func (n *emailNotifier) sendAlert(ctx context.Context, alert domain.Alert) {
+	prefs, err := n.prefRepo.FindEnabledByChannel(ctx, domain.NotificationChannelEmail)
+	if err != nil {
+		log.Printf("Error al obtener preferencias de correo: %v", err)
+		return
+	}
+
+	for _, pref := range prefs {
+		if !preferenceMatches(pref, alert) {
+			continue
+		}
+
+		// Solo se avisa a quien puede ver el dispositivo de la alerta
+		access, err := n.gardenService.DeviceAccess(ctx, pref.UserID)
+		if err != nil {
+			log.Printf("Error al obtener los dispositivos del usuario %d: %v", pref.UserID, err)
+			continue
+		}
+		if !access.Allows(alert.DeviceID) {
+			continue
+		}
+
+		user, err := n.userRepo.FindByID(ctx, pref.UserID)
+		if err != nil {
+			log.Printf("Error al obtener usuario %d: %v", pref.UserID, err)
+			continue
+		}
+
+		// Cada destinatario recibe el mensaje en su idioma
+		localized := []domain.Alert{alert}
+		n.messages.LocalizeAlerts(n.messages.ResolveLocale(ctx, user.ID, ""), localized)
+
+		msg, err := renderAlertEmail(user, localized[0])
+		if err != nil {
+			log.Printf("Error al generar correo de alerta para %s: %v", user.Email, err)
+			continue
+		}
+
+		if err := n.mailer.Send(*msg); err != nil {
+			log.Printf("Error al enviar correo a %s: %v", user.Email, err)
+		}
+	}
+}
+
+// renderAlertEmail genera las versiones de texto y HTML del correo de una alerta
+func renderAlertEmail(user *domain.User, alert domain.Alert) (*email.Message, error) {
+	data := alertEmailData{Username: user.Username, Alert: alert}
+
+	var text, html bytes.Buffer
+	if err := alertTextTemplate.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := alertHTMLTemplate.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &email.Message{
+		To:      []string{user.Email},
+		Subject: "[SmartGarden] " + alert.Message,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+func (n *emailNotifier) DeliverDigest(ctx context.Context, user domain.User, digest domain.Digest) error {
+	data := digestEmailData{Username: user.Username, Digest: digest}
+
+	var text, html bytes.Buffer
+	if err := digestTextTemplate.Execute(&text, data); err != nil {
+		return err
+	}
+	if err := digestHTMLTemplate.Execute(&html, data); err != nil {
+		return err
+	}
+
+	return n.mailer.Send(email.Message{
+		To:      []string{user.Email},
+		Subject: fmt.Sprintf("[SmartGarden] Resumen: %d alertas nuevas", digest.TotalAlerts),
+		Text:    text.String(),
+		HTML:    html.String(),
+	})
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type gardenService struct {
+	gardenRepo ports.GardenRepository
+	deviceRepo ports.DeviceRepository
+	userRepo   ports.UserRepository
+}
+
+func NewGardenService(gardenRepo ports.GardenRepository, deviceRepo ports.DeviceRepository, userRepo ports.UserRepository) ports.GardenService {
+	return &gardenService{
+		gardenRepo: gardenRepo,
+		deviceRepo: deviceRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *gardenService) CreateGarden(ctx context.Context, userID uint, req domain.CreateGardenRequest) (*domain.Garden, error) {
+	timezone, err := gardenTimezone(req.Timezone, req.Latitude, req.Longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	garden := &domain.Garden{
+		Name:      req.Name,
+		OwnerID:   userID,
+		Timezone:  timezone,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+	}
+
+	if err := s.gardenRepo.Create(ctx, garden); err != nil {
+		return nil, err
+	}
+
+	return garden, nil
+}
+
+func (s *gardenService) GetGardens(ctx context.Context, userID uint) ([]domain.Garden, error) {
+	return s.gardenRepo.FindByUser(ctx, userID)
+}
+
+func (s *gardenService) GetGarden(ctx context.Context, userID, gardenID uint) (*domain.Garden, error) {
+	if err := s.authorize(ctx, userID, gardenID, false); err != nil {
+		return nil, err
+	}
+
+	return s.gardenRepo.FindByID(ctx, gardenID)
+}
+
+func (s *gardenService) UpdateGarden(ctx context.Context, userID, gardenID uint, req domain.UpdateGardenRequest) (*domain.Garden, error) {
+	if err := s.authorize(ctx, userID, gardenID, true); err != nil {
+		return nil, err
+	}
+
+	timezone, err := gardenTimezone(req.Timezone, req.Latitude, req.Longitude)
+	if err != nil {
+		return nil, err
+	}
+
+	garden, err := s.gardenRepo.FindByID(ctx, gardenID)
+	if err != nil {
+		return nil, err
+	}
+
+	garden.Name = req.Name
+	garden.Timezone = timezone
+	garden.Latitude = req.Latitude
+	garden.Longitude = req.Longitude
+
+	if err := s.gardenRepo.Update(ctx, garden); err != nil {
+		return nil, err
+	}
+
+	return garden, nil
+}
+
+func (s *gardenService) GetMembers(ctx context.Context, userID, gardenID uint) ([]domain.GardenMember, error) {
+	if err := s.authorize(ctx, userID, gardenID, false); err != nil {
+		return nil, err
+	}
+
+	return s.gardenRepo.FindMembers(ctx, gardenID)
+}
+
+func (s *gardenService) AddMember(ctx context.Context, userID, gardenID uint, req domain.AddGardenMemberRequest) error {
+	if err := s.authorize(ctx, userID, gardenID, true); err != nil {
+		return err
+	}
+
+	member, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil {
+		return err
+	}
+
+	return s.gardenRepo.AddMember(ctx, gardenID, member.ID, domain.GardenRoleMember)
+}
+
+func (s *gardenService) RemoveMember(ctx context.Context, userID, gardenID, memberID uint) error {
+	if err := s.authorize(ctx, userID, gardenID, true); err != nil {
+		return err
+	}
+
+	role, err := s.gardenRepo.GetMemberRole(ctx, gardenID, memberID)
+	if err != nil {
+		return err
+	}
+	if role == domain.GardenRoleOwner {
+		return errors.New("no se puede quitar al propietario del jardín")
+	}
+
+	return s.gardenRepo.RemoveMember(ctx, gardenID, memberID)
+}
+
+func (s *gardenService) AssignDevice(ctx context.Context, userID, gardenID uint, deviceID string) error {
+	if err := s.authorize(ctx, userID, gardenID, true); err != nil {
+		return err
+	}
+
+	return s.deviceRepo.SetGarden(ctx, deviceID, &gardenID)
+}
+
+func (s *gardenService) UnassignDevice(ctx context.Context, userID, gardenID uint, deviceID string) error {
+	if err := s.authorize(ctx, userID, gardenID, true); err != nil {
+		return err
+	}
+
+	device, err := s.deviceRepo.FindByID(ctx, deviceID)
+	if err != nil {
+		return err
+	}
+	if device.GardenID == nil || *device.GardenID != gardenID {
+		return errors.New("el dispositivo no pertenece a este jardín")
+	}
+
+	return s.deviceRepo.SetGarden(ctx, deviceID, nil)
+}
+
+func (s *gardenService) DeviceAccess(ctx context.Context, userID uint) (*domain.DeviceAccess, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == domain.RoleAdmin {
+		return &domain.DeviceAccess{All: true}, nil
+	}
+
+	ids, err := s.gardenRepo.FindDeviceIDsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	access := &domain.DeviceAccess{DeviceIDs: make(map[string]bool, len(ids))}
+	for _, id := range ids {
+		access.DeviceIDs[id] = true
+	}
+
+	return access, nil
+}
+
+// gardenTimezone valida la zona horaria y la ubicación del jardín y devuelve la zona
+// horaria que se guarda
+func gardenTimezone(timezone string, latitude, longitude *float64) (string, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return "", errors.New("zona horaria inválida")
+	}
+	if (latitude == nil) != (longitude == nil) {
+		return "", errors.New("la ubicación requiere latitud y longitud")
+	}
+	return timezone, nil
+}
+
+// authorize comprueba que el usuario sea miembro del jardín, o su propietario si va a
+// gestionarlo. Los administradores tienen acceso a todos los jardines
+func (s *gardenService) authorize(ctx context.Context, userID, gardenID uint, manage bool) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.Role == domain.RoleAdmin {
+		return nil
+	}
+
+	role, err := s.gardenRepo.GetMemberRole(ctx, gardenID, userID)
+	if err != nil {
+		return err
+	}
+	if role == "" || (manage && role != domain.GardenRoleOwner) {
+		return domain.ErrGardenAccessDenied
+	}
+
+	return nil
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type messageService struct {
+	templateRepo ports.MessageTemplateRepository
+	userRepo     ports.UserRepository
+	defaults     map[string]map[string]string // idioma -> clave -> plantilla
+	mutex        sync.RWMutex
+	overrides    map[string]map[string]domain.MessageTemplate
+	compiled     map[string]map[string]*template.Template
+}
+
+// NewMessageService carga los catálogos de mensajes incluidos en el binario
+func NewMessageService(templateRepo ports.MessageTemplateRepository, userRepo ports.UserRepository) (ports.MessageService, error) {
+	s := &messageService{
+		templateRepo: templateRepo,
+		userRepo:     userRepo,
+		defaults:     map[string]map[string]string{},
+		overrides:    map[string]map[string]domain.MessageTemplate{},
+	}
+
+	for _, locale := range domain.SupportedLocales {
+		data, err := templateFiles.ReadFile("templates/messages/" + locale + ".json")
+		if err != nil {
+			return nil, err
+		}
+
+		catalog := map[string]string{}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("catálogo %s inválido: %w", locale, err)
+		}
+		s.defaults[locale] = catalog
+	}
+
+	if err := s.compile(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *messageService) Render(locale, key string, params map[string]interface{}) string {
+	s.mutex.RLock()
+	tmpl, ok := s.compiled[locale][key]
+	if !ok {
+		tmpl, ok = s.compiled[domain.DefaultLocale][key]
+	}
+	s.mutex.RUnlock()
+
+	if !ok {
+		return key
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, params); err != nil {
+		return key
+	}
+	return buf.String()
+}
+
+func (s *messageService) LocalizeAlerts(locale string, alerts []domain.Alert) {
+	for i := range alerts {
+		if alerts[i].MessageKey != "" {
+			alerts[i].Message = s.Render(locale, alerts[i].MessageKey, alerts[i].MessageParams)
+		}
+	}
+}
+
+// ResolveLocale elige el idioma del usuario, luego el de Accept-Language y por último el predeterminado
+func (s *messageService) ResolveLocale(ctx context.Context, userID uint, acceptLanguage string) string {
+	if userID != 0 {
+		if user, err := s.userRepo.FindByID(ctx, userID); err == nil && isSupportedLocale(user.Locale) {
+			return user.Locale
+		}
+	}
+
+	if locale := parseAcceptLanguage(acceptLanguage); locale != "" {
+		return locale
+	}
+
+	return domain.DefaultLocale
+}
+
+func (s *messageService) LoadOverrides(ctx context.Context) error {
+	templates, err := s.templateRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.overrides = map[string]map[string]domain.MessageTemplate{}
+	for _, tmpl := range templates {
+		if s.overrides[tmpl.Locale] == nil {
+			s.overrides[tmpl.Locale] = map[string]domain.MessageTemplate{}
+		}
+		s.overrides[tmpl.Locale][tmpl.Key] = tmpl
+	}
+	s.mutex.Unlock()
+
+	return s.compile()
+}
+
+func (s *messageService) GetTemplates(locale string) ([]domain.MessageTemplate, error) {
+	if !isSupportedLocale(locale) {
+		return nil, errors.New("idioma no soportado")
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	templates := []domain.MessageTemplate{}
+	for key, text := range s.defaults[locale] {
+		if override, ok := s.overrides[locale][key]; ok {
+			templates = append(templates, override)
+			continue
+		}
+		templates = append(templates, domain.MessageTemplate{Locale: locale, Key: key, Template: text})
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Key < templates[j].Key
+	})
+
+	return templates, nil
+}
+
+func (s *messageService) UpdateTemplate(ctx context.Context, userID uint, locale, key string, req domain.UpdateMessageTemplateRequest) (*domain.MessageTemplate, error) {
+	if !isSupportedLocale(locale) {
+		return nil, errors.New("idioma no soportado")
+	}
+	if _, ok := s.defaults[locale][key]; !ok {
+		return nil, errors.New("clave de mensaje desconocida")
+	}
+	if _, err := parseMessageTemplate(key, req.Template); err != nil {
+		return nil, fmt.Errorf("plantilla inválida: %w", err)
+	}
+
+	tmpl := &domain.MessageTemplate{
+		Locale:    locale,
+		Key:       key,
+		Template:  req.Template,
+		UpdatedBy: userID,
+	}
+
+	if err := s.templateRepo.Upsert(ctx, tmpl); err != nil {
+		return nil, err
+	}
+
+	if err := s.LoadOverrides(ctx); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func (s *messageService) ResetTemplate(ctx context.Context, locale, key string) error {
+	if err := s.templateRepo.Delete(ctx, locale, key); err != nil {
+		return err
+	}
+	return s.LoadOverrides(ctx)
+}
+
+// compile prepara las plantillas efectivas: las personalizadas sustituyen a las del catálogo
+func (s *messageService) compile() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	compiled := map[string]map[string]*template.Template{}
+	for locale, catalog := range s.defaults {
+		compiled[locale] = map[string]*template.Template{}
+		for key, text := range catalog {
+			if override, ok := s.overrides[locale][key]; ok {
+				text = override.Template
+			}
+
+			tmpl, err := parseMessageTemplate(key, text)
+			if err != nil {
+				return fmt.Errorf("plantilla %s/%s inválida: %w", locale, key, err)
+			}
+			compiled[locale][key] = tmpl
+		}
+	}
+
+	s.compiled = compiled
+	return nil
+}
+
+func parseMessageTemplate(key, text string) (*template.Template, error) {
+	return template.New(key).Option("missingkey=zero").Parse(text)
+}
+
+func isSupportedLocale(locale string) bool {
+	for _, supported := range domain.SupportedLocales {
+		if supported == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAcceptLanguage devuelve el idioma soportado con mayor peso q, o vacío si no hay ninguno
+func parseAcceptLanguage(header string) string {
+	best := ""
+	bestQ := 0.0
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := strings.ToLower(strings.SplitN(fields[0], "-", 2)[0])
+		if !isSupportedLocale(lang) {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+
+		if q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+
+	return best
+}
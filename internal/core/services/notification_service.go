@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+// Orden de las severidades de menor a mayor
+var severityRank = map[string]int{
+	domain.AlertSeverityInfo:     0,
+	domain.AlertSeverityWarning:  1,
+	domain.AlertSeverityCritical: 2,
+}
+
+var errUnknownChannel = errors.New("canal de notificación desconocido")
+
+type notificationService struct {
+	prefRepo ports.NotificationPreferenceRepository
+}
+
+func NewNotificationService(prefRepo ports.NotificationPreferenceRepository) ports.NotificationService {
+	return &notificationService{
+		prefRepo: prefRepo,
+	}
+}
+
+func (s *notificationService) GetPreferences(ctx context.Context, userID uint) ([]domain.NotificationPreference, error) {
+	return s.prefRepo.FindByUser(ctx, userID)
+}
+
+func (s *notificationService) UpdatePreference(ctx context.Context, userID uint, channel string, req domain.UpdateNotificationPreferenceRequest) (*domain.NotificationPreference, error) {
+	if !isNotificationChannel(channel) {
+		return nil, errUnknownChannel
+	}
+
+	minSeverity := req.MinSeverity
+	if minSeverity == "" {
+		minSeverity = domain.AlertSeverityWarning
+	}
+
+	sensorTypes := req.SensorTypes
+	if sensorTypes == nil {
+		sensorTypes = []string{}
+	}
+
+	pref := &domain.NotificationPreference{
+		UserID:      userID,
+		Channel:     channel,
+		Enabled:     req.Enabled,
+		MinSeverity: minSeverity,
+		SensorTypes: sensorTypes,
+	}
+
+	if err := s.prefRepo.Upsert(ctx, pref); err != nil {
+		return nil, err
+	}
+
+	return pref, nil
+}
+
+// preferenceMatches indica si la alerta cumple la severidad mínima y las métricas elegidas
+func preferenceMatches(pref domain.NotificationPreference, alert domain.Alert) bool {
+	if !pref.Enabled || severityRank[alert.Severity] < severityRank[pref.MinSeverity] {
+		return false
+	}
+	if len(pref.SensorTypes) == 0 {
+		return true
+	}
+	for _, sensorType := range pref.SensorTypes {
+		if sensorType == alert.SensorType {
+			return true
+		}
+	}
+	return false
+}
+
+func isNotificationChannel(channel string) bool {
+	return channel == domain.NotificationChannelEmail || channel == domain.NotificationChannelPush
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+// Revisiones sin confirmar sus conexiones tras las que se descartan las de una instancia
+// que dejó de funcionar sin cerrarlas
+const presenceConnectionTTLChecks = 3
+
+// presenceService calcula la presencia con las conexiones de todas las instancias de la
+// API, que cada una guarda en la base de datos. El cambio de presencia de un dispositivo lo
+// registra y difunde solo la instancia que consigue guardarlo
+type presenceService struct {
+	deviceRepo    ports.DeviceRepository
+	presenceRepo  ports.PresenceRepository
+	publisher     ports.EventPublisher
+	missedAllowed int
+	checkInterval time.Duration
+	instanceID    string
+
+	mu sync.Mutex
+	// Conexiones en tiempo real abiertas por dispositivo en esta instancia
+	connections map[string]int
+}
+
+// NewPresenceService crea el servicio de presencia; un dispositivo sin conexión sigue
+// presente mientras no pasen missedAllowed intervalos esperados sin lecturas
+func NewPresenceService(deviceRepo ports.DeviceRepository, presenceRepo ports.PresenceRepository, publisher ports.EventPublisher, missedAllowed int, checkInterval time.Duration) ports.PresenceService {
+	instanceID, err := randomHex(8)
+	if err != nil {
+		instanceID = strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+
+	return &presenceService{
+		deviceRepo:    deviceRepo,
+		presenceRepo:  presenceRepo,
+		publisher:     publisher,
+		missedAllowed: missedAllowed,
+		checkInterval: checkInterval,
+		instanceID:    instanceID,
+		connections:   make(map[string]int),
+	}
+}
+
+func (s *presenceService) DeviceConnected(ctx context.Context, deviceID, transport string) {
+	s.updateConnections(ctx, deviceID, 1)
+	s.recordEvent(ctx, deviceID, domain.PresenceConnected, transport)
+	s.refresh(ctx)
+}
+
+func (s *presenceService) DeviceDisconnected(ctx context.Context, deviceID, transport string) {
+	s.updateConnections(ctx, deviceID, -1)
+	s.recordEvent(ctx, deviceID, domain.PresenceDisconnected, transport)
+	s.refresh(ctx)
+}
+
+// updateConnections ajusta las conexiones del dispositivo en esta instancia y las guarda.
+// El mutex se mantiene al guardarlas para que no se escriba un recuento anterior
+func (s *presenceService) updateConnections(ctx context.Context, deviceID string, delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	connections := s.connections[deviceID] + delta
+	if connections > 0 {
+		s.connections[deviceID] = connections
+	} else {
+		delete(s.connections, deviceID)
+	}
+
+	if err := s.presenceRepo.SetConnections(ctx, s.instanceID, deviceID, connections, time.Now()); err != nil {
+		log.Printf("Error al guardar las conexiones del dispositivo %s: %v", deviceID, err)
+	}
+}
+
+func (s *presenceService) GetStatus(ctx context.Context, access *domain.DeviceAccess) (*domain.SystemStatus, error) {
+	presences, err := s.computePresence(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []domain.DevicePresence{}
+	for _, presence := range presences {
+		if access.Allows(presence.DeviceID) {
+			visible = append(visible, presence)
+		}
+	}
+
+	status := buildSystemStatus(visible)
+	return &status, nil
+}
+
+func (s *presenceService) GetHistory(ctx context.Context, deviceID string, limit int) ([]domain.PresenceEvent, error) {
+	return s.presenceRepo.FindEvents(ctx, deviceID, limit)
+}
+
+// Run recalcula periódicamente la presencia para detectar los dispositivos que dejan de
+// enviar lecturas, hasta que se cancele el contexto
+func (s *presenceService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	s.refresh(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.keepConnections(context.WithoutCancel(ctx), time.Now())
+			s.refresh(context.WithoutCancel(ctx))
+		}
+	}
+}
+
+// keepConnections confirma las conexiones de esta instancia y descarta las de las
+// instancias que dejaron de confirmarlas
+func (s *presenceService) keepConnections(ctx context.Context, now time.Time) {
+	if err := s.presenceRepo.TouchConnections(ctx, s.instanceID, now); err != nil {
+		log.Printf("Error al confirmar las conexiones de los dispositivos: %v", err)
+	}
+	if err := s.presenceRepo.DeleteStaleConnections(ctx, now.Add(-s.connectionTTL())); err != nil {
+		log.Printf("Error al descartar conexiones caducadas: %v", err)
+	}
+}
+
+func (s *presenceService) connectionTTL() time.Duration {
+	return presenceConnectionTTLChecks * s.checkInterval
+}
+
+// refresh recalcula la presencia, registra y difunde los cambios y, si hubo alguno,
+// el nuevo estado del sistema. Cuando varias instancias detectan el mismo cambio, solo la
+// primera en guardarlo lo difunde
+func (s *presenceService) refresh(ctx context.Context) {
+	presences, err := s.computePresence(ctx, time.Now())
+	if err != nil {
+		log.Printf("Error al calcular la presencia de los dispositivos: %v", err)
+		return
+	}
+
+	changed := []domain.DevicePresence{}
+	for _, presence := range presences {
+		updated, err := s.presenceRepo.SetOnline(ctx, presence.DeviceID, presence.Online)
+		if err != nil {
+			log.Printf("Error al guardar la presencia del dispositivo %s: %v", presence.DeviceID, err)
+			continue
+		}
+		if updated {
+			changed = append(changed, presence)
+		}
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	for _, presence := range changed {
+		event := domain.PresenceOffline
+		if presence.Online {
+			event = domain.PresenceOnline
+		}
+		s.recordEvent(ctx, presence.DeviceID, event, "")
+		s.publisher.PublishPresence(ctx, presence)
+	}
+
+	s.publisher.PublishSystemStatus(ctx, buildSystemStatus(presences))
+}
+
+// computePresence calcula la presencia de todos los dispositivos registrados
+func (s *presenceService) computePresence(ctx context.Context, now time.Time) ([]domain.DevicePresence, error) {
+	devices, err := s.deviceRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	connections, err := s.presenceRepo.CountConnections(ctx, now.Add(-s.connectionTTL()))
+	if err != nil {
+		return nil, err
+	}
+
+	presences := make([]domain.DevicePresence, 0, len(devices))
+	for _, device := range devices {
+		limit := time.Duration(device.ExpectedIntervalSeconds*s.missedAllowed) * time.Second
+		recent := device.LastSeenAt != nil && now.Sub(*device.LastSeenAt) <= limit
+		presences = append(presences, domain.DevicePresence{
+			DeviceID:    device.ID,
+			Name:        device.Name,
+			Online:      connections[device.ID] > 0 || recent,
+			Connections: connections[device.ID],
+			LastSeenAt:  device.LastSeenAt,
+		})
+	}
+
+	return presences, nil
+}
+
+func (s *presenceService) recordEvent(ctx context.Context, deviceID, event, transport string) {
+	err := s.presenceRepo.RecordEvent(ctx, &domain.PresenceEvent{
+		DeviceID:  deviceID,
+		Event:     event,
+		Transport: transport,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("Error al registrar la presencia del dispositivo %s: %v", deviceID, err)
+	}
+}
+
+// buildSystemStatus resume la presencia de los dispositivos en un estado global
+func buildSystemStatus(presences []domain.DevicePresence) domain.SystemStatus {
+	sort.Slice(presences, func(i, j int) bool {
+		return presences[i].DeviceID < presences[j].DeviceID
+	})
+
+	status := domain.SystemStatus{
+		TotalSensors:   len(presences),
+		LastUpdateTime: time.Now(),
+		Devices:        presences,
+	}
+	for _, presence := range presences {
+		if presence.Online {
+			status.SensorsOnline++
+		}
+	}
+
+	switch {
+	case status.TotalSensors == 0:
+		status.Status = domain.SystemStatusEmpty
+	case status.SensorsOnline == status.TotalSensors:
+		status.Status = domain.SystemStatusOK
+	case status.SensorsOnline == 0:
+		status.Status = domain.SystemStatusDown
+	default:
+		status.Status = domain.SystemStatusDegraded
+	}
+
+	return status
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"ApiSmart/pkg/webpush"
+)
+
+// Tiempo que el servicio de push conserva una alerta no entregada
+const pushTTLSeconds = 24 * 60 * 60
+
+// Tiempo máximo de cada envío al servicio de push
+const pushTimeout = 10 * time.Second
+
+// Contenido que recibe el service worker de la PWA
+type pushPayload struct {
+	Title string        `json:"title"`
+	Body  string        `json:"body"`
+	Alert *domain.Alert `json:"alert,omitempty"`
+}
+
+type pushService struct {
+	pushRepo      ports.PushRepository
+	prefRepo      ports.NotificationPreferenceRepository
+	messages      ports.MessageService
+	gardenService ports.GardenService
+	keys          webpush.VAPIDKeys
+	subject       string
+	client        *http.Client
+	sending       *background
+}
+
+// NewPushService usa las claves VAPID recibidas; si están vacías las carga de la base
+// de datos y, la primera vez, genera y guarda un par nuevo
+func NewPushService(ctx context.Context, pushRepo ports.PushRepository, prefRepo ports.NotificationPreferenceRepository, messages ports.MessageService, gardenService ports.GardenService, keys webpush.VAPIDKeys, subject string) (ports.PushService, error) {
+	if keys.PublicKey == "" || keys.PrivateKey == "" {
+		publicKey, privateKey, err := pushRepo.GetVAPIDKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if publicKey == "" {
+			generated, err := webpush.GenerateVAPIDKeys()
+			if err != nil {
+				return nil, err
+			}
+			if err := pushRepo.SaveVAPIDKeys(ctx, generated.PublicKey, generated.PrivateKey); err != nil {
+				return nil, err
+			}
+			publicKey, privateKey = generated.PublicKey, generated.PrivateKey
+		}
+
+		keys = webpush.VAPIDKeys{PublicKey: publicKey, PrivateKey: privateKey}
+	}
+
+	return &pushService{
+		pushRepo:      pushRepo,
+		prefRepo:      prefRepo,
+		messages:      messages,
+		gardenService: gardenService,
+		keys:          keys,
+		subject:       subject,
+		client:        newPublicClient(pushTimeout),
+		sending:       newBackground(),
+	}, nil
+}
+
+func (s *pushService) VAPIDPublicKey() string {
+	return s.keys.PublicKey
+}
+
+func (s *pushService) Subscribe(ctx context.Context, userID uint, req domain.PushSubscribeRequest) (*domain.PushSubscription, error) {
+	if err := validatePushEndpoint(ctx, req.Endpoint); err != nil {
+		return nil, err
+	}
+
+	sub := &domain.PushSubscription{
+		UserID:   userID,
+		Endpoint: req.Endpoint,
+		P256dh:   req.Keys.P256dh,
+		Auth:     req.Keys.Auth,
+	}
+
+	if err := s.pushRepo.SaveSubscription(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// validatePushEndpoint exige https y aplica las mismas comprobaciones de direcciones
+// internas que a los webhooks, porque el servidor envía peticiones al endpoint
+func validatePushEndpoint(ctx context.Context, endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme != "https" {
+		return fmt.Errorf("%w: debe ser una URL https", domain.ErrPushEndpointInvalid)
+	}
+	if err := validateWebhookURL(ctx, endpoint); err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrPushEndpointInvalid, err)
+	}
+	return nil
+}
+
+func (s *pushService) Unsubscribe(ctx context.Context, userID uint, endpoint string) error {
+	return s.pushRepo.DeleteSubscription(ctx, userID, endpoint)
+}
+
+func (s *pushService) Channel() string {
+	return domain.NotificationChannelPush
+}
+
+// NotifyReading no envía notificaciones push: solo las alertas lo hacen
+func (s *pushService) NotifyReading(ctx context.Context, data domain.SensorData) {}
+
+func (s *pushService) NotifyAlert(ctx context.Context, alert domain.Alert) {
+	if !s.sending.start(func() { s.sendAlert(context.Background(), alert) }) {
+		log.Printf("Notificación push de la alerta %d descartada: el servicio se está deteniendo", alert.ID)
+	}
+}
+
+func (s *pushService) Drain(ctx context.Context) error {
+	return s.sending.drain(ctx)
+}
+
+func (s *pushService) sendAlert(ctx context.Context, alert domain.Alert) {
+	prefs, err := s.prefRepo.FindEnabledByChannel(ctx, domain.NotificationChannelPush)
+	if err != nil {
+		log.Printf("Error al obtener preferencias push: %v", err)
+		return
+	}
+
+	opts := webpush.Options{
+		Subject: s.subject,
+		TTL:     pushTTLSeconds,
+		Urgency: "normal",
+	}
+	if alert.Severity == domain.AlertSeverityCritical {
+		opts.Urgency = "high"
+	}
+
+	for _, pref := range prefs {
+		if !preferenceMatches(pref, alert) {
+			continue
+		}
+
+		// Solo se avisa a quien puede ver el dispositivo de la alerta
+		access, err := s.gardenService.DeviceAccess(ctx, pref.UserID)
+		if err != nil {
+			log.Printf("Error al obtener los dispositivos del usuario %d: %v", pref.UserID, err)
+			continue
+		}
+		if !access.Allows(alert.DeviceID) {
+			continue
+		}
+
+		subs, err := s.pushRepo.FindSubscriptionsByUser(ctx, pref.UserID)
+		if err != nil {
+			log.Printf("Error al obtener suscripciones push del usuario %d: %v", pref.UserID, err)
+			continue
+		}
+		if len(subs) == 0 {
+			continue
+		}
+
+		// Cada usuario recibe el mensaje en su idioma
+		localized := []domain.Alert{alert}
+		s.messages.LocalizeAlerts(s.messages.ResolveLocale(ctx, pref.UserID, ""), localized)
+
+		payload, err := json.Marshal(pushPayload{
+			Title: "SmartGarden",
+			Body:  localized[0].Message,
+			Alert: &localized[0],
+		})
+		if err != nil {
+			log.Printf("Error al serializar notificación push: %v", err)
+			return
+		}
+
+		for _, sub := range subs {
+			if err := s.send(ctx, sub, payload, opts); err != nil {
+				log.Printf("Error al enviar notificación push a la suscripción %d: %v", sub.ID, err)
+			}
+		}
+	}
+}
+
+func (s *pushService) DeliverDigest(ctx context.Context, user domain.User, digest domain.Digest) error {
+	subs, err := s.pushRepo.FindSubscriptionsByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return errors.New("el usuario no tiene suscripciones push")
+	}
+
+	// El resumen completo no cabe en un mensaje push; se envía solo el total
+	payload, err := json.Marshal(pushPayload{
+		Title: "Resumen de SmartGarden",
+		Body:  fmt.Sprintf("%d alertas nuevas desde %s", digest.TotalAlerts, digest.PeriodStart.Format("2006-01-02 15:04")),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Basta con que llegue a uno de los navegadores del usuario
+	opts := webpush.Options{Subject: s.subject, TTL: pushTTLSeconds, Urgency: "low"}
+	var lastErr error
+	delivered := false
+	for _, sub := range subs {
+		if err := s.send(ctx, sub, payload, opts); err != nil {
+			log.Printf("Error al enviar resumen push a la suscripción %d: %v", sub.ID, err)
+			lastErr = err
+			continue
+		}
+		delivered = true
+	}
+
+	if delivered {
+		return nil
+	}
+	return lastErr
+}
+
+// send entrega un mensaje y elimina la suscripción si el servicio de push la da por caducada
+func (s *pushService) send(ctx context.Context, sub domain.PushSubscription, payload []byte, opts webpush.Options) error {
+	resp, err := webpush.Send(s.client, webpush.Subscription{
+		Endpoint: sub.Endpoint,
+		P256dh:   sub.P256dh,
+		Auth:     sub.Auth,
+	}, payload, s.keys, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusGone || resp.StatusCode == http.StatusNotFound:
+		if err := s.pushRepo.DeleteSubscriptionByID(ctx, sub.ID); err != nil {
+			log.Printf("Error al eliminar suscripción push caducada %d: %v", sub.ID, err)
+		}
+		return fmt.Errorf("suscripción caducada: el servicio push respondió %s", resp.Status)
+	case resp.StatusCode >= 300:
+		return fmt.Errorf("el servicio push respondió %s", resp.Status)
+	}
+
+	return nil
+}
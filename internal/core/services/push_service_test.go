@@ -0,0 +1,33 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"ApiSmart/internal/core/domain"
+)
+
+func TestValidatePushEndpoint(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		wantErr  bool
+	}{
+		{"https://93.184.216.34/push/abc", false},
+		{"http://93.184.216.34/push/abc", true},
+		{"ftp://93.184.216.34/push/abc", true},
+		{"https://127.0.0.1/push/abc", true},
+		{"https://169.254.169.254/latest/meta-data", true},
+		{"https://[::ffff:10.0.0.1]/push/abc", true},
+	}
+
+	for _, tt := range tests {
+		err := validatePushEndpoint(context.Background(), tt.endpoint)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validatePushEndpoint(%s) = %v, se esperaba error: %v", tt.endpoint, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, domain.ErrPushEndpointInvalid) {
+			t.Errorf("validatePushEndpoint(%s) = %v, se esperaba ErrPushEndpointInvalid", tt.endpoint, err)
+		}
+	}
+}
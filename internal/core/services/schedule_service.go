@@ -0,0 +1,492 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+	"ApiSmart/pkg/sun"
+	"github.com/robfig/cron/v3"
+)
+
+const (
+	// Frecuencia con la que el planificador busca programaciones pendientes
+	scheduleCheckInterval = 15 * time.Second
+	// Retraso a partir del cual una ejecución se da por perdida, p. ej. si la API estuvo detenida
+	scheduleMissedGrace = 10 * time.Minute
+	// Ejecuciones devueltas en el historial de una programación
+	scheduleRunLimit = 100
+	// Ejecuciones calculadas por defecto y como máximo en la vista previa
+	defaultSchedulePreview = 10
+	maxSchedulePreview     = 100
+)
+
+var errNoNextRun = errors.New("la programación no tiene próximas ejecuciones")
+
+type scheduleService struct {
+	scheduleRepo    ports.ScheduleRepository
+	deviceRepo      ports.DeviceRepository
+	sensorRepo      ports.SensorRepository
+	actuatorService ports.ActuatorService
+	gardenService   ports.GardenService
+}
+
+// NewScheduleService crea el servicio de programaciones; los comandos se emiten a través
+// del servicio de actuadores en nombre de quien creó la programación
+func NewScheduleService(scheduleRepo ports.ScheduleRepository, deviceRepo ports.DeviceRepository, sensorRepo ports.SensorRepository, actuatorService ports.ActuatorService, gardenService ports.GardenService) ports.ScheduleService {
+	return &scheduleService{
+		scheduleRepo:    scheduleRepo,
+		deviceRepo:      deviceRepo,
+		sensorRepo:      sensorRepo,
+		actuatorService: actuatorService,
+		gardenService:   gardenService,
+	}
+}
+
+func (s *scheduleService) CreateSchedule(ctx context.Context, userID uint, req domain.ScheduleRequest) (*domain.Schedule, error) {
+	garden, err := s.validate(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	schedule := &domain.Schedule{
+		Enabled:   true,
+		CreatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	applyScheduleRequest(schedule, req)
+
+	if err := s.plan(schedule, garden, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.Create(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *scheduleService) GetSchedules(ctx context.Context, userID uint) ([]domain.Schedule, error) {
+	actuators, err := s.actuatorService.GetActuators(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	visibleActuators := make(map[uint]bool, len(actuators))
+	for _, actuator := range actuators {
+		visibleActuators[actuator.ID] = true
+	}
+
+	schedules, err := s.scheduleRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []domain.Schedule{}
+	for _, schedule := range schedules {
+		if visibleActuators[schedule.ActuatorID] {
+			visible = append(visible, schedule)
+		}
+	}
+
+	return visible, nil
+}
+
+func (s *scheduleService) GetSchedule(ctx context.Context, userID, id uint) (*domain.Schedule, error) {
+	schedule, err := s.scheduleRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.actuatorService.GetActuator(ctx, userID, schedule.ActuatorID); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *scheduleService) UpdateSchedule(ctx context.Context, userID, id uint, req domain.ScheduleRequest) (*domain.Schedule, error) {
+	schedule, err := s.GetSchedule(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	garden, err := s.validate(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	applyScheduleRequest(schedule, req)
+	schedule.UpdatedAt = now
+
+	if err := s.plan(schedule, garden, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *scheduleService) SetEnabled(ctx context.Context, userID, id uint, enabled bool) (*domain.Schedule, error) {
+	schedule, err := s.GetSchedule(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	garden, err := s.garden(ctx, userID, schedule.ActuatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Al reactivarla no se recuperan las ejecuciones del tiempo en que estuvo desactivada
+	now := time.Now()
+	schedule.Enabled = enabled
+	schedule.UpdatedAt = now
+
+	if err := s.plan(schedule, garden, now); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.Update(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (s *scheduleService) DeleteSchedule(ctx context.Context, userID, id uint) error {
+	if _, err := s.GetSchedule(ctx, userID, id); err != nil {
+		return err
+	}
+
+	return s.scheduleRepo.Delete(ctx, id)
+}
+
+func (s *scheduleService) GetRuns(ctx context.Context, userID, id uint) ([]domain.ScheduleRun, error) {
+	if _, err := s.GetSchedule(ctx, userID, id); err != nil {
+		return nil, err
+	}
+
+	return s.scheduleRepo.FindRuns(ctx, id, scheduleRunLimit)
+}
+
+func (s *scheduleService) Preview(ctx context.Context, userID, id uint, count int) (*domain.SchedulePreview, error) {
+	schedule, err := s.GetSchedule(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	garden, err := s.garden(ctx, userID, schedule.ActuatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if count <= 0 {
+		count = defaultSchedulePreview
+	}
+	if count > maxSchedulePreview {
+		count = maxSchedulePreview
+	}
+
+	preview := &domain.SchedulePreview{
+		ScheduleID: schedule.ID,
+		Timezone:   garden.Location().String(),
+		Runs:       []time.Time{},
+	}
+
+	after := time.Now()
+	for len(preview.Runs) < count {
+		next, err := nextScheduleRun(*schedule, garden, after)
+		if errors.Is(err, errNoNextRun) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		preview.Runs = append(preview.Runs, next)
+		after = next
+	}
+
+	return preview, nil
+}
+
+// Run ejecuta el planificador de programaciones hasta que se cancele el contexto
+func (s *scheduleService) Run(ctx context.Context) {
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			// Las ejecuciones en curso terminan aunque se detenga la API
+			s.runDue(context.WithoutCancel(ctx), now)
+		}
+	}
+}
+
+func (s *scheduleService) runDue(ctx context.Context, now time.Time) {
+	schedules, err := s.scheduleRepo.FindDue(ctx, now)
+	if err != nil {
+		log.Printf("Error al buscar programaciones pendientes: %v", err)
+		return
+	}
+
+	for _, schedule := range schedules {
+		if err := s.runSchedule(ctx, schedule, now); err != nil {
+			log.Printf("Error al ejecutar la programación %d: %v", schedule.ID, err)
+		}
+	}
+}
+
+// runSchedule realiza la ejecución pendiente de la programación y calcula la siguiente
+func (s *scheduleService) runSchedule(ctx context.Context, schedule domain.Schedule, now time.Time) error {
+	scheduledFor := *schedule.NextRunAt
+
+	// La siguiente se calcula desde ahora para no encadenar las ejecuciones perdidas; si no
+	// se puede calcular, la programación queda detenida hasta que se modifique
+	var nextRunAt *time.Time
+	garden, err := s.garden(ctx, schedule.CreatedBy, schedule.ActuatorID)
+	if err == nil {
+		var next time.Time
+		next, err = nextScheduleRun(schedule, garden, now)
+		if err == nil {
+			nextRunAt = &next
+		}
+	}
+	if err != nil {
+		log.Printf("La programación %d se detiene: %v", schedule.ID, err)
+	}
+
+	// Otra instancia pudo realizar ya esta ejecución
+	claimed, err := s.scheduleRepo.Advance(ctx, schedule.ID, scheduledFor, nextRunAt, now)
+	if err != nil || !claimed {
+		return err
+	}
+
+	run := &domain.ScheduleRun{
+		ScheduleID:   schedule.ID,
+		Status:       domain.ScheduleRunExecuted,
+		ScheduledFor: scheduledFor,
+		CreatedAt:    now,
+	}
+
+	if now.Sub(scheduledFor) > scheduleMissedGrace {
+		run.Status = domain.ScheduleRunSkipped
+		run.Reason = "ejecución perdida mientras la API estaba detenida"
+	} else if reason := s.skipReason(ctx, schedule.SkipIf); reason != "" {
+		run.Status = domain.ScheduleRunSkipped
+		run.Reason = reason
+	} else {
+		command, err := s.actuatorService.SendCommand(ctx, schedule.CreatedBy, schedule.ActuatorID, schedule.CommandRequest())
+		if err != nil {
+			run.Status = domain.ScheduleRunFailed
+			run.Reason = historyError(err)
+		} else {
+			run.CommandID = &command.ID
+		}
+	}
+
+	return s.scheduleRepo.RecordRun(ctx, run)
+}
+
+// skipReason devuelve por qué se omite la ejecución, o "" si debe realizarse
+func (s *scheduleService) skipReason(ctx context.Context, condition *domain.ScheduleSkipCondition) string {
+	if condition == nil {
+		return ""
+	}
+
+	reading, err := s.sensorRepo.GetLatestMetricReading(ctx, condition.DeviceID, condition.SensorType)
+	if err != nil {
+		// Sin lecturas no se puede comprobar la condición y la ejecución sigue adelante
+		log.Printf("No se pudo comprobar la condición de omisión (%s de %s): %v", condition.SensorType, condition.DeviceID, err)
+		return ""
+	}
+
+	if !condition.Matches(reading.Value) {
+		return ""
+	}
+
+	return fmt.Sprintf("la última lectura de %s de %s es %g", condition.SensorType, condition.DeviceID, reading.Value)
+}
+
+// plan calcula la siguiente ejecución de la programación, o ninguna si está desactivada
+func (s *scheduleService) plan(schedule *domain.Schedule, garden *domain.Garden, now time.Time) error {
+	schedule.NextRunAt = nil
+	if !schedule.Enabled {
+		return nil
+	}
+
+	next, err := nextScheduleRun(*schedule, garden, now)
+	if err != nil {
+		return err
+	}
+
+	schedule.NextRunAt = &next
+	return nil
+}
+
+// validate comprueba la programación y devuelve el jardín con el que se calculan sus horas
+func (s *scheduleService) validate(ctx context.Context, userID uint, req domain.ScheduleRequest) (*domain.Garden, error) {
+	err := validateCommandRequest(domain.ActuatorCommandRequest{
+		Action:          req.Action,
+		DutyCycle:       req.DutyCycle,
+		DurationSeconds: req.DurationSeconds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	garden, err := s.garden(ctx, userID, req.ActuatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Kind {
+	case domain.ScheduleKindCron:
+		if _, err := parseCron(req.Cron); err != nil {
+			return nil, err
+		}
+	case domain.ScheduleKindSun:
+		if req.SunEvent == "" {
+			return nil, errors.New("las programaciones solares requieren sun_event")
+		}
+		if garden.Latitude == nil || garden.Longitude == nil {
+			return nil, errors.New("el jardín del actuador no tiene ubicación")
+		}
+	}
+
+	if req.SkipIf != nil {
+		access, err := s.gardenService.DeviceAccess(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !access.Allows(req.SkipIf.DeviceID) {
+			return nil, domain.ErrGardenAccessDenied
+		}
+	}
+
+	return garden, nil
+}
+
+// garden devuelve el jardín del dispositivo del actuador, comprobando que el usuario
+// puede verlo. Los dispositivos sin jardín usan UTC y no tienen ubicación
+func (s *scheduleService) garden(ctx context.Context, userID, actuatorID uint) (*domain.Garden, error) {
+	actuator, err := s.actuatorService.GetActuator(ctx, userID, actuatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := s.deviceRepo.FindByID(ctx, actuator.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.GardenID == nil {
+		return &domain.Garden{Timezone: "UTC"}, nil
+	}
+
+	return s.gardenService.GetGarden(ctx, userID, *device.GardenID)
+}
+
+// parseCron interpreta una expresión cron de cinco campos; la zona horaria es la del jardín
+func parseCron(expr string) (cron.Schedule, error) {
+	if strings.Contains(expr, "TZ=") {
+		return nil, errors.New("la zona horaria de la programación es la del jardín")
+	}
+
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("expresión cron inválida: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// nextScheduleRun calcula la siguiente ejecución posterior a after en la zona horaria del jardín
+func nextScheduleRun(schedule domain.Schedule, garden *domain.Garden, after time.Time) (time.Time, error) {
+	local := after.In(garden.Location())
+
+	switch schedule.Kind {
+	case domain.ScheduleKindCron:
+		expr, err := parseCron(schedule.Cron)
+		if err != nil {
+			return time.Time{}, err
+		}
+		next := expr.Next(local)
+		if next.IsZero() {
+			return time.Time{}, errNoNextRun
+		}
+		return next, nil
+
+	case domain.ScheduleKindSun:
+		if garden.Latitude == nil || garden.Longitude == nil {
+			return time.Time{}, errors.New("el jardín del actuador no tiene ubicación")
+		}
+
+		offset := time.Duration(schedule.OffsetMinutes) * time.Minute
+		// Se empieza por el día anterior por si el desfase cruza la medianoche. En latitudes
+		// polares hay días sin salida o puesta del sol, que se saltan
+		for day := -1; day <= 366; day++ {
+			date := time.Date(local.Year(), local.Month(), local.Day()+day, 12, 0, 0, 0, local.Location())
+			sunrise, sunset, ok := sun.Times(date, *garden.Latitude, *garden.Longitude)
+			if !ok {
+				continue
+			}
+
+			at := sunrise
+			if schedule.SunEvent == domain.SunEventSunset {
+				at = sunset
+			}
+			if at = at.Add(offset); at.After(after) {
+				return at, nil
+			}
+		}
+		return time.Time{}, errNoNextRun
+
+	default:
+		return time.Time{}, fmt.Errorf("tipo de programación desconocido: %s", schedule.Kind)
+	}
+}
+
+// applyScheduleRequest copia la definición de la petición en la programación
+func applyScheduleRequest(schedule *domain.Schedule, req domain.ScheduleRequest) {
+	schedule.Name = req.Name
+	if req.Enabled != nil {
+		schedule.Enabled = *req.Enabled
+	}
+	schedule.Kind = req.Kind
+	schedule.Cron = ""
+	schedule.SunEvent = ""
+	schedule.OffsetMinutes = 0
+	switch req.Kind {
+	case domain.ScheduleKindCron:
+		schedule.Cron = strings.TrimSpace(req.Cron)
+	case domain.ScheduleKindSun:
+		schedule.SunEvent = req.SunEvent
+		schedule.OffsetMinutes = req.OffsetMinutes
+	}
+	schedule.ActuatorID = req.ActuatorID
+	schedule.Action = req.Action
+	schedule.DutyCycle = nil
+	schedule.DurationSeconds = nil
+	switch req.Action {
+	case domain.CommandActionDutyCycle:
+		schedule.DutyCycle = req.DutyCycle
+	case domain.CommandActionRun:
+		schedule.DurationSeconds = req.DurationSeconds
+	}
+	schedule.SkipIf = req.SkipIf
+}
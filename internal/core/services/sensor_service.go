@@ -2,39 +2,127 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"ApiSmart/internal/core/domain"
 	"ApiSmart/internal/core/ports"
 )
 
+// Número de dispositivos incluidos en el ranking de estadísticas
+const topNoisyDevices = 5
+
 type sensorService struct {
-	sensorRepo   ports.SensorRepository
-	alertService ports.AlertService
+	sensorRepo        ports.SensorRepository
+	deviceRepo        ports.DeviceRepository
+	alertService      ports.AlertService
+	silenceService    ports.SilenceService
+	automationService ports.AutomationService
+	gardenService     ports.GardenService
+	publisher         ports.EventPublisher
+	notifiers         []ports.Notifier
 }
 
-func NewSensorService(sensorRepo ports.SensorRepository, alertService ports.AlertService) ports.SensorService {
+func NewSensorService(sensorRepo ports.SensorRepository, deviceRepo ports.DeviceRepository, alertService ports.AlertService, silenceService ports.SilenceService, automationService ports.AutomationService, gardenService ports.GardenService, publisher ports.EventPublisher, notifiers ...ports.Notifier) ports.SensorService {
 	return &sensorService{
-		sensorRepo:   sensorRepo,
-		alertService: alertService,
+		sensorRepo:        sensorRepo,
+		deviceRepo:        deviceRepo,
+		alertService:      alertService,
+		silenceService:    silenceService,
+		automationService: automationService,
+		gardenService:     gardenService,
+		publisher:         publisher,
+		notifiers:         notifiers,
 	}
 }
 
 func (s *sensorService) SaveSensorData(ctx context.Context, data *domain.SensorData) error {
+	if data.DeviceID == "" {
+		data.DeviceID = domain.DefaultDeviceID
+	}
+
 	// Guardar los datos del sensor
 	if err := s.sensorRepo.SaveSensorData(ctx, data); err != nil {
 		return err
 	}
 
+	if err := s.touchDevice(ctx, data.DeviceID, data.ID, data.CreatedAt); err != nil {
+		return err
+	}
+
+	s.publisher.PublishReading(ctx, *data)
+	for _, notifier := range s.notifiers {
+		notifier.NotifyReading(ctx, *data)
+	}
+
 	// Verificar si se deben generar alertas
-	alerts := s.alertService.CheckAndCreateAlerts(data)
+	if err := s.RecordAlerts(ctx, s.alertService.CheckAndCreateAlerts(data)); err != nil {
+		return err
+	}
 
-	// Guardar las alertas generadas
-	for _, alert := range alerts {
-		if err := s.sensorRepo.SaveAlert(ctx, &alert); err != nil {
+	// Actuar según las automatizaciones del dispositivo
+	s.automationService.EvaluateReading(ctx, *data)
+	return nil
+}
+
+func (s *sensorService) SaveMetricReading(ctx context.Context, reading *domain.MetricReading) error {
+	if reading.DeviceID == "" {
+		reading.DeviceID = domain.DefaultDeviceID
+	}
+	if reading.CreatedAt.IsZero() {
+		reading.CreatedAt = time.Now()
+	}
+
+	if err := s.sensorRepo.SaveMetricReading(ctx, reading); err != nil {
+		return err
+	}
+
+	if err := s.touchDevice(ctx, reading.DeviceID, 0, reading.CreatedAt); err != nil {
+		return err
+	}
+
+	if err := s.RecordAlerts(ctx, s.alertService.CheckMetric(*reading)); err != nil {
+		return err
+	}
+
+	s.automationService.EvaluateMetric(ctx, *reading)
+	return nil
+}
+
+// touchDevice registra el contacto del dispositivo y cierra la alerta de silencio si la había
+func (s *sensorService) touchDevice(ctx context.Context, deviceID string, readingID uint, at time.Time) error {
+	previous, err := s.deviceRepo.Touch(ctx, deviceID, readingID, at, domain.DefaultDeviceIntervalSeconds)
+	if err != nil {
+		return err
+	}
+	if previous == domain.DeviceStatusSilent {
+		return s.sensorRepo.ResolveActiveAlerts(ctx, deviceID, domain.DeviceSilentRule)
+	}
+	return nil
+}
+
+// RecordAlerts guarda las alertas, las difunde y avisa a los canales de notificación. Las que
+// coinciden con un silencio se registran, pero no se difunden ni se notifican
+func (s *sensorService) RecordAlerts(ctx context.Context, alerts []domain.Alert) error {
+	if err := s.silenceService.MarkSilenced(ctx, alerts, time.Now()); err != nil {
+		return err
+	}
+
+	for i := range alerts {
+		if err := s.sensorRepo.SaveAlert(ctx, &alerts[i]); err != nil {
 			return err
 		}
 	}
 
+	for _, alert := range alerts {
+		if alert.Silenced {
+			continue
+		}
+		s.publisher.PublishAlert(ctx, alert)
+		for _, notifier := range s.notifiers {
+			notifier.NotifyAlert(ctx, alert)
+		}
+	}
+
 	return nil
 }
 
@@ -46,10 +134,63 @@ func (s *sensorService) GetLatestSensorData(ctx context.Context) (*domain.Sensor
 	return s.sensorRepo.GetLatestSensorData(ctx)
 }
 
-func (s *sensorService) GetAlerts(ctx context.Context, isRead *bool) ([]domain.Alert, error) {
-	return s.sensorRepo.GetAlerts(ctx, isRead)
+func (s *sensorService) GetLatestSensorDataByDevice(ctx context.Context) ([]domain.SensorData, error) {
+	return s.sensorRepo.GetLatestSensorDataByDevice(ctx)
+}
+
+func (s *sensorService) GetAlerts(ctx context.Context, userID uint, filter domain.AlertFilter) (*domain.AlertPage, error) {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.Access = access
+	return s.sensorRepo.GetAlerts(ctx, filter)
 }
 
-func (s *sensorService) MarkAlertAsRead(ctx context.Context, alertID uint) error {
+func (s *sensorService) GetAlertStats(ctx context.Context, userID uint, from, to *time.Time) (*domain.AlertStats, error) {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sensorRepo.GetAlertStats(ctx, domain.AlertFilter{From: from, To: to, Access: access}, topNoisyDevices)
+}
+
+func (s *sensorService) MarkAlertAsRead(ctx context.Context, userID, alertID uint) error {
+	if err := s.checkAlertAccess(ctx, userID, alertID); err != nil {
+		return err
+	}
 	return s.sensorRepo.MarkAlertAsRead(ctx, alertID)
 }
+
+func (s *sensorService) AcknowledgeAlert(ctx context.Context, userID, alertID uint) error {
+	if err := s.checkAlertAccess(ctx, userID, alertID); err != nil {
+		return err
+	}
+	return s.sensorRepo.UpdateAlertState(ctx, alertID, domain.AlertStateAcknowledged)
+}
+
+func (s *sensorService) ResolveAlert(ctx context.Context, userID, alertID uint) error {
+	if err := s.checkAlertAccess(ctx, userID, alertID); err != nil {
+		return err
+	}
+	return s.sensorRepo.UpdateAlertState(ctx, alertID, domain.AlertStateResolved)
+}
+
+// checkAlertAccess comprueba que el usuario puede ver el dispositivo de la alerta
+func (s *sensorService) checkAlertAccess(ctx context.Context, userID, alertID uint) error {
+	alert, err := s.sensorRepo.FindAlertByID(ctx, alertID)
+	if err != nil {
+		return err
+	}
+
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !access.Allows(alert.DeviceID) {
+		return domain.ErrGardenAccessDenied
+	}
+	return nil
+}
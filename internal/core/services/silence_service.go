@@ -0,0 +1,279 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+type silenceService struct {
+	silenceRepo   ports.SilenceRepository
+	gardenService ports.GardenService
+}
+
+// NewSilenceService crea el servicio de silencios. Cada usuario gestiona los silencios de los
+// dispositivos que ve; los que aplican a todos los dispositivos solo los gestionan los
+// administradores
+func NewSilenceService(silenceRepo ports.SilenceRepository, gardenService ports.GardenService) ports.SilenceService {
+	return &silenceService{
+		silenceRepo:   silenceRepo,
+		gardenService: gardenService,
+	}
+}
+
+func (s *silenceService) CreateSilence(ctx context.Context, userID uint, req domain.CreateSilenceRequest) (*domain.Silence, error) {
+	if err := s.checkManage(ctx, userID, req.DeviceID); err != nil {
+		return nil, err
+	}
+
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if !req.EndsAt.After(startsAt) {
+		return nil, errors.New("la fecha de fin debe ser posterior a la de inicio")
+	}
+
+	silence := &domain.Silence{
+		DeviceID:   req.DeviceID,
+		SensorType: req.SensorType,
+		Rule:       req.Rule,
+		StartsAt:   startsAt,
+		EndsAt:     req.EndsAt,
+		Comment:    req.Comment,
+		CreatedBy:  userID,
+	}
+
+	if err := s.silenceRepo.CreateSilence(ctx, silence); err != nil {
+		return nil, err
+	}
+
+	return silence, nil
+}
+
+func (s *silenceService) GetSilences(ctx context.Context, userID uint, activeOnly bool) ([]domain.Silence, error) {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var activeAt *time.Time
+	if activeOnly {
+		now := time.Now()
+		activeAt = &now
+	}
+
+	silences, err := s.silenceRepo.FindSilences(ctx, activeAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Los silencios de todos los dispositivos también afectan a los del usuario
+	visible := []domain.Silence{}
+	for _, silence := range silences {
+		if silence.DeviceID == "" || access.Allows(silence.DeviceID) {
+			visible = append(visible, silence)
+		}
+	}
+
+	return visible, nil
+}
+
+func (s *silenceService) ExpireSilence(ctx context.Context, userID, id uint) error {
+	silence, err := s.silenceRepo.FindSilenceByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkManage(ctx, userID, silence.DeviceID); err != nil {
+		return err
+	}
+
+	return s.silenceRepo.ExpireSilence(ctx, id, time.Now())
+}
+
+func (s *silenceService) CreateMaintenanceWindow(ctx context.Context, userID uint, req domain.CreateMaintenanceWindowRequest) (*domain.MaintenanceWindow, error) {
+	if err := s.checkManage(ctx, userID, req.DeviceID); err != nil {
+		return nil, err
+	}
+
+	if _, err := time.Parse("15:04", req.StartTime); err != nil {
+		return nil, errors.New("hora de inicio inválida, se espera HH:MM")
+	}
+
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, errors.New("zona horaria inválida")
+	}
+
+	days := req.DaysOfWeek
+	if days == nil {
+		days = []int{}
+	}
+
+	window := &domain.MaintenanceWindow{
+		Name:            req.Name,
+		DeviceID:        req.DeviceID,
+		SensorType:      req.SensorType,
+		Rule:            req.Rule,
+		DaysOfWeek:      days,
+		StartTime:       req.StartTime,
+		DurationMinutes: req.DurationMinutes,
+		Timezone:        timezone,
+		CreatedBy:       userID,
+	}
+
+	if err := s.silenceRepo.CreateMaintenanceWindow(ctx, window); err != nil {
+		return nil, err
+	}
+
+	return window, nil
+}
+
+func (s *silenceService) GetMaintenanceWindows(ctx context.Context, userID uint) ([]domain.MaintenanceWindow, error) {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	windows, err := s.silenceRepo.FindMaintenanceWindows(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := []domain.MaintenanceWindow{}
+	for _, window := range windows {
+		if window.DeviceID == "" || access.Allows(window.DeviceID) {
+			visible = append(visible, window)
+		}
+	}
+
+	return visible, nil
+}
+
+func (s *silenceService) DeleteMaintenanceWindow(ctx context.Context, userID, id uint) error {
+	window, err := s.silenceRepo.FindMaintenanceWindowByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.checkManage(ctx, userID, window.DeviceID); err != nil {
+		return err
+	}
+
+	return s.silenceRepo.DeleteMaintenanceWindow(ctx, id)
+}
+
+func (s *silenceService) MarkSilenced(ctx context.Context, alerts []domain.Alert, at time.Time) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	silences, err := s.silenceRepo.FindSilences(ctx, &at)
+	if err != nil {
+		return err
+	}
+
+	windows, err := s.silenceRepo.FindMaintenanceWindows(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Solo las ventanas activas en este instante
+	active := windows[:0]
+	for _, window := range windows {
+		if maintenanceWindowActive(window, at) {
+			active = append(active, window)
+		}
+	}
+
+	for i := range alerts {
+		alerts[i].Silenced = isSilenced(alerts[i], silences, active)
+	}
+
+	return nil
+}
+
+// checkManage comprueba que el usuario puede silenciar el dispositivo; los silencios de
+// todos los dispositivos son solo para administradores
+func (s *silenceService) checkManage(ctx context.Context, userID uint, deviceID string) error {
+	access, err := s.gardenService.DeviceAccess(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if access.All || deviceID != "" && access.Allows(deviceID) {
+		return nil
+	}
+	return domain.ErrGardenAccessDenied
+}
+
+// isSilenced indica si la alerta coincide con alguno de los silencios o ventanas activas
+func isSilenced(alert domain.Alert, silences []domain.Silence, windows []domain.MaintenanceWindow) bool {
+	for _, silence := range silences {
+		if matchesAlert(silence.DeviceID, silence.SensorType, silence.Rule, alert) {
+			return true
+		}
+	}
+	for _, window := range windows {
+		if matchesAlert(window.DeviceID, window.SensorType, window.Rule, alert) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAlert compara los criterios de un silencio con la alerta; un criterio vacío coincide siempre
+func matchesAlert(deviceID, sensorType, rule string, alert domain.Alert) bool {
+	return (deviceID == "" || deviceID == alert.DeviceID) &&
+		(sensorType == "" || sensorType == alert.SensorType) &&
+		(rule == "" || rule == alert.Rule)
+}
+
+// maintenanceWindowActive comprueba si el instante cae dentro de alguna repetición de la ventana,
+// incluidas las que empezaron días antes y todavía no han terminado
+func maintenanceWindowActive(window domain.MaintenanceWindow, at time.Time) bool {
+	loc, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := time.Parse("15:04", window.StartTime)
+	if err != nil {
+		return false
+	}
+
+	local := at.In(loc)
+	duration := time.Duration(window.DurationMinutes) * time.Minute
+	lookback := window.DurationMinutes/(24*60) + 1
+
+	for offset := 0; offset <= lookback; offset++ {
+		day := local.AddDate(0, 0, -offset)
+		if !windowRunsOn(window.DaysOfWeek, day.Weekday()) {
+			continue
+		}
+
+		windowStart := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if !local.Before(windowStart) && local.Before(windowStart.Add(duration)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func windowRunsOn(days []int, weekday time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, day := range days {
+		if time.Weekday(day) == weekday {
+			return true
+		}
+	}
+	return false
+}
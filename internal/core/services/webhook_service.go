@@ -0,0 +1,380 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+// Parámetros de reintento de las entregas de webhooks
+const (
+	webhookMaxAttempts    = 5
+	webhookInitialBackoff = 2 * time.Second
+	webhookTimeout        = 10 * time.Second
+	webhookDeliveryLimit  = 100
+)
+
+// Cabeceras enviadas con cada entrega
+const (
+	WebhookSignatureHeader = "X-SmartGarden-Signature"
+	WebhookEventHeader     = "X-SmartGarden-Event"
+)
+
+type webhookService struct {
+	webhookRepo   ports.WebhookRepository
+	gardenService ports.GardenService
+	client        *http.Client
+	backoff       time.Duration
+	delivering    *background
+}
+
+// NewWebhookService crea el servicio de webhooks; cada webhook solo recibe los eventos de
+// los dispositivos que puede ver su propietario
+func NewWebhookService(webhookRepo ports.WebhookRepository, gardenService ports.GardenService) ports.WebhookService {
+	return &webhookService{
+		webhookRepo:   webhookRepo,
+		gardenService: gardenService,
+		client:        newPublicClient(webhookTimeout),
+		backoff:       webhookInitialBackoff,
+		delivering:    newBackground(),
+	}
+}
+
+func (s *webhookService) CreateWebhook(ctx context.Context, userID uint, req domain.CreateWebhookRequest) (*domain.Webhook, error) {
+	for _, eventType := range req.EventTypes {
+		if !isWebhookEventType(eventType) {
+			return nil, fmt.Errorf("tipo de evento desconocido: %s", eventType)
+		}
+	}
+
+	if err := validateWebhookURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+
+	// Generar un secreto si el cliente no proporciona uno
+	secret := req.Secret
+	if secret == "" {
+		generated, err := randomHex(32)
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	webhook := &domain.Webhook{
+		UserID:     userID,
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+		Active:     true,
+	}
+
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) GetWebhooks(ctx context.Context, userID uint) ([]domain.Webhook, error) {
+	return s.webhookRepo.FindByUser(ctx, userID)
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, userID, id uint) error {
+	return s.webhookRepo.Delete(ctx, userID, id)
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, userID, webhookID uint) ([]domain.WebhookDelivery, error) {
+	if _, err := s.webhookRepo.FindByID(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.FindDeliveries(ctx, webhookID, webhookDeliveryLimit)
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, userID, deliveryID uint) error {
+	delivery, err := s.webhookRepo.FindDeliveryByID(ctx, userID, deliveryID)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := s.webhookRepo.FindByID(ctx, userID, delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	if !s.delivering.start(func() { s.deliver(*webhook, delivery.EventType, []byte(delivery.Payload)) }) {
+		return errors.New("el servicio de webhooks se está deteniendo")
+	}
+	return nil
+}
+
+func (s *webhookService) NotifyReading(ctx context.Context, data domain.SensorData) {
+	s.dispatch(ctx, domain.EventReadingCreated, data.DeviceID, data)
+}
+
+func (s *webhookService) NotifyAlert(ctx context.Context, alert domain.Alert) {
+	s.dispatch(ctx, domain.EventAlertCreated, alert.DeviceID, alert)
+}
+
+func (s *webhookService) Drain(ctx context.Context) error {
+	return s.delivering.drain(ctx)
+}
+
+// dispatch envía el evento del dispositivo a los webhooks suscritos cuyo propietario puede
+// verlo, sin bloquear la ingesta
+func (s *webhookService) dispatch(ctx context.Context, eventType, deviceID string, data interface{}) {
+	webhooks, err := s.webhookRepo.FindActiveByEventType(ctx, eventType)
+	if err != nil {
+		log.Printf("Error al buscar webhooks para %s: %v", eventType, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	eventID, err := randomHex(16)
+	if err != nil {
+		log.Printf("Error al generar ID de evento: %v", err)
+		return
+	}
+
+	payload, err := json.Marshal(domain.WebhookEvent{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("Error al serializar evento %s: %v", eventType, err)
+		return
+	}
+
+	access := make(map[uint]*domain.DeviceAccess)
+	for _, webhook := range webhooks {
+		if !s.ownerAllows(ctx, access, webhook.UserID, deviceID) {
+			continue
+		}
+		if !s.delivering.start(func() { s.deliver(webhook, eventType, payload) }) {
+			log.Printf("Entrega de %s al webhook %d descartada: el servicio se está deteniendo", eventType, webhook.ID)
+		}
+	}
+}
+
+// ownerAllows indica si el propietario del webhook puede ver el dispositivo; los permisos de
+// cada usuario se consultan una vez por evento
+func (s *webhookService) ownerAllows(ctx context.Context, access map[uint]*domain.DeviceAccess, userID uint, deviceID string) bool {
+	userAccess, ok := access[userID]
+	if !ok {
+		var err error
+		userAccess, err = s.gardenService.DeviceAccess(ctx, userID)
+		if err != nil {
+			log.Printf("Error al obtener los dispositivos del propietario de webhooks %d: %v", userID, err)
+		}
+		access[userID] = userAccess
+	}
+	return userAccess.Allows(deviceID)
+}
+
+// deliver realiza la entrega con reintentos y backoff exponencial, registrando cada intento
+func (s *webhookService) deliver(webhook domain.Webhook, eventType string, payload []byte) {
+	backoff := s.backoff
+
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		delivery := s.attempt(webhook, eventType, payload)
+		delivery.Attempt = attempt
+
+		if err := s.webhookRepo.SaveDelivery(context.Background(), &delivery); err != nil {
+			log.Printf("Error al registrar entrega del webhook %d: %v", webhook.ID, err)
+		}
+
+		if delivery.Success {
+			return
+		}
+
+		if attempt < webhookMaxAttempts {
+			// Al detener la API no se espera a los reintentos; la entrega queda registrada
+			// y se puede reenviar después
+			select {
+			case <-time.After(backoff):
+			case <-s.delivering.stopped():
+				log.Printf("Webhook %d: reintentos de %s interrumpidos al detener el servicio", webhook.ID, eventType)
+				return
+			}
+			backoff *= 2
+		}
+	}
+
+	log.Printf("Webhook %d: entrega de %s fallida tras %d intentos", webhook.ID, eventType, webhookMaxAttempts)
+}
+
+// attempt realiza un único intento de entrega
+func (s *webhookService) attempt(webhook domain.Webhook, eventType string, payload []byte) domain.WebhookDelivery {
+	delivery := domain.WebhookDelivery{
+		WebhookID: webhook.ID,
+		EventType: eventType,
+		Payload:   string(payload),
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, eventType)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(webhook.Secret, payload))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	delivery.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	defer resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = "respuesta no exitosa: " + resp.Status
+	}
+
+	return delivery
+}
+
+// SignWebhookPayload calcula la firma HMAC-SHA256 en hexadecimal del cuerpo enviado
+func SignWebhookPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhookURL comprueba que la URL es http o https y no apunta a direcciones
+// internas, para que los webhooks y las suscripciones push no sirvan para alcanzar
+// servicios de la red privada
+func validateWebhookURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return errors.New("la URL debe ser http o https")
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, parsed.Hostname())
+	if err != nil {
+		return fmt.Errorf("no se pudo resolver el host de la URL: %w", err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return errors.New("la URL apunta a una dirección interna")
+		}
+	}
+
+	return nil
+}
+
+// newPublicClient crea el cliente de las entregas a URLs de usuarios (webhooks y push). La
+// dirección se vuelve a comprobar al conectar, porque el nombre puede resolverse a otra
+// distinta tras registrar la URL, y en cada redirección
+func newPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("dirección no permitida: %s", host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// Con un proxy la comprobación se haría sobre la dirección del proxy
+	transport.Proxy = nil
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// Rangos que no son direcciones públicas de Internet (registro de propósito especial de
+// la IANA). Incluye los formatos IPv6 que contienen una dirección IPv4, como NAT64, 6to4 y
+// Teredo, porque pueden llevar a direcciones internas
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("192.88.99.0/24"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("224.0.0.0/4"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("::/96"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001::/23"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("ff00::/8"),
+}
+
+// isPublicIP indica si la dirección es pública. Las IPv4 mapeadas en IPv6 se comprueban
+// como IPv4
+func isPublicIP(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, known := range domain.WebhookEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// randomHex genera una cadena aleatoria de n bytes codificada en hexadecimal
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ApiSmart/internal/core/domain"
+	"ApiSmart/internal/core/ports"
+)
+
+// deliveryRecorder guarda los intentos de entrega; el resto de métodos no se usan
+type deliveryRecorder struct {
+	ports.WebhookRepository
+	mu         sync.Mutex
+	deliveries []domain.WebhookDelivery
+}
+
+func (r *deliveryRecorder) SaveDelivery(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deliveries = append(r.deliveries, *delivery)
+	return nil
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	got := SignWebhookPayload("secreto", []byte(`{"id":"1"}`))
+	want := "60a59a950ad5c8e74f3767882f93ea57ba02c3f5aceb2211a1a381e731f65633"
+	if got != want {
+		t.Fatalf("firma = %s, se esperaba %s", got, want)
+	}
+
+	if SignWebhookPayload("otro", []byte(`{"id":"1"}`)) == want {
+		t.Fatal("la firma no depende del secreto")
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int32
+		wantAttempts int
+		wantSuccess  bool
+	}{
+		{"primer intento", 0, 1, true},
+		{"tras dos fallos", 2, 3, true},
+		{"siempre falla", webhookMaxAttempts, webhookMaxAttempts, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := []byte(`{"type":"reading.created"}`)
+			var calls atomic.Int32
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get(WebhookSignatureHeader) != "sha256="+SignWebhookPayload("secreto", payload) {
+					t.Errorf("firma incorrecta: %s", r.Header.Get(WebhookSignatureHeader))
+				}
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer receiver.Close()
+
+			repo := &deliveryRecorder{}
+			// El receptor escucha en loopback, que el cliente de producción rechaza
+			service := &webhookService{
+				webhookRepo: repo,
+				client:      receiver.Client(),
+				backoff:     time.Millisecond,
+				delivering:  newBackground(),
+			}
+
+			webhook := domain.Webhook{ID: 7, URL: receiver.URL, Secret: "secreto"}
+			service.deliver(webhook, domain.EventReadingCreated, payload)
+
+			if len(repo.deliveries) != tt.wantAttempts {
+				t.Fatalf("intentos = %d, se esperaban %d", len(repo.deliveries), tt.wantAttempts)
+			}
+			for i, delivery := range repo.deliveries {
+				if delivery.Attempt != i+1 || delivery.WebhookID != webhook.ID {
+					t.Errorf("intento %d registrado como %+v", i+1, delivery)
+				}
+			}
+			last := repo.deliveries[len(repo.deliveries)-1]
+			if last.Success != tt.wantSuccess {
+				t.Errorf("último intento con éxito = %v, se esperaba %v", last.Success, tt.wantSuccess)
+			}
+		})
+	}
+}
+
+func TestDeliverRejectsInternalAddresses(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("el cliente de webhooks no debe conectar a loopback")
+	}))
+	defer receiver.Close()
+
+	service := &webhookService{client: newPublicClient(webhookTimeout)}
+	delivery := service.attempt(domain.Webhook{URL: receiver.URL}, domain.EventReadingCreated, []byte(`{}`))
+	if delivery.Success || delivery.Error == "" {
+		t.Fatalf("entrega a loopback = %+v, se esperaba un error", delivery)
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"https://93.184.216.34/hook", false},
+		{"ftp://93.184.216.34/hook", true},
+		{"http://127.0.0.1:8080/hook", true},
+		{"http://10.0.0.5/hook", true},
+		{"http://192.168.1.10/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"http://[::1]/hook", true},
+		{"http://0.0.0.0/hook", true},
+		{"http://0.1.2.3/hook", true},
+		{"http://100.64.0.1/hook", true},
+		{"http://100.127.255.254/hook", true},
+		{"http://198.18.0.1/hook", true},
+		{"http://[::ffff:127.0.0.1]/hook", true},
+		{"http://[::ffff:10.0.0.1]/hook", true},
+		{"http://[64:ff9b::a00:1]/hook", true},
+		{"http://[2002:7f00:1::]/hook", true},
+		{"http://[2001:0:4136:e378:8000:63bf:3fff:fdd2]/hook", true},
+		{"http://[fd00::1]/hook", true},
+		{"http://[2606:4700:4700::1111]/hook", false},
+		{"http://100.128.0.1/hook", false},
+	}
+
+	for _, tt := range tests {
+		err := validateWebhookURL(context.Background(), tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateWebhookURL(%s) = %v, se esperaba error: %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
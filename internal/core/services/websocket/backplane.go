@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"ApiSmart/internal/core/ports"
+)
+
+// Espera antes de volver a suscribirse al backplane tras perder la suscripción
+const backplaneRetryDelay = 2 * time.Second
+
+// backplaneMessage es el mensaje que se reparte entre las instancias: el evento ya
+// serializado junto al dispositivo que lo originó y los temas en los que se publica
+type backplaneMessage struct {
+	DeviceID    string `json:"device_id,omitempty"`
+	DevicesOnly bool   `json:"devices_only,omitempty"`
+	// Comando de actuador que contiene el mensaje, para marcarlo como enviado al entregarlo
+	CommandID uint            `json:"command_id,omitempty"`
+	Topics    []string        `json:"topics"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// memoryBackplane reparte los mensajes dentro del propio proceso. Es el backplane
+// predeterminado cuando la API se ejecuta en una sola instancia
+type memoryBackplane struct {
+	mutex    sync.Mutex
+	seq      uint64
+	nextID   int
+	handlers map[int]func(seq uint64, message []byte)
+}
+
+// NewMemoryBackplane crea un backplane en memoria
+func NewMemoryBackplane() ports.Backplane {
+	return &memoryBackplane{handlers: make(map[int]func(uint64, []byte))}
+}
+
+// Publish numera el mensaje y lo entrega a todos los suscriptores. El mutex se mantiene
+// durante la entrega para que todos reciban los mensajes en el mismo orden
+func (b *memoryBackplane) Publish(ctx context.Context, message []byte) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.seq++
+	for _, handler := range b.handlers {
+		handler(b.seq, message)
+	}
+	return nil
+}
+
+func (b *memoryBackplane) Subscribe(ctx context.Context, handler func(seq uint64, message []byte)) error {
+	b.mutex.Lock()
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+	b.mutex.Unlock()
+
+	<-ctx.Done()
+
+	b.mutex.Lock()
+	delete(b.handlers, id)
+	b.mutex.Unlock()
+	return ctx.Err()
+}
+
+func (b *memoryBackplane) Close() error {
+	return nil
+}
+
+// consumeBackplane recibe los mensajes publicados por todas las instancias, incluida
+// esta, y los entrega a Run hasta que se cancela el contexto. Si se pierde la suscripción
+// se vuelve a suscribir
+func (s *Server) consumeBackplane(ctx context.Context) {
+	for {
+		err := s.backplane.Subscribe(ctx, s.receiveBackplane)
+		if ctx.Err() != nil {
+			return
+		}
+		s.counters.backplaneErrors.Add(1)
+		log.Printf("Suscripción al backplane perdida: %v", err)
+
+		select {
+		case <-time.After(backplaneRetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// receiveBackplane pasa a Run un mensaje recibido del backplane con su número global
+func (s *Server) receiveBackplane(seq uint64, data []byte) {
+	var message backplaneMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		log.Printf("Error al deserializar mensaje del backplane: %v", err)
+		return
+	}
+
+	select {
+	case s.broadcast <- outboundMessage{
+		seq:         seq,
+		deviceID:    message.DeviceID,
+		devicesOnly: message.DevicesOnly,
+		commandID:   message.CommandID,
+		topics:      message.Topics,
+		data:        message.Data,
+	}:
+	case <-s.done:
+	}
+}
+
+// advanceSeq avanza la numeración hasta la del mensaje recibido. Un salto indica que se
+// perdieron mensajes del backplane y un retroceso que su numeración se reinició; en ambos
+// casos el historial ya no sirve para reanudar y los clientes conectados deben
+// resincronizarse. Debe llamarse desde Run con el mutex tomado
+func (s *Server) advanceSeq(seq uint64) {
+	previous := s.seq
+	s.seq = seq
+	if previous == 0 || seq == previous+1 {
+		return
+	}
+
+	s.counters.backplaneGaps.Add(1)
+	log.Printf("Numeración del backplane discontinua: se esperaba %d y se recibió %d", previous+1, seq)
+
+	s.history = newReplayBuffer(s.options.ReplayBufferSize)
+	data, err := json.Marshal(s.resyncPayload(previous))
+	if err != nil {
+		return
+	}
+	for client := range s.clients {
+		s.deliver(client, data)
+	}
+}
@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"ApiSmart/internal/core/domain"
+	wsDomain "ApiSmart/internal/core/domain/websocket"
+	"ApiSmart/internal/core/ports"
+)
+
+// SetActuatorService asigna el servicio que registra las confirmaciones de los comandos
+func (s *Server) SetActuatorService(actuatorService ports.ActuatorService) {
+	s.actuatorService = actuatorService
+}
+
+// PublishCommand envía un comando a las conexiones del dispositivo, en cualquier instancia.
+// La instancia que lo entrega a una conexión del dispositivo lo marca como enviado
+func (s *Server) PublishCommand(ctx context.Context, command domain.ActuatorCommand) {
+	s.publish(backplaneMessage{
+		DeviceID:    command.DeviceID,
+		DevicesOnly: true,
+		CommandID:   command.ID,
+		Topics:      []string{wsDomain.DeviceTopic(command.DeviceID)},
+	}, wsDomain.CommandPayload{
+		Type:    wsDomain.CommandMessage,
+		Command: command,
+	})
+}
+
+// PublishActuator difunde el estado de un actuador a los clientes que ven su dispositivo
+func (s *Server) PublishActuator(ctx context.Context, actuator domain.Actuator, command *domain.ActuatorCommand) {
+	s.BroadcastEvent(actuator.DeviceID, wsDomain.ActuatorPayload{
+		Type:     wsDomain.ActuatorMessage,
+		Actuator: actuator,
+		Command:  command,
+	}, wsDomain.TopicActuators)
+}
+
+// markCommandSent registra que el comando se entregó a una conexión del dispositivo
+func (s *Server) markCommandSent(commandID uint) {
+	if s.actuatorService == nil {
+		return
+	}
+
+	if err := s.actuatorService.MarkCommandSent(context.Background(), commandID); err != nil {
+		log.Printf("Error al marcar el comando %d como enviado: %v", commandID, err)
+	}
+}
+
+// sendPendingCommands entrega al dispositivo que acaba de conectar los comandos que se
+// emitieron mientras no estaba conectado y siguen sin confirmar
+func (c *Client) sendPendingCommands() {
+	if c.server.actuatorService == nil {
+		return
+	}
+
+	commands, err := c.server.actuatorService.PendingCommands(context.Background(), c.deviceID)
+	if err != nil {
+		log.Printf("Error al obtener los comandos pendientes del dispositivo %s: %v", c.deviceID, err)
+		return
+	}
+
+	for _, command := range commands {
+		c.reply(wsDomain.CommandPayload{
+			Type:    wsDomain.CommandMessage,
+			Command: command,
+		})
+	}
+}
+
+// handleCommandAck registra la confirmación de un comando enviada por el dispositivo
+func (c *Client) handleCommandAck(message []byte) {
+	if c.deviceID == "" || c.server.actuatorService == nil {
+		c.reply(wsDomain.AckPayload{
+			Type:  wsDomain.NackMessage,
+			Error: "solo los dispositivos autenticados pueden confirmar comandos",
+		})
+		return
+	}
+
+	var ack wsDomain.CommandAckMessage
+	if err := json.Unmarshal(message, &ack); err != nil {
+		log.Printf("Error al deserializar confirmación de comando: %v", err)
+		return
+	}
+
+	if _, err := c.server.actuatorService.AcknowledgeCommand(context.Background(), c.deviceID, ack.CommandAck); err != nil {
+		c.reply(wsDomain.AckPayload{
+			Type:      wsDomain.NackMessage,
+			CommandID: ack.CommandID,
+			Error:     err.Error(),
+		})
+	}
+}
@@ -1,120 +1,83 @@
 package websocket
 
 import (
+	"ApiSmart/internal/core/domain"
 	"ApiSmart/internal/core/domain/websocket"
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"time"
 )
 
-// handleSensorEvent procesa los eventos de sensores
-func (s *Server) handleSensorEvent(event websocket.SensorEvent) {
+// Métrica del servicio de alertas que corresponde a cada tipo de evento
+var eventSensorTypes = map[websocket.EventType]string{
+	websocket.HumidityEvent:    "humedad",
+	websocket.TemperatureEvent: "temperatura",
+	websocket.LightEvent:       "luz",
+	websocket.PHEvent:          "ph",
+}
+
+// handleSensorEvent procesa los eventos de sensores y devuelve el ID de la lectura guardada
+func (s *Server) handleSensorEvent(event websocket.SensorEvent) (uint, error) {
 	switch event.Type {
-	case websocket.HumidityEvent:
-		s.handleHumidityEvent(event)
-	case websocket.TemperatureEvent:
-		s.handleTemperatureEvent(event)
-	case websocket.LightEvent:
-		s.handleLightEvent(event)
-	case websocket.PHEvent:
-		s.handlePHEvent(event)
+	case websocket.ReadingEvent:
+		return s.handleReadingEvent(event)
+	case websocket.HumidityEvent, websocket.TemperatureEvent, websocket.LightEvent, websocket.PHEvent:
+		return s.handleMetricEvent(event)
 	case websocket.SystemEvent:
-		s.handleSystemStatusEvent(event)
+		// El estado del sistema lo calcula el servicio de presencia con las conexiones reales
+		return 0, errors.New("el estado del sistema lo calcula el servidor")
 	default:
-		log.Printf("Evento desconocido: %v", event.Type)
+		return 0, fmt.Errorf("evento desconocido: %v", event.Type)
 	}
 }
 
-// handleHumidityEvent procesa eventos de humedad
-func (s *Server) handleHumidityEvent(event websocket.SensorEvent) {
-	if event.Value < 30.0 {
-		s.BroadcastEvent(map[string]interface{}{
-			"type":    "alert",
-			"message": "Humedad baja",
-			"value":   event.Value,
-			"unit":    event.Unit,
-		})
-	} else if event.Value > 70.0 {
-		s.BroadcastEvent(map[string]interface{}{
-			"type":    "alert",
-			"message": "Humedad alta",
-			"value":   event.Value,
-			"unit":    event.Unit,
-		})
+// handleReadingEvent guarda una lectura completa igual que POST /sensores; el servicio
+// de sensores la difunde junto con sus alertas
+func (s *Server) handleReadingEvent(event websocket.SensorEvent) (uint, error) {
+	if event.Reading == nil {
+		return 0, errors.New("el evento no incluye la lectura")
 	}
-	s.BroadcastEvent(event)
-}
 
-// handleTemperatureEvent procesa eventos de temperatura
-func (s *Server) handleTemperatureEvent(event websocket.SensorEvent) {
-	if event.Value < 15.0 {
-		s.BroadcastEvent(map[string]interface{}{
-			"type":    "alert",
-			"message": "Temperatura baja",
-			"value":   event.Value,
-			"unit":    event.Unit,
-		})
-	} else if event.Value > 30.0 {
-		s.BroadcastEvent(map[string]interface{}{
-			"type":    "alert",
-			"message": "Temperatura alta",
-			"value":   event.Value,
-			"unit":    event.Unit,
-		})
-	}
-	s.BroadcastEvent(event)
-}
+	data := *event.Reading
+	data.ID = 0
+	data.DeviceID = eventDeviceID(event)
 
-// handleLightEvent procesa eventos de luz
-func (s *Server) handleLightEvent(event websocket.SensorEvent) {
-	if event.Value < 100.0 {
-		s.BroadcastEvent(map[string]interface{}{
-			"type":    "alert",
-			"message": "Luz insuficiente",
-			"value":   event.Value,
-			"unit":    event.Unit,
-		})
+	if err := s.sensorService.SaveSensorData(context.Background(), &data); err != nil {
+		return 0, err
 	}
-	s.BroadcastEvent(event)
-}
 
-// handlePHEvent procesa eventos de pH
-func (s *Server) handlePHEvent(event websocket.SensorEvent) {
-	if event.Value < 5.5 || event.Value > 7.5 {
-		s.BroadcastEvent(map[string]interface{}{
-			"type":    "alert",
-			"message": "pH fuera de rango",
-			"value":   event.Value,
-			"unit":    event.Unit,
-		})
-	}
-	s.BroadcastEvent(event)
+	return data.ID, nil
 }
 
-// handleSystemStatusEvent procesa eventos de estado del sistema
-func (s *Server) handleSystemStatusEvent(event websocket.SensorEvent) {
-	// Verificar si Details existe y es del tipo correcto
-	if event.Details == nil {
-		log.Printf("Error: Details es nil en evento de sistema")
-		return
+// handleMetricEvent guarda la lectura de una métrica, que se evalúa con los umbrales del
+// servicio de alertas, y difunde el evento. La lectura se fecha con la hora del servidor,
+// porque el reloj del dispositivo puede estar desajustado; la suya se guarda aparte
+func (s *Server) handleMetricEvent(event websocket.SensorEvent) (uint, error) {
+	reading := domain.MetricReading{
+		DeviceID:   eventDeviceID(event),
+		SensorType: eventSensorTypes[event.Type],
+		Value:      event.Value,
+		Unit:       event.Unit,
+		CreatedAt:  time.Now(),
+	}
+	if !event.Timestamp.IsZero() {
+		deviceTime := event.Timestamp
+		reading.DeviceTime = &deviceTime
 	}
 
-	// Acceder directamente a los valores del mapa
-	sensorsOnline, ok1 := event.Details["sensors_online"].(float64)
-	totalSensors, ok2 := event.Details["total_sensors"].(float64)
-
-	if !ok1 || !ok2 {
-		log.Printf("Error: No se pudieron convertir los valores de sensores")
-		return
+	if err := s.sensorService.SaveMetricReading(context.Background(), &reading); err != nil {
+		return 0, err
 	}
 
-	if sensorsOnline < totalSensors {
-		s.BroadcastEvent(map[string]interface{}{
-			"type":    "alert",
-			"message": "Sensores desconectados",
-			"details": map[string]interface{}{
-				"online": sensorsOnline,
-				"total":  totalSensors,
-			},
-		})
+	s.BroadcastEvent(reading.DeviceID, event, websocket.MetricTopic(reading.SensorType))
+	return reading.ID, nil
+}
+
+// eventDeviceID obtiene el dispositivo indicado en los detalles del evento
+func eventDeviceID(event websocket.SensorEvent) string {
+	if deviceID, ok := event.Details["device_id"].(string); ok && deviceID != "" {
+		return deviceID
 	}
-	s.BroadcastEvent(event)
+	return domain.DefaultDeviceID
 }
@@ -0,0 +1,42 @@
+package websocket
+
+import (
+	"sync/atomic"
+
+	wsDomain "ApiSmart/internal/core/domain/websocket"
+)
+
+// hubCounters acumula las métricas del servidor; se actualizan de forma atómica
+type hubCounters struct {
+	broadcast         atomic.Uint64
+	queued            atomic.Uint64
+	dropped           atomic.Uint64
+	slowDisconnects   atomic.Uint64
+	heartbeatTimeouts atomic.Uint64
+
+	backplanePublished atomic.Uint64
+	backplaneErrors    atomic.Uint64
+	backplaneGaps      atomic.Uint64
+}
+
+// Metrics devuelve una instantánea de la actividad del servidor
+func (s *Server) Metrics() wsDomain.HubMetrics {
+	s.mutex.Lock()
+	clients := len(s.clients)
+	topics := len(s.subscribers)
+	s.mutex.Unlock()
+
+	return wsDomain.HubMetrics{
+		ConnectedClients:        clients,
+		Topics:                  topics,
+		SlowConsumerPolicy:      s.options.SlowConsumerPolicy,
+		MessagesBroadcast:       s.counters.broadcast.Load(),
+		MessagesQueued:          s.counters.queued.Load(),
+		MessagesDropped:         s.counters.dropped.Load(),
+		SlowConsumerDisconnects: s.counters.slowDisconnects.Load(),
+		HeartbeatTimeouts:       s.counters.heartbeatTimeouts.Load(),
+		BackplanePublished:      s.counters.backplanePublished.Load(),
+		BackplaneErrors:         s.counters.backplaneErrors.Load(),
+		BackplaneGaps:           s.counters.backplaneGaps.Load(),
+	}
+}
@@ -0,0 +1,180 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+
+	"ApiSmart/internal/core/domain"
+	wsDomain "ApiSmart/internal/core/domain/websocket"
+)
+
+// Capacidad predeterminada del historial de mensajes para reenviar al reconectar
+const defaultReplayBuffer = 1024
+
+// registration da de alta un cliente indicando el último mensaje que ya recibió y, si
+// no quiere recibirlo todo, los temas a los que se suscribe desde el principio
+type registration struct {
+	client  *Client
+	lastSeq uint64
+	topics  []string
+}
+
+// replayBuffer guarda los últimos mensajes difundidos en un búfer circular
+type replayBuffer struct {
+	messages []outboundMessage
+	next     int
+	full     bool
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{messages: make([]outboundMessage, size)}
+}
+
+func (b *replayBuffer) add(message outboundMessage) {
+	b.messages[b.next] = message
+	b.next = (b.next + 1) % len(b.messages)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// ordered devuelve los mensajes guardados del más antiguo al más reciente
+func (b *replayBuffer) ordered() []outboundMessage {
+	if !b.full {
+		return b.messages[:b.next]
+	}
+	return append(append([]outboundMessage{}, b.messages[b.next:]...), b.messages[:b.next]...)
+}
+
+// oldestSeq devuelve el número del mensaje más antiguo guardado, o 0 si no hay ninguno
+func (b *replayBuffer) oldestSeq() uint64 {
+	if b.full {
+		return b.messages[b.next].seq
+	}
+	if b.next == 0 {
+		return 0
+	}
+	return b.messages[0].seq
+}
+
+// withSeq añade el número de secuencia al objeto JSON del mensaje
+func withSeq(seq uint64, data []byte) []byte {
+	if len(data) < 2 || data[0] != '{' {
+		return data
+	}
+
+	message := append([]byte(`{"seq":`), strconv.FormatUint(seq, 10)...)
+	if string(data) == "{}" {
+		return append(message, '}')
+	}
+	message = append(message, ',')
+	return append(message, data[1:]...)
+}
+
+// canResume indica si todavía se guardan todos los mensajes posteriores a lastSeq
+func (s *Server) canResume(lastSeq uint64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if lastSeq > s.seq {
+		// El servidor se reinició y la numeración empezó de nuevo
+		return false
+	}
+	if lastSeq == s.seq {
+		return true
+	}
+	oldest := s.history.oldestSeq()
+	return oldest != 0 && oldest <= lastSeq+1
+}
+
+// currentSeq devuelve el número del último mensaje difundido
+func (s *Server) currentSeq() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.seq
+}
+
+// replay reenvía al cliente los mensajes visibles posteriores a lastSeq. Si ya no están
+// todos, o no caben en su cola, le pide que se resincronice.
+// Debe llamarse desde Run con el mutex tomado
+func (s *Server) replay(client *Client, lastSeq uint64) {
+	if lastSeq >= s.seq {
+		return
+	}
+
+	missed := []outboundMessage{}
+	complete := false
+	for _, message := range s.history.ordered() {
+		if message.seq <= lastSeq {
+			complete = true
+			continue
+		}
+		if message.seq == lastSeq+1 {
+			complete = true
+		}
+		if client.accepts(message) {
+			missed = append(missed, message)
+		}
+	}
+
+	if !complete || len(missed) > cap(client.send)-len(client.send) {
+		data, err := json.Marshal(s.resyncPayload(lastSeq))
+		if err == nil {
+			s.deliver(client, data)
+		}
+		return
+	}
+
+	for _, message := range missed {
+		s.deliver(client, message.data)
+	}
+}
+
+// resyncPayload construye el aviso de resincronización. Debe llamarse con el mutex tomado
+func (s *Server) resyncPayload(lastSeq uint64) wsDomain.ResyncPayload {
+	return wsDomain.ResyncPayload{
+		Type:      wsDomain.ResyncMessage,
+		LastSeq:   lastSeq,
+		OldestSeq: s.history.oldestSeq(),
+		LatestSeq: s.seq,
+	}
+}
+
+// snapshot construye la última lectura de cada dispositivo visible para el cliente
+func (s *Server) snapshot(client *Client, seq uint64) wsDomain.SnapshotPayload {
+	payload := wsDomain.SnapshotPayload{
+		Type:     wsDomain.SnapshotMessage,
+		Seq:      seq,
+		Readings: []domain.SensorData{},
+	}
+
+	readings, err := s.sensorService.GetLatestSensorDataByDevice(context.Background())
+	if err != nil {
+		log.Printf("Error al obtener las últimas lecturas: %v", err)
+		return payload
+	}
+
+	for _, reading := range readings {
+		if client.canReceive(reading.DeviceID) {
+			payload.Readings = append(payload.Readings, reading)
+		}
+	}
+
+	return payload
+}
+
+// queue encola un mensaje para un cliente que aún no está registrado en Run
+func (c *Client) queue(payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error al marcar evento: %v", err)
+		return
+	}
+
+	select {
+	case c.send <- data:
+	default:
+	}
+}
@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fill guarda en un búfer de tamaño size los mensajes numerados de 1 a count
+func fill(size, count int) *replayBuffer {
+	buffer := newReplayBuffer(size)
+	for seq := 1; seq <= count; seq++ {
+		buffer.add(outboundMessage{seq: uint64(seq)})
+	}
+	return buffer
+}
+
+func seqs(messages []outboundMessage) []uint64 {
+	result := []uint64{}
+	for _, message := range messages {
+		result = append(result, message.seq)
+	}
+	return result
+}
+
+func TestReplayBuffer(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		count      int
+		wantSeqs   []uint64
+		wantOldest uint64
+	}{
+		{"vacío", 4, 0, []uint64{}, 0},
+		{"parcial", 4, 2, []uint64{1, 2}, 1},
+		{"justo lleno", 4, 4, []uint64{1, 2, 3, 4}, 1},
+		{"una vuelta y un mensaje", 4, 5, []uint64{2, 3, 4, 5}, 2},
+		{"varias vueltas", 4, 10, []uint64{7, 8, 9, 10}, 7},
+		{"vuelta completa exacta", 4, 8, []uint64{5, 6, 7, 8}, 5},
+		{"tamaño uno", 1, 3, []uint64{3}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer := fill(tt.size, tt.count)
+
+			if got := seqs(buffer.ordered()); !reflect.DeepEqual(got, tt.wantSeqs) {
+				t.Errorf("ordered = %v, se esperaba %v", got, tt.wantSeqs)
+			}
+			if got := buffer.oldestSeq(); got != tt.wantOldest {
+				t.Errorf("oldestSeq = %d, se esperaba %d", got, tt.wantOldest)
+			}
+		})
+	}
+}
+
+func TestReplayBufferOrderedDoesNotAlias(t *testing.T) {
+	buffer := fill(3, 4)
+	ordered := buffer.ordered()
+	buffer.add(outboundMessage{seq: 5})
+
+	if got := seqs(ordered); !reflect.DeepEqual(got, []uint64{2, 3, 4}) {
+		t.Errorf("ordered cambió al añadir un mensaje: %v", got)
+	}
+}
+
+func TestWithSeq(t *testing.T) {
+	tests := []struct {
+		name string
+		seq  uint64
+		data string
+		want string
+	}{
+		{"objeto", 7, `{"type":"sensor_data"}`, `{"seq":7,"type":"sensor_data"}`},
+		{"objeto vacío", 1, `{}`, `{"seq":1}`},
+		{"número máximo", ^uint64(0), `{"a":1}`, `{"seq":18446744073709551615,"a":1}`},
+		{"array", 3, `[1,2]`, `[1,2]`},
+		{"cadena", 3, `"hola"`, `"hola"`},
+		{"demasiado corto", 3, `{`, `{`},
+		{"vacío", 3, ``, ``},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(withSeq(tt.seq, []byte(tt.data))); got != tt.want {
+				t.Errorf("withSeq = %s, se esperaba %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanResume(t *testing.T) {
+	tests := []struct {
+		name    string
+		size    int
+		count   int
+		lastSeq uint64
+		want    bool
+	}{
+		{"sin mensajes", 4, 0, 0, true},
+		{"al día", 4, 3, 3, true},
+		{"desde el principio sin vuelta", 4, 3, 0, true},
+		{"un mensaje perdido", 4, 3, 2, true},
+		{"servidor reiniciado", 4, 3, 9, false},
+		{"tras la vuelta, justo el más antiguo", 4, 10, 6, true},
+		{"tras la vuelta, uno antes del más antiguo", 4, 10, 5, false},
+		{"tras la vuelta, desde el principio", 4, 10, 0, false},
+		{"tras la vuelta, al día", 4, 10, 10, true},
+		{"búfer justo lleno, desde el principio", 4, 4, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &Server{
+				seq:     uint64(tt.count),
+				history: fill(tt.size, tt.count),
+			}
+
+			if got := server.canResume(tt.lastSeq); got != tt.want {
+				t.Errorf("canResume(%d) = %v, se esperaba %v", tt.lastSeq, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,109 +1,595 @@
 package websocket
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"sync"
+	"time"
 
+	"ApiSmart/internal/core/domain"
 	wsDomain "ApiSmart/internal/core/domain/websocket"
+	"ApiSmart/internal/core/ports"
 
 	gorillaWs "github.com/gorilla/websocket"
 )
 
+// Cada cuánto se recalculan los dispositivos visibles para los clientes conectados
+const accessRefreshInterval = time.Minute
+
+// Transporte con el que se registran las conexiones en el historial de presencia
+const presenceTransport = "websocket"
+
+// Motivo enviado en el cierre de las conexiones al detener el servidor
+const shutdownReason = "el servidor se está deteniendo"
+
+const (
+	// Tiempo máximo para escribir un mensaje al cliente
+	writeWait = 10 * time.Second
+	// Tiempo máximo sin recibir un pong antes de dar la conexión por muerta
+	pongWait = 60 * time.Second
+	// Frecuencia de los ping; menor que pongWait
+	pingPeriod = (pongWait * 9) / 10
+	// Tamaño máximo de los mensajes recibidos
+	maxMessageSize = 4096
+	// Capacidad predeterminada de la cola de salida de cada cliente
+	defaultSendBuffer = 256
+)
+
+// ErrServerClosed se devuelve al conectar cuando el servidor ya se está deteniendo
+var ErrServerClosed = errors.New("el servidor WebSocket se está deteniendo")
+
+// Options configura el servidor WebSocket
+type Options struct {
+	SendBufferSize     int
+	SlowConsumerPolicy string // wsDomain.SlowConsumerDisconnect o wsDomain.SlowConsumerDropOldest
+	ReplayBufferSize   int
+	// Reparte los mensajes entre las instancias; si es nil se usa uno en memoria
+	Backplane ports.Backplane
+}
+
 // Client representa un cliente WebSocket conectado
 type Client struct {
 	conn     *gorillaWs.Conn
 	server   *Server
 	send     chan []byte
-	sensorID string
+	deviceID string // Conexión de un dispositivo autenticado; vacío para usuarios
+	userID   uint
+
+	// Temas suscritos; solo se modifican desde Server.Run
+	topics     map[string]bool
+	subscribed bool // Ya envió alguna suscripción explícita
+
+	// Cierre de la cola de salida, que puede pedirse desde varios caminos
+	closeOnce sync.Once
+	slow      bool // Se desconectó por no consumir los mensajes a tiempo
+	goingAway bool // Se desconectó porque el servidor se detiene
+
+	// Dispositivos cuyos eventos puede recibir el usuario
+	accessMu sync.RWMutex
+	access   *domain.DeviceAccess
+}
+
+// outboundMessage es un mensaje ya serializado junto al dispositivo que lo originó y
+// los temas en los que se publica. Los mensajes para dispositivos, como los comandos,
+// solo los reciben sus conexiones y no las de los usuarios
+type outboundMessage struct {
+	seq         uint64
+	deviceID    string
+	devicesOnly bool
+	commandID   uint
+	topics      []string
+	data        []byte
 }
 
 // Server representa el servidor WebSocket
 type Server struct {
 	clients    map[*Client]bool
-	broadcast  chan []byte
-	register   chan *Client
+	broadcast  chan outboundMessage
+	register   chan registration
 	unregister chan *Client
+	subscribe  chan subscriptionChange
+	direct     chan directMessage
 	mutex      sync.Mutex
+
+	// Índice de suscriptores por tema, para no recorrer todas las conexiones
+	subscribers map[string]map[*Client]bool
+
+	// Número del último mensaje difundido y mensajes recientes para reenviar al reconectar.
+	// La numeración la asigna el backplane, por lo que es la misma en todas las instancias
+	seq       uint64
+	history   *replayBuffer
+	backplane ports.Backplane
+
+	// Las lecturas recibidas se evalúan y guardan igual que las de la API HTTP
+	alertService    ports.AlertService
+	sensorService   ports.SensorService
+	gardenService   ports.GardenService
+	presenceService ports.PresenceService
+	actuatorService ports.ActuatorService
+	twinService     ports.DeviceTwinService
+
+	options  Options
+	counters hubCounters
+
+	// Ciclo de vida: done se cierra cuando Run deja de atender los canales, y pumps
+	// cuenta las goroutines de las conexiones WebSocket abiertas
+	done    chan struct{}
+	closing bool
+	pumps   sync.WaitGroup
 }
 
-// NewServer crea una nueva instancia del servidor WebSocket
-func NewServer() *Server {
+// NewServer crea una nueva instancia del servidor WebSocket. El servicio de sensores se
+// asigna después con SetSensorService, ya que este usa el servidor como publicador
+func NewServer(alertService ports.AlertService, gardenService ports.GardenService, options Options) *Server {
+	if options.SendBufferSize <= 0 {
+		options.SendBufferSize = defaultSendBuffer
+	}
+	if options.ReplayBufferSize <= 0 {
+		options.ReplayBufferSize = defaultReplayBuffer
+	}
+	switch options.SlowConsumerPolicy {
+	case wsDomain.SlowConsumerDisconnect, wsDomain.SlowConsumerDropOldest:
+	default:
+		if options.SlowConsumerPolicy != "" {
+			log.Printf("Política de clientes lentos desconocida %q, se usa %q", options.SlowConsumerPolicy, wsDomain.SlowConsumerDisconnect)
+		}
+		options.SlowConsumerPolicy = wsDomain.SlowConsumerDisconnect
+	}
+	if options.Backplane == nil {
+		options.Backplane = NewMemoryBackplane()
+	}
+
 	return &Server{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		alertService:  alertService,
+		gardenService: gardenService,
+		options:       options,
+		clients:       make(map[*Client]bool),
+		broadcast:     make(chan outboundMessage),
+		register:      make(chan registration),
+		unregister:    make(chan *Client),
+		subscribe:     make(chan subscriptionChange),
+		direct:        make(chan directMessage),
+		subscribers:   make(map[string]map[*Client]bool),
+		history:       newReplayBuffer(options.ReplayBufferSize),
+		backplane:     options.Backplane,
+		done:          make(chan struct{}),
+	}
+}
+
+// SetSensorService asigna el servicio con el que se guardan las alertas recibidas
+func (s *Server) SetSensorService(sensorService ports.SensorService) {
+	s.sensorService = sensorService
+}
+
+// Temas de métrica de las lecturas completas
+var readingTopics = []string{
+	wsDomain.MetricTopic("temperatura"),
+	wsDomain.MetricTopic("luz"),
+	wsDomain.MetricTopic("humedad"),
+	wsDomain.MetricTopic("humo"),
+}
+
+// SetPresenceService asigna el servicio al que se informa de las conexiones de dispositivos
+func (s *Server) SetPresenceService(presenceService ports.PresenceService) {
+	s.presenceService = presenceService
+}
+
+// PublishReading difunde una lectura guardada a los clientes suscritos
+func (s *Server) PublishReading(ctx context.Context, data domain.SensorData) {
+	payload := wsDomain.ReadingPayload{
+		Type:    wsDomain.ReadingMessage,
+		Reading: data,
 	}
+	s.BroadcastEvent(data.DeviceID, payload, readingTopics...)
 }
 
-// Run inicia el servidor WebSocket
-func (s *Server) Run() {
+// PublishAlert difunde una alerta generada a los clientes suscritos
+func (s *Server) PublishAlert(ctx context.Context, alert domain.Alert) {
+	s.BroadcastEvent(alert.DeviceID, wsDomain.AlertPayload{
+		Type:    wsDomain.AlertMessage,
+		Message: alert.Message,
+		Alert:   alert,
+	}, wsDomain.TopicAlerts, wsDomain.MetricTopic(alert.SensorType))
+}
+
+// PublishPresence difunde un cambio de presencia a los clientes que ven el dispositivo
+func (s *Server) PublishPresence(ctx context.Context, presence domain.DevicePresence) {
+	payload := wsDomain.PresencePayload{
+		Type:     wsDomain.PresenceMessage,
+		Presence: presence,
+	}
+	s.BroadcastEvent(presence.DeviceID, payload, wsDomain.TopicSystem)
+}
+
+// PublishSystemStatus difunde el estado global del sistema. Incluye todos los dispositivos,
+// por lo que solo lo reciben los clientes con acceso a todos
+func (s *Server) PublishSystemStatus(ctx context.Context, status domain.SystemStatus) {
+	payload := wsDomain.SystemStatus{
+		Type:         wsDomain.SystemStatusMessage,
+		SystemStatus: status,
+	}
+	s.BroadcastEvent("", payload, wsDomain.TopicSystem)
+}
+
+// Run inicia el servidor WebSocket. Al cancelarse el contexto cierra todas las
+// conexiones y no vuelve hasta que han terminado
+func (s *Server) Run(ctx context.Context) {
+	refresh := time.NewTicker(accessRefreshInterval)
+	defer refresh.Stop()
+
+	go s.consumeBackplane(ctx)
+
 	for {
 		select {
-		case client := <-s.register:
+		case <-ctx.Done():
+			s.shutdown()
+			return
+
+		case <-refresh.C:
 			s.mutex.Lock()
-			s.clients[client] = true
+			clients := make([]*Client, 0, len(s.clients))
+			for client := range s.clients {
+				clients = append(clients, client)
+			}
+			s.mutex.Unlock()
+			go s.refreshAccess(clients)
+
+		case reg := <-s.register:
+			s.mutex.Lock()
+			s.clients[reg.client] = true
+			if len(reg.topics) == 0 {
+				s.addTopic(reg.client, wsDomain.TopicAll)
+			}
+			for _, topic := range reg.topics {
+				s.addTopic(reg.client, topic)
+				reg.client.subscribed = true
+			}
+			s.replay(reg.client, reg.lastSeq)
 			s.mutex.Unlock()
 
 		case client := <-s.unregister:
 			s.mutex.Lock()
 			if _, ok := s.clients[client]; ok {
-				delete(s.clients, client)
-				close(client.send)
+				s.removeClient(client)
+			}
+			s.mutex.Unlock()
+
+		case change := <-s.subscribe:
+			s.mutex.Lock()
+			if _, ok := s.clients[change.client]; ok {
+				s.applySubscription(change)
+			}
+			s.mutex.Unlock()
+
+		case message := <-s.direct:
+			s.mutex.Lock()
+			if _, ok := s.clients[message.client]; ok {
+				s.deliver(message.client, message.data)
 			}
 			s.mutex.Unlock()
 
 		case message := <-s.broadcast:
+			s.counters.broadcast.Add(1)
 			s.mutex.Lock()
-			for client := range s.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(s.clients, client)
+			s.advanceSeq(message.seq)
+			message.data = withSeq(message.seq, message.data)
+			s.history.add(message)
+			toDevice := false
+			for client := range s.recipients(message.topics) {
+				if client.accepts(message) && s.deliver(client, message.data) && client.deviceID != "" {
+					toDevice = true
 				}
 			}
 			s.mutex.Unlock()
+
+			// Solo la instancia con la conexión del dispositivo marca el comando como enviado
+			if message.commandID != 0 && toDevice {
+				go s.markCommandSent(message.commandID)
+			}
 		}
 	}
 }
 
-// BroadcastEvent envía un evento a todos los clientes conectados
-func (s *Server) BroadcastEvent(event interface{}) {
-	message, err := json.Marshal(event)
+// BroadcastEvent envía un evento del dispositivo a los clientes que pueden verlo y están
+// suscritos a su tema de dispositivo o a alguno de los temas adicionales. Sin dispositivo
+// solo lo reciben los clientes con acceso a todos. El evento se publica en el backplane,
+// que lo entrega a los clientes de todas las instancias
+func (s *Server) BroadcastEvent(deviceID string, event interface{}, topics ...string) {
+	if deviceID != "" {
+		topics = append([]string{wsDomain.DeviceTopic(deviceID)}, topics...)
+	}
+	s.publish(backplaneMessage{DeviceID: deviceID, Topics: topics}, event)
+}
+
+// publish serializa el evento y lo publica en el backplane con el destino indicado
+func (s *Server) publish(message backplaneMessage, event interface{}) {
+	var err error
+	message.Data, err = json.Marshal(event)
+	if err != nil {
+		log.Printf("Error al marcar evento: %v", err)
+		return
+	}
+
+	data, err := json.Marshal(message)
 	if err != nil {
 		log.Printf("Error al marcar evento: %v", err)
 		return
 	}
-	s.broadcast <- message
+	if err := s.backplane.Publish(context.Background(), data); err != nil {
+		s.counters.backplaneErrors.Add(1)
+		log.Printf("Error al publicar evento en el backplane: %v", err)
+		return
+	}
+	s.counters.backplanePublished.Add(1)
 }
 
-// HandleWebSocket maneja una nueva conexión WebSocket
-func (s *Server) HandleWebSocket(conn *gorillaWs.Conn) {
+// HandleWebSocket maneja una nueva conexión WebSocket de un usuario ya autenticado. Con
+// lastSeq se reenvían los mensajes perdidos desde entonces; sin él, o si ya no se guardan,
+// el cliente recibe una instantánea de las últimas lecturas
+func (s *Server) HandleWebSocket(conn *gorillaWs.Conn, userID uint, lastSeq *uint64) {
+	client, err := s.newClient(userID)
+	if err != nil {
+		log.Printf("Error al obtener los dispositivos del usuario %d: %v", userID, err)
+		conn.Close()
+		return
+	}
+	client.conn = conn
+
+	if err := s.connect(client, lastSeq, nil); err != nil {
+		rejectConnection(conn)
+		return
+	}
+
+	go client.writePump()
+	go client.readPump()
+}
+
+// HandleDeviceWebSocket maneja la conexión de un dispositivo ya autenticado. Sus eventos
+// se guardan como lecturas y cada uno recibe una confirmación
+func (s *Server) HandleDeviceWebSocket(conn *gorillaWs.Conn, deviceID string) {
 	client := &Client{
-		conn:   conn,
-		server: s,
-		send:   make(chan []byte, 256),
+		conn:     conn,
+		server:   s,
+		send:     make(chan []byte, s.options.SendBufferSize),
+		deviceID: deviceID,
+		access:   &domain.DeviceAccess{DeviceIDs: map[string]bool{deviceID: true}},
+		topics:   make(map[string]bool),
 	}
 
-	s.register <- client
+	// Los dispositivos no necesitan el historial: solo reciben lo que ocurra desde ahora
+	err := s.registerClient(registration{
+		client:  client,
+		lastSeq: s.currentSeq(),
+		topics:  []string{wsDomain.DeviceTopic(deviceID)},
+	})
+	if err != nil {
+		rejectConnection(conn)
+		return
+	}
+	s.presenceService.DeviceConnected(context.Background(), deviceID, presenceTransport)
+	go client.sendPendingCommands()
+	go client.sendTwinDelta()
 
 	go client.writePump()
 	go client.readPump()
 }
 
-// writePump maneja la escritura de mensajes al cliente
+// newClient crea un cliente con los dispositivos visibles para el usuario
+func (s *Server) newClient(userID uint) (*Client, error) {
+	access, err := s.gardenService.DeviceAccess(context.Background(), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		server: s,
+		send:   make(chan []byte, s.options.SendBufferSize),
+		userID: userID,
+		access: access,
+		topics: make(map[string]bool),
+	}, nil
+}
+
+// connect encola la instantánea o el aviso de resincronización que corresponda y registra
+// el cliente, que recibirá los mensajes posteriores a los ya reflejados
+func (s *Server) connect(client *Client, lastSeq *uint64, topics []string) error {
+	var resumeFrom uint64
+	needSnapshot := lastSeq == nil
+	if lastSeq != nil {
+		if s.canResume(*lastSeq) {
+			resumeFrom = *lastSeq
+		} else {
+			s.mutex.Lock()
+			client.queue(s.resyncPayload(*lastSeq))
+			s.mutex.Unlock()
+			needSnapshot = true
+		}
+	}
+	if needSnapshot {
+		resumeFrom = s.currentSeq()
+		client.queue(s.snapshot(client, resumeFrom))
+	}
+
+	return s.registerClient(registration{client: client, lastSeq: resumeFrom, topics: topics})
+}
+
+// registerClient da de alta al cliente en Run. Las goroutines de las conexiones WebSocket
+// se cuentan antes de registrarlas, para que shutdown espere a que terminen
+func (s *Server) registerClient(reg registration) error {
+	s.mutex.Lock()
+	if s.closing {
+		s.mutex.Unlock()
+		return ErrServerClosed
+	}
+	if reg.client.conn != nil {
+		s.pumps.Add(2)
+	}
+	s.mutex.Unlock()
+
+	select {
+	case s.register <- reg:
+		return nil
+	case <-s.done:
+		if reg.client.conn != nil {
+			s.pumps.Add(-2)
+		}
+		return ErrServerClosed
+	}
+}
+
+// shutdown da de baja a todos los clientes: los WebSocket reciben un cierre "going away"
+// después de los mensajes que tenían en cola y los streams terminan. Espera a que acaben
+// las conexiones, incluidas las lecturas de dispositivos que se estuvieran guardando
+func (s *Server) shutdown() {
+	s.mutex.Lock()
+	s.closing = true
+	close(s.done)
+	for client := range s.clients {
+		client.goingAway = true
+		s.removeClient(client)
+	}
+	s.mutex.Unlock()
+
+	s.pumps.Wait()
+}
+
+// rejectConnection cierra una conexión que no se pudo registrar porque el servidor se detiene
+func rejectConnection(conn *gorillaWs.Conn) {
+	closeMessage := gorillaWs.FormatCloseMessage(gorillaWs.CloseGoingAway, shutdownReason)
+	conn.WriteControl(gorillaWs.CloseMessage, closeMessage, time.Now().Add(writeWait))
+	conn.Close()
+}
+
+// refreshAccess recalcula los dispositivos visibles de cada cliente, para que los cambios
+// en los jardines se apliquen sin reconectar
+func (s *Server) refreshAccess(clients []*Client) {
+	for _, client := range clients {
+		// Las conexiones de dispositivos no tienen usuario ni lista de dispositivos
+		if client.deviceID != "" {
+			continue
+		}
+
+		access, err := s.gardenService.DeviceAccess(context.Background(), client.userID)
+		if err != nil {
+			log.Printf("Error al actualizar los dispositivos del usuario %d: %v", client.userID, err)
+			continue
+		}
+
+		client.accessMu.Lock()
+		client.access = access
+		client.accessMu.Unlock()
+	}
+}
+
+// accepts indica si el mensaje es para este cliente: los de dispositivos solo van a sus
+// conexiones y el resto a quien pueda ver el dispositivo
+func (c *Client) accepts(message outboundMessage) bool {
+	if message.devicesOnly && c.deviceID == "" {
+		return false
+	}
+	return c.canReceive(message.deviceID)
+}
+
+// canReceive indica si el usuario del cliente puede ver los eventos del dispositivo
+func (c *Client) canReceive(deviceID string) bool {
+	c.accessMu.RLock()
+	defer c.accessMu.RUnlock()
+	return c.access.Allows(deviceID)
+}
+
+// deliver encola un mensaje para el cliente aplicando la política de clientes lentos e
+// indica si quedó encolado. Debe llamarse desde Run con el mutex tomado, ya que Run es el
+// único que escribe en send
+func (s *Server) deliver(client *Client, message []byte) bool {
+	select {
+	case client.send <- message:
+		s.counters.queued.Add(1)
+		return true
+	default:
+	}
+
+	if s.options.SlowConsumerPolicy == wsDomain.SlowConsumerDropOldest {
+		// Descartar el mensaje más antiguo para hacer sitio al nuevo
+		select {
+		case <-client.send:
+			s.counters.dropped.Add(1)
+		default:
+		}
+		select {
+		case client.send <- message:
+			s.counters.queued.Add(1)
+			return true
+		default:
+			s.counters.dropped.Add(1)
+		}
+		return false
+	}
+
+	s.counters.dropped.Add(1)
+	s.counters.slowDisconnects.Add(1)
+	client.slow = true
+	s.removeClient(client)
+	return false
+}
+
+// unregisterClient da de baja al cliente; si el servidor ya se detuvo no hace falta
+func (s *Server) unregisterClient(client *Client) {
+	select {
+	case s.unregister <- client:
+	case <-s.done:
+	}
+}
+
+// directMessage es un mensaje para un único cliente
+type directMessage struct {
+	client *Client
+	data   []byte
+}
+
+// reply envía un mensaje solo a este cliente, a través de Run como el resto de mensajes
+func (c *Client) reply(payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error al marcar evento: %v", err)
+		return
+	}
+	select {
+	case c.server.direct <- directMessage{client: c, data: data}:
+	case <-c.server.done:
+	}
+}
+
+// closeSend cierra la cola de salida una sola vez, lo que termina writePump
+func (c *Client) closeSend() {
+	c.closeOnce.Do(func() {
+		close(c.send)
+	})
+}
+
+// writePump maneja la escritura de mensajes al cliente y envía los ping de keepalive
 func (c *Client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
 	defer func() {
+		ticker.Stop()
 		c.conn.Close()
+		c.server.pumps.Done()
 	}()
 
 	for {
 		select {
 		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
 			if !ok {
-				c.conn.WriteMessage(gorillaWs.CloseMessage, []byte{})
+				closeMessage := gorillaWs.FormatCloseMessage(gorillaWs.CloseNormalClosure, "")
+				if c.slow {
+					closeMessage = gorillaWs.FormatCloseMessage(gorillaWs.CloseTryAgainLater, "cliente demasiado lento")
+				} else if c.goingAway {
+					closeMessage = gorillaWs.FormatCloseMessage(gorillaWs.CloseGoingAway, shutdownReason)
+				}
+				c.conn.WriteMessage(gorillaWs.CloseMessage, closeMessage)
 				return
 			}
 
@@ -116,26 +602,68 @@ func (c *Client) writePump() {
 			if err := w.Close(); err != nil {
 				return
 			}
+
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(gorillaWs.PingMessage, nil); err != nil {
+				return
+			}
 		}
 	}
 }
 
-// readPump maneja la lectura de mensajes del cliente
+// readPump maneja la lectura de mensajes del cliente; cada pong renueva el plazo de lectura
 func (c *Client) readPump() {
 	defer func() {
-		c.server.unregister <- c
+		c.server.unregisterClient(c)
 		c.conn.Close()
+		if c.deviceID != "" {
+			c.server.presenceService.DeviceDisconnected(context.Background(), c.deviceID, presenceTransport)
+		}
+		c.server.pumps.Done()
 	}()
 
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
 	for {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
-			if gorillaWs.IsUnexpectedCloseError(err, gorillaWs.CloseGoingAway, gorillaWs.CloseAbnormalClosure) {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				c.server.counters.heartbeatTimeouts.Add(1)
+			} else if gorillaWs.IsUnexpectedCloseError(err, gorillaWs.CloseGoingAway, gorillaWs.CloseAbnormalClosure) {
 				log.Printf("error: %v", err)
 			}
 			break
 		}
 
+		// Mensajes de control de suscripciones, confirmaciones de comandos e informes de configuración
+		var control wsDomain.ControlMessage
+		if err := json.Unmarshal(message, &control); err == nil {
+			switch control.Type {
+			case wsDomain.SubscribeControl, wsDomain.UnsubscribeControl:
+				select {
+				case c.server.subscribe <- subscriptionChange{
+					client:      c,
+					topics:      control.Topics,
+					unsubscribe: control.Type == wsDomain.UnsubscribeControl,
+				}:
+				case <-c.server.done:
+				}
+				continue
+			case wsDomain.CommandAckControl:
+				c.handleCommandAck(message)
+				continue
+			case wsDomain.TwinReportedControl:
+				c.handleTwinReport(message)
+				continue
+			}
+		}
+
 		// Procesar mensaje recibido
 		var event wsDomain.SensorEvent
 		if err := json.Unmarshal(message, &event); err != nil {
@@ -143,7 +671,34 @@ func (c *Client) readPump() {
 			continue
 		}
 
-		// Procesar evento según su tipo
-		c.server.handleSensorEvent(event)
+		// Solo los dispositivos autenticados envían lecturas, y siempre en su nombre
+		if c.deviceID == "" {
+			c.reply(wsDomain.AckPayload{
+				Type:      wsDomain.NackMessage,
+				MessageID: event.MessageID,
+				Error:     "solo los dispositivos autenticados pueden enviar lecturas",
+			})
+			continue
+		}
+		if event.Details == nil {
+			event.Details = map[string]interface{}{}
+		}
+		event.Details["device_id"] = c.deviceID
+
+		// Procesar evento según su tipo y confirmar el resultado
+		readingID, err := c.server.handleSensorEvent(event)
+		if err != nil {
+			c.reply(wsDomain.AckPayload{
+				Type:      wsDomain.NackMessage,
+				MessageID: event.MessageID,
+				Error:     err.Error(),
+			})
+			continue
+		}
+		c.reply(wsDomain.AckPayload{
+			Type:      wsDomain.AckMessage,
+			MessageID: event.MessageID,
+			ReadingID: readingID,
+		})
 	}
 }
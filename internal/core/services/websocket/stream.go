@@ -0,0 +1,50 @@
+package websocket
+
+import (
+	"fmt"
+	"strings"
+
+	wsDomain "ApiSmart/internal/core/domain/websocket"
+)
+
+// Stream es una suscripción al servidor para transportes sin WebSocket, como SSE. Recibe
+// los mismos mensajes que un cliente WebSocket, pero no envía mensajes de control
+type Stream struct {
+	client *Client
+}
+
+// OpenStream registra una suscripción para el usuario con los temas indicados (todos si
+// no se indica ninguno), reanudando desde lastSeq igual que HandleWebSocket
+func (s *Server) OpenStream(userID uint, lastSeq *uint64, topics []string) (*Stream, error) {
+	invalid := []string{}
+	for _, topic := range topics {
+		if !wsDomain.ValidTopic(topic) {
+			invalid = append(invalid, topic)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("temas inválidos: %s", strings.Join(invalid, ", "))
+	}
+
+	client, err := s.newClient(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.connect(client, lastSeq, topics); err != nil {
+		return nil, err
+	}
+
+	return &Stream{client: client}, nil
+}
+
+// Messages devuelve los mensajes pendientes; se cierra cuando el servidor da de baja
+// la suscripción, por ejemplo por ser un consumidor lento
+func (st *Stream) Messages() <-chan []byte {
+	return st.client.send
+}
+
+// Close da de baja la suscripción
+func (st *Stream) Close() {
+	st.client.server.unregisterClient(st.client)
+}
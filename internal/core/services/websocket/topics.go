@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"encoding/json"
+	"sort"
+
+	wsDomain "ApiSmart/internal/core/domain/websocket"
+)
+
+// subscriptionChange es una petición de un cliente para cambiar sus temas
+type subscriptionChange struct {
+	client      *Client
+	topics      []string
+	unsubscribe bool
+}
+
+// applySubscription actualiza los temas del cliente y le confirma el resultado. La primera
+// suscripción explícita sustituye a la inicial a todos los mensajes.
+// Debe llamarse desde Run con el mutex tomado
+func (s *Server) applySubscription(change subscriptionChange) {
+	client := change.client
+	invalid := []string{}
+
+	for _, topic := range change.topics {
+		if !wsDomain.ValidTopic(topic) {
+			invalid = append(invalid, topic)
+			continue
+		}
+		if change.unsubscribe {
+			s.removeTopic(client, topic)
+			continue
+		}
+		if !client.subscribed && topic != wsDomain.TopicAll {
+			s.removeTopic(client, wsDomain.TopicAll)
+		}
+		s.addTopic(client, topic)
+	}
+	client.subscribed = true
+
+	topics := make([]string, 0, len(client.topics))
+	for topic := range client.topics {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+
+	message, err := json.Marshal(wsDomain.SubscriptionsPayload{
+		Type:    wsDomain.SubscriptionsMessage,
+		Topics:  topics,
+		Invalid: invalid,
+	})
+	if err != nil {
+		return
+	}
+
+	s.deliver(client, message)
+}
+
+// recipients reúne los suscriptores de los temas del mensaje y de todos los mensajes
+func (s *Server) recipients(topics []string) map[*Client]bool {
+	recipients := make(map[*Client]bool, len(s.subscribers[wsDomain.TopicAll]))
+	for client := range s.subscribers[wsDomain.TopicAll] {
+		recipients[client] = true
+	}
+	for _, topic := range topics {
+		for client := range s.subscribers[topic] {
+			recipients[client] = true
+		}
+	}
+	return recipients
+}
+
+func (s *Server) addTopic(client *Client, topic string) {
+	if s.subscribers[topic] == nil {
+		s.subscribers[topic] = make(map[*Client]bool)
+	}
+	s.subscribers[topic][client] = true
+	client.topics[topic] = true
+}
+
+func (s *Server) removeTopic(client *Client, topic string) {
+	delete(client.topics, topic)
+	delete(s.subscribers[topic], client)
+	if len(s.subscribers[topic]) == 0 {
+		delete(s.subscribers, topic)
+	}
+}
+
+// removeClient desconecta al cliente y lo quita del índice de temas
+func (s *Server) removeClient(client *Client) {
+	for topic := range client.topics {
+		s.removeTopic(client, topic)
+	}
+	delete(s.clients, client)
+	client.closeSend()
+}
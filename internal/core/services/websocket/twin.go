@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"ApiSmart/internal/core/domain"
+	wsDomain "ApiSmart/internal/core/domain/websocket"
+	"ApiSmart/internal/core/ports"
+)
+
+// SetDeviceTwinService asigna el servicio que entrega y registra la configuración de los dispositivos
+func (s *Server) SetDeviceTwinService(twinService ports.DeviceTwinService) {
+	s.twinService = twinService
+}
+
+// PublishTwinDelta envía la configuración pendiente a las conexiones del dispositivo, en
+// cualquier instancia
+func (s *Server) PublishTwinDelta(ctx context.Context, delta domain.DeviceTwinDelta) {
+	s.publish(backplaneMessage{
+		DeviceID:    delta.DeviceID,
+		DevicesOnly: true,
+		Topics:      []string{wsDomain.DeviceTopic(delta.DeviceID)},
+	}, wsDomain.TwinDeltaPayload{
+		Type:  wsDomain.TwinDeltaMessage,
+		Delta: delta,
+	})
+}
+
+// sendTwinDelta entrega al dispositivo que acaba de conectar la configuración que cambió
+// mientras no estaba conectado
+func (c *Client) sendTwinDelta() {
+	if c.server.twinService == nil {
+		return
+	}
+
+	delta, err := c.server.twinService.PendingDelta(context.Background(), c.deviceID)
+	if err != nil {
+		log.Printf("Error al obtener la configuración pendiente del dispositivo %s: %v", c.deviceID, err)
+		return
+	}
+
+	if !delta.Delta.IsEmpty() {
+		c.reply(wsDomain.TwinDeltaPayload{
+			Type:  wsDomain.TwinDeltaMessage,
+			Delta: *delta,
+		})
+	}
+}
+
+// handleTwinReport registra la configuración aplicada que comunica el dispositivo y le
+// reenvía la pendiente si la deseada cambió mientras la aplicaba
+func (c *Client) handleTwinReport(message []byte) {
+	if c.deviceID == "" || c.server.twinService == nil {
+		c.reply(wsDomain.AckPayload{
+			Type:  wsDomain.NackMessage,
+			Error: "solo los dispositivos autenticados pueden informar de su configuración",
+		})
+		return
+	}
+
+	var report wsDomain.TwinReportMessage
+	if err := json.Unmarshal(message, &report); err != nil {
+		log.Printf("Error al deserializar informe de configuración: %v", err)
+		return
+	}
+
+	twin, err := c.server.twinService.ReportConfig(context.Background(), c.deviceID, report.ReportConfigRequest)
+	if err != nil {
+		c.reply(wsDomain.AckPayload{
+			Type:    wsDomain.NackMessage,
+			Version: report.AppliedVersion,
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if !twin.InSync && twin.DesiredVersion > report.AppliedVersion {
+		c.reply(wsDomain.TwinDeltaPayload{
+			Type:  wsDomain.TwinDeltaMessage,
+			Delta: twin.TwinDelta(),
+		})
+	}
+}
@@ -6,26 +6,23 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
 	"ApiSmart/config"
+	"ApiSmart/internal/adapters/backplane"
 	"ApiSmart/internal/adapters/handlers"
 	"ApiSmart/internal/adapters/repositories/mysql"
+	"ApiSmart/internal/core/ports"
 	"ApiSmart/internal/core/services"
 	wsService "ApiSmart/internal/core/services/websocket"
 	"ApiSmart/pkg/database"
+	"ApiSmart/pkg/email"
 
 	"github.com/gin-gonic/gin"
-	gorillaWs "github.com/gorilla/websocket"
 )
 
-var upgrader = gorillaWs.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // En producción, configurar según necesidades de seguridad
-	},
-}
-
 func main() {
 	cfg := config.LoadConfig()
 
@@ -37,41 +34,280 @@ func main() {
 
 	userRepo := mysql.NewUserRepository(db)
 	sensorRepo := mysql.NewSensorRepository(db)
+	webhookRepo := mysql.NewWebhookRepository(db)
+	notificationPrefRepo := mysql.NewNotificationPreferenceRepository(db)
+	pushRepo := mysql.NewPushRepository(db)
+	silenceRepo := mysql.NewSilenceRepository(db)
+	digestRepo := mysql.NewDigestRepository(db)
+	messageTemplateRepo := mysql.NewMessageTemplateRepository(db)
+	deviceRepo := mysql.NewDeviceRepository(db)
+	gardenRepo := mysql.NewGardenRepository(db)
+	presenceRepo := mysql.NewPresenceRepository(db)
+	actuatorRepo := mysql.NewActuatorRepository(db)
+	automationRepo := mysql.NewAutomationRepository(db)
+	scheduleRepo := mysql.NewScheduleRepository(db)
+	controlLoopRepo := mysql.NewControlLoopRepository(db)
+	deviceTwinRepo := mysql.NewDeviceTwinRepository(db)
 
-	authService := services.NewAuthService(userRepo)
-	alertService := services.NewAlertService()
-	sensorService := services.NewSensorService(sensorRepo, alertService)
+	messageService, err := services.NewMessageService(messageTemplateRepo, userRepo)
+	if err != nil {
+		log.Fatalf("Failed to load message templates: %v", err)
+	}
+	if err := messageService.LoadOverrides(context.Background()); err != nil {
+		log.Fatalf("Failed to load message template overrides: %v", err)
+	}
 
-	authHandler := handlers.NewAuthHandler(authService)
-	sensorHandler := handlers.NewSensorHandler(sensorService)
+	authService := services.NewAuthService(userRepo, cfg.AdminEmails)
+	if err := authService.BootstrapAdmins(context.Background()); err != nil {
+		log.Fatalf("Failed to bootstrap administrators: %v", err)
+	}
+	alertService := services.NewAlertService(messageService)
+	gardenService := services.NewGardenService(gardenRepo, deviceRepo, userRepo)
+	silenceService := services.NewSilenceService(silenceRepo, gardenService)
+	webhookService := services.NewWebhookService(webhookRepo, gardenService)
+	notificationService := services.NewNotificationService(notificationPrefRepo)
 
-	// Inicializar servidor WebSocket
-	wsServer := wsService.NewServer()
-	go wsServer.Run()
+	pushService, err := services.NewPushService(context.Background(), pushRepo, notificationPrefRepo, messageService, gardenService, cfg.VAPIDKeys, cfg.VAPIDSubject)
+	if err != nil {
+		log.Fatalf("Failed to initialize push service: %v", err)
+	}
 
-	router := gin.Default()
+	// Canales que notifican a los usuarios; el correo solo si hay servidor SMTP
+	channels := []ports.ChannelNotifier{pushService}
+	if cfg.SMTPConfig.Host != "" {
+		mailer := email.NewMailer(cfg.SMTPConfig)
+		channels = append(channels, services.NewEmailNotifier(mailer, notificationPrefRepo, userRepo, messageService, gardenService))
+	}
 
-	// Rutas WebSocket
-	router.GET("/ws", func(c *gin.Context) {
-		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	notifiers := []ports.Notifier{webhookService}
+	for _, channel := range channels {
+		notifiers = append(notifiers, channel)
+	}
+
+	// Backplane que reparte los mensajes WebSocket entre las instancias de la API
+	var wsBackplane ports.Backplane
+	switch cfg.WSBackplane {
+	case "memory":
+	case "redis":
+		wsBackplane, err = backplane.NewRedisBackplane(cfg.RedisURL, cfg.WSBackplaneChannel)
 		if err != nil {
-			log.Printf("Error al actualizar conexión: %v", err)
-			return
+			log.Fatalf("Failed to connect to Redis backplane: %v", err)
 		}
-		wsServer.HandleWebSocket(conn)
+		defer wsBackplane.Close()
+	default:
+		log.Fatalf("Unknown WebSocket backplane: %s", cfg.WSBackplane)
+	}
+
+	// Inicializar servidor WebSocket; difunde las lecturas y alertas del servicio de sensores
+	wsServer := wsService.NewServer(alertService, gardenService, wsService.Options{
+		SendBufferSize:     cfg.WSSendBuffer,
+		SlowConsumerPolicy: cfg.WSSlowConsumerPolicy,
+		ReplayBufferSize:   cfg.WSReplayBuffer,
+		Backplane:          wsBackplane,
 	})
 
+	// Las automatizaciones se evalúan al recibir cada lectura y actúan mediante los actuadores
+	actuatorService := services.NewActuatorService(actuatorRepo, deviceRepo, gardenService, wsServer, time.Duration(cfg.ActuatorAckTimeoutSeconds)*time.Second)
+	wsServer.SetActuatorService(actuatorService)
+	automationService := services.NewAutomationService(automationRepo, deviceRepo, gardenRepo, actuatorService, gardenService)
+	scheduleService := services.NewScheduleService(scheduleRepo, deviceRepo, sensorRepo, actuatorService, gardenService)
+	controlLoopService := services.NewControlLoopService(controlLoopRepo, sensorRepo, actuatorRepo, actuatorService, gardenService)
+
+	sensorService := services.NewSensorService(sensorRepo, deviceRepo, alertService, silenceService, automationService, gardenService, wsServer, notifiers...)
+	wsServer.SetSensorService(sensorService)
+
+	presenceService := services.NewPresenceService(deviceRepo, presenceRepo, wsServer, cfg.DeviceMissedIntervals, time.Duration(cfg.DeviceCheckSeconds)*time.Second)
+	wsServer.SetPresenceService(presenceService)
+
+	digestService := services.NewDigestService(digestRepo, sensorRepo, userRepo, gardenService, channels...)
+	// La configuración deseada se envía a los dispositivos conectados al cambiar y al conectar
+	deviceTwinService := services.NewDeviceTwinService(deviceTwinRepo, deviceRepo, gardenService, wsServer)
+	wsServer.SetDeviceTwinService(deviceTwinService)
+
+	deviceService := services.NewDeviceService(deviceRepo, sensorService, gardenService, messageService, cfg.DeviceMissedIntervals, time.Duration(cfg.DeviceCheckSeconds)*time.Second)
+
+	authHandler := handlers.NewAuthHandler(authService)
+	sensorHandler := handlers.NewSensorHandler(sensorService, messageService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	pushHandler := handlers.NewPushHandler(pushService)
+	silenceHandler := handlers.NewSilenceHandler(silenceService)
+	digestHandler := handlers.NewDigestHandler(digestService)
+	messageHandler := handlers.NewMessageHandler(messageService)
+	deviceHandler := handlers.NewDeviceHandler(deviceService)
+	gardenHandler := handlers.NewGardenHandler(gardenService)
+	presenceHandler := handlers.NewPresenceHandler(presenceService, gardenService)
+	actuatorHandler := handlers.NewActuatorHandler(actuatorService)
+	automationHandler := handlers.NewAutomationHandler(automationService)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
+	controlLoopHandler := handlers.NewControlLoopHandler(controlLoopService)
+	deviceTwinHandler := handlers.NewDeviceTwinHandler(deviceTwinService)
+	wsHandler := handlers.NewWebSocketHandler(authService, deviceService, wsServer, cfg.WSAllowedOrigins)
+	sseHandler := handlers.NewSSEHandler(authService, wsServer)
+
+	// Tareas en segundo plano; se detienen al cancelar ctx y se esperan antes de salir
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	var workers sync.WaitGroup
+	runWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+
+	// Servidor WebSocket
+	runWorker(wsServer.Run)
+
+	// Planificador de resúmenes periódicos
+	runWorker(digestService.Run)
+
+	// Detección de dispositivos sin datos
+	runWorker(deviceService.Run)
+
+	// Presencia de los dispositivos y estado del sistema
+	runWorker(presenceService.Run)
+
+	// Expiración de comandos de actuadores y fin de los encendidos temporales
+	runWorker(actuatorService.Run)
+
+	// Programaciones de los actuadores
+	runWorker(scheduleService.Run)
+
+	// Lazos de control PID
+	runWorker(controlLoopService.Run)
+
+	router := gin.New()
+	router.Use(gin.LoggerWithFormatter(handlers.AccessLogFormatter), gin.Recovery())
+
+	// Rutas WebSocket
+	router.GET("/ws", wsHandler.HandleWebSocket)
+	// Alternativa a /ws para redes que bloquean WebSocket
+	router.GET("/api/stream", sseHandler.Stream)
+
 	router.POST("/api/register", authHandler.Register)
 	router.POST("/api/login", authHandler.Login)
 
-	router.POST("/sensores", sensorHandler.CreateSensorData)
+	// Las lecturas disparan automatizaciones y lazos de control, así que solo las envían
+	// dispositivos autenticados
+	router.POST("/sensores", deviceHandler.DeviceAuthMiddleware(), sensorHandler.CreateSensorData)
 
 	authorized := router.Group("/api")
 	authorized.Use(authHandler.AuthMiddleware())
 	{
+		authorized.GET("/me", authHandler.GetProfile)
+		authorized.PUT("/me/preferences", authHandler.UpdatePreferences)
+
 		authorized.GET("/sensors", sensorHandler.GetAllSensorData)
 		authorized.GET("/sensors/latest", sensorHandler.GetLatestSensorData)
 		authorized.GET("/sensors/alerts", sensorHandler.GetAlerts)
+		authorized.GET("/sensors/alerts/stats", sensorHandler.GetAlertStats)
+		authorized.PUT("/sensors/alerts/:id/read", sensorHandler.MarkAlertAsRead)
+		authorized.PUT("/sensors/alerts/:id/acknowledge", sensorHandler.AcknowledgeAlert)
+		authorized.PUT("/sensors/alerts/:id/resolve", sensorHandler.ResolveAlert)
+
+		authorized.POST("/webhooks", webhookHandler.CreateWebhook)
+		authorized.GET("/webhooks", webhookHandler.GetWebhooks)
+		authorized.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		authorized.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+		authorized.POST("/webhooks/deliveries/:id/redeliver", webhookHandler.Redeliver)
+
+		authorized.GET("/notifications/preferences", notificationHandler.GetPreferences)
+		authorized.PUT("/notifications/preferences/:channel", notificationHandler.UpdatePreference)
+
+		authorized.GET("/push/vapid-public-key", pushHandler.GetVAPIDPublicKey)
+		authorized.POST("/push/subscriptions", pushHandler.Subscribe)
+		authorized.DELETE("/push/subscriptions", pushHandler.Unsubscribe)
+
+		authorized.POST("/silences", silenceHandler.CreateSilence)
+		authorized.GET("/silences", silenceHandler.GetSilences)
+		authorized.DELETE("/silences/:id", silenceHandler.ExpireSilence)
+		authorized.POST("/maintenance-windows", silenceHandler.CreateMaintenanceWindow)
+		authorized.GET("/maintenance-windows", silenceHandler.GetMaintenanceWindows)
+		authorized.DELETE("/maintenance-windows/:id", silenceHandler.DeleteMaintenanceWindow)
+
+		authorized.GET("/digests/subscription", digestHandler.GetSubscription)
+		authorized.PUT("/digests/subscription", digestHandler.UpdateSubscription)
+		authorized.DELETE("/digests/subscription", digestHandler.DeleteSubscription)
+		authorized.GET("/digests/preview", digestHandler.PreviewDigest)
+
+		authorized.GET("/messages/templates/:locale", messageHandler.GetTemplates)
+
+		authorized.GET("/devices", deviceHandler.GetDevices)
+		authorized.GET("/devices/:id", deviceHandler.GetDevice)
+		authorized.PUT("/devices/:id", deviceHandler.UpdateDevice)
+		authorized.GET("/devices/:id/presence", presenceHandler.GetDevicePresence)
+		authorized.GET("/devices/:id/twin", deviceTwinHandler.GetTwin)
+		authorized.PUT("/devices/:id/twin/desired", deviceTwinHandler.UpdateDesired)
+		authorized.GET("/system/status", presenceHandler.GetSystemStatus)
+
+		authorized.POST("/gardens", gardenHandler.CreateGarden)
+		authorized.GET("/gardens", gardenHandler.GetGardens)
+		authorized.GET("/gardens/:id", gardenHandler.GetGarden)
+		authorized.PUT("/gardens/:id", gardenHandler.UpdateGarden)
+		authorized.GET("/gardens/:id/members", gardenHandler.GetMembers)
+		authorized.POST("/gardens/:id/members", gardenHandler.AddMember)
+		authorized.DELETE("/gardens/:id/members/:userId", gardenHandler.RemoveMember)
+		authorized.PUT("/gardens/:id/devices/:deviceId", gardenHandler.AssignDevice)
+		authorized.DELETE("/gardens/:id/devices/:deviceId", gardenHandler.UnassignDevice)
+
+		authorized.GET("/actuators", actuatorHandler.GetActuators)
+		authorized.GET("/actuators/:id", actuatorHandler.GetActuator)
+		authorized.POST("/actuators/:id/commands", actuatorHandler.SendCommand)
+		authorized.GET("/actuators/:id/commands", actuatorHandler.GetCommands)
+
+		authorized.GET("/automations", automationHandler.GetAutomations)
+		authorized.POST("/automations", automationHandler.CreateAutomation)
+		authorized.GET("/automations/:id", automationHandler.GetAutomation)
+		authorized.PUT("/automations/:id", automationHandler.UpdateAutomation)
+		authorized.PUT("/automations/:id/enabled", automationHandler.SetEnabled)
+		authorized.DELETE("/automations/:id", automationHandler.DeleteAutomation)
+		authorized.GET("/automations/:id/executions", automationHandler.GetExecutions)
+
+		authorized.GET("/schedules", scheduleHandler.GetSchedules)
+		authorized.POST("/schedules", scheduleHandler.CreateSchedule)
+		authorized.GET("/schedules/:id", scheduleHandler.GetSchedule)
+		authorized.PUT("/schedules/:id", scheduleHandler.UpdateSchedule)
+		authorized.PUT("/schedules/:id/enabled", scheduleHandler.SetEnabled)
+		authorized.DELETE("/schedules/:id", scheduleHandler.DeleteSchedule)
+		authorized.GET("/schedules/:id/runs", scheduleHandler.GetRuns)
+		authorized.GET("/schedules/:id/preview", scheduleHandler.Preview)
+
+		authorized.GET("/control-loops", controlLoopHandler.GetControlLoops)
+		authorized.POST("/control-loops", controlLoopHandler.CreateControlLoop)
+		authorized.GET("/control-loops/:id", controlLoopHandler.GetControlLoop)
+		authorized.PUT("/control-loops/:id", controlLoopHandler.UpdateControlLoop)
+		authorized.PUT("/control-loops/:id/tuning", controlLoopHandler.TuneControlLoop)
+		authorized.PUT("/control-loops/:id/enabled", controlLoopHandler.SetEnabled)
+		authorized.DELETE("/control-loops/:id", controlLoopHandler.DeleteControlLoop)
+		authorized.GET("/control-loops/:id/samples", controlLoopHandler.GetSamples)
+	}
+
+	admin := authorized.Group("")
+	admin.Use(authHandler.AdminMiddleware())
+	{
+		admin.PUT("/messages/templates/:locale/:key", messageHandler.UpdateTemplate)
+		admin.DELETE("/messages/templates/:locale/:key", messageHandler.ResetTemplate)
+
+		admin.GET("/ws/metrics", wsHandler.GetMetrics)
+
+		admin.PUT("/users/:id/role", authHandler.SetUserRole)
+
+		admin.POST("/devices/:id/token", deviceHandler.GenerateToken)
+
+		admin.POST("/actuators", actuatorHandler.CreateActuator)
+		admin.DELETE("/actuators/:id", actuatorHandler.DeleteActuator)
+	}
+
+	// Rutas de los dispositivos, autenticados con su ID y token
+	deviceAPI := router.Group("/api/device")
+	deviceAPI.Use(deviceHandler.DeviceAuthMiddleware())
+	{
+		deviceAPI.GET("/commands", actuatorHandler.GetPendingCommands)
+		deviceAPI.POST("/commands/:id/ack", actuatorHandler.AcknowledgeCommand)
+		deviceAPI.GET("/twin", deviceTwinHandler.GetPendingDelta)
+		deviceAPI.PUT("/twin/reported", deviceTwinHandler.ReportConfig)
 	}
 
 	srv := &http.Server{
@@ -92,11 +328,41 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
 	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+
+	// Detener el servidor WebSocket y las tareas periódicas; los clientes reciben un cierre
+	// "going away" y los streams SSE terminan, lo que permite cerrar el servidor HTTP
+	stop()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+	}
+	if err := waitGroup(shutdownCtx, &workers); err != nil {
+		log.Printf("Background workers did not stop in time: %v", err)
+	}
+
+	// Esperar a las notificaciones en curso de las alertas ya guardadas
+	for _, notifier := range notifiers {
+		if err := notifier.Drain(shutdownCtx); err != nil {
+			log.Printf("Pending notifications were not delivered: %v", err)
+		}
 	}
 
 	log.Println("Server exited properly")
 }
+
+// waitGroup espera a que termine el grupo o a que venza el contexto
+func waitGroup(ctx context.Context, wg *sync.WaitGroup) error {
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -54,6 +54,8 @@ func initTables(db *sql.DB) error {
 			username VARCHAR(100) NOT NULL,
 			email VARCHAR(100) NOT NULL UNIQUE,
 			password VARCHAR(255) NOT NULL,
+			role VARCHAR(20) NOT NULL DEFAULT 'user',
+			locale VARCHAR(10) NOT NULL DEFAULT '',
 			created_at DATETIME NOT NULL,
 			updated_at DATETIME NOT NULL,
 			INDEX (email)
@@ -67,11 +69,13 @@ func initTables(db *sql.DB) error {
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS sensor_data (
 			id INT AUTO_INCREMENT PRIMARY KEY,
+			device_id VARCHAR(64) NOT NULL DEFAULT 'default',
 			temperatura_dht FLOAT NOT NULL,
 			luz FLOAT NOT NULL,
 			humedad FLOAT NOT NULL,
 			humo FLOAT NOT NULL,
 			created_at DATETIME NOT NULL,
+			INDEX (device_id),
 			INDEX (created_at)
 		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 	`)
@@ -83,14 +87,26 @@ func initTables(db *sql.DB) error {
 	_, err = db.Exec(`
 		CREATE TABLE IF NOT EXISTS alerts (
 			id INT AUTO_INCREMENT PRIMARY KEY,
-			sensor_id INT NOT NULL,
+			sensor_id INT NULL,
+			device_id VARCHAR(64) NOT NULL DEFAULT 'default',
 			sensor_type VARCHAR(20) NOT NULL,
+			rule VARCHAR(50) NOT NULL DEFAULT '',
+			severity VARCHAR(20) NOT NULL DEFAULT 'warning',
+			state VARCHAR(20) NOT NULL DEFAULT 'active',
 			value FLOAT NOT NULL,
 			message TEXT NOT NULL,
+			message_key VARCHAR(100) NOT NULL DEFAULT '',
+			message_params TEXT NULL,
 			is_read BOOLEAN NOT NULL DEFAULT FALSE,
+			silenced BOOLEAN NOT NULL DEFAULT FALSE,
 			created_at DATETIME NOT NULL,
+			acknowledged_at DATETIME NULL,
+			resolved_at DATETIME NULL,
 			INDEX (sensor_id),
+			INDEX (device_id),
 			INDEX (sensor_type),
+			INDEX (severity),
+			INDEX (state),
 			INDEX (is_read),
 			INDEX (created_at),
 			FOREIGN KEY (sensor_id) REFERENCES sensor_data(id) ON DELETE CASCADE
@@ -100,5 +116,575 @@ func initTables(db *sql.DB) error {
 		return err
 	}
 
+	// Tabla de webhooks
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS webhooks (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL DEFAULT 0,
+			url VARCHAR(500) NOT NULL,
+			event_types VARCHAR(255) NOT NULL,
+			secret VARCHAR(255) NOT NULL,
+			active BOOLEAN NOT NULL DEFAULT TRUE,
+			created_at DATETIME NOT NULL,
+			INDEX (user_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tabla de intentos de entrega de webhooks
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS webhook_deliveries (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			webhook_id INT NOT NULL,
+			event_type VARCHAR(50) NOT NULL,
+			payload MEDIUMTEXT NOT NULL,
+			attempt INT NOT NULL,
+			status_code INT NOT NULL DEFAULT 0,
+			error TEXT NOT NULL,
+			success BOOLEAN NOT NULL DEFAULT FALSE,
+			duration_ms BIGINT NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			INDEX (webhook_id),
+			INDEX (created_at),
+			FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tabla de preferencias de notificación por usuario y canal
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS notification_preferences (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL,
+			channel VARCHAR(20) NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT FALSE,
+			min_severity VARCHAR(20) NOT NULL DEFAULT 'warning',
+			sensor_types VARCHAR(255) NOT NULL DEFAULT '',
+			updated_at DATETIME NOT NULL,
+			UNIQUE KEY (user_id, channel),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tabla de suscripciones Web Push
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS push_subscriptions (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL,
+			endpoint VARCHAR(500) NOT NULL,
+			p256dh VARCHAR(255) NOT NULL,
+			auth VARCHAR(255) NOT NULL,
+			created_at DATETIME NOT NULL,
+			UNIQUE KEY (endpoint),
+			INDEX (user_id),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tabla con el par de claves VAPID del servidor
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS vapid_keys (
+			id INT PRIMARY KEY,
+			public_key VARCHAR(255) NOT NULL,
+			private_key VARCHAR(255) NOT NULL,
+			created_at DATETIME NOT NULL
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tabla de silencios de alertas
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS silences (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			device_id VARCHAR(64) NOT NULL DEFAULT '',
+			sensor_type VARCHAR(20) NOT NULL DEFAULT '',
+			rule VARCHAR(50) NOT NULL DEFAULT '',
+			starts_at DATETIME NOT NULL,
+			ends_at DATETIME NOT NULL,
+			comment TEXT NOT NULL,
+			created_by INT NOT NULL,
+			created_at DATETIME NOT NULL,
+			INDEX (starts_at, ends_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tabla de ventanas de mantenimiento recurrentes
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS maintenance_windows (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			device_id VARCHAR(64) NOT NULL DEFAULT '',
+			sensor_type VARCHAR(20) NOT NULL DEFAULT '',
+			rule VARCHAR(50) NOT NULL DEFAULT '',
+			days_of_week VARCHAR(20) NOT NULL DEFAULT '',
+			start_time CHAR(5) NOT NULL,
+			duration_minutes INT NOT NULL,
+			timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+			created_by INT NOT NULL,
+			created_at DATETIME NOT NULL
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tabla de suscripciones a resúmenes periódicos
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS digest_subscriptions (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			user_id INT NOT NULL,
+			frequency VARCHAR(10) NOT NULL,
+			time_of_day CHAR(5) NOT NULL,
+			weekday TINYINT NOT NULL DEFAULT 0,
+			timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+			channels VARCHAR(50) NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			last_sent_at DATETIME NULL,
+			next_run_at DATETIME NOT NULL,
+			UNIQUE KEY (user_id),
+			INDEX (next_run_at),
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tabla de plantillas de mensajes modificadas por administradores
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS message_templates (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			locale VARCHAR(10) NOT NULL,
+			message_key VARCHAR(100) NOT NULL,
+			template TEXT NOT NULL,
+			updated_by INT NOT NULL,
+			updated_at DATETIME NOT NULL,
+			UNIQUE KEY (locale, message_key)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tabla de dispositivos y su último contacto
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS devices (
+			id VARCHAR(64) PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			garden_id INT NULL,
+			expected_interval_seconds INT NOT NULL,
+			token_hash CHAR(64) NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'online',
+			last_seen_at DATETIME NULL,
+			last_reading_id INT NOT NULL DEFAULT 0,
+			presence_online BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at DATETIME NOT NULL,
+			INDEX (garden_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de jardines
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS gardens (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			owner_id INT NOT NULL,
+			timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+			latitude DOUBLE NULL,
+			longitude DOUBLE NULL,
+			created_at DATETIME NOT NULL,
+			INDEX (owner_id),
+			FOREIGN KEY (owner_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de miembros de jardines
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS garden_members (
+			garden_id INT NOT NULL,
+			user_id INT NOT NULL,
+			role VARCHAR(20) NOT NULL DEFAULT 'member',
+			created_at DATETIME NOT NULL,
+			PRIMARY KEY (garden_id, user_id),
+			INDEX (user_id),
+			FOREIGN KEY (garden_id) REFERENCES gardens(id) ON DELETE CASCADE,
+			FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de lecturas de una sola métrica recibidas por WebSocket
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS metric_readings (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			device_id VARCHAR(64) NOT NULL,
+			sensor_type VARCHAR(20) NOT NULL,
+			value FLOAT NOT NULL,
+			unit VARCHAR(20) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			device_time DATETIME NULL,
+			INDEX (device_id, sensor_type, created_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de las conexiones en tiempo real abiertas en cada instancia de la API
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS device_connections (
+			device_id VARCHAR(64) NOT NULL,
+			instance_id VARCHAR(32) NOT NULL,
+			connections INT NOT NULL,
+			updated_at DATETIME NOT NULL,
+			PRIMARY KEY (device_id, instance_id),
+			INDEX (instance_id),
+			INDEX (updated_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla del historial de presencia de los dispositivos
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS device_presence (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			device_id VARCHAR(64) NOT NULL,
+			event VARCHAR(20) NOT NULL,
+			transport VARCHAR(20) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			INDEX (device_id, created_at)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de actuadores
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS actuators (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			device_id VARCHAR(64) NOT NULL,
+			name VARCHAR(100) NOT NULL,
+			type VARCHAR(20) NOT NULL,
+			channel INT NOT NULL,
+			state VARCHAR(10) NOT NULL,
+			duty_cycle INT NOT NULL DEFAULT 0,
+			state_until DATETIME NULL,
+			updated_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL,
+			UNIQUE KEY (device_id, channel),
+			INDEX (state_until)
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de comandos de actuadores
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS actuator_commands (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			actuator_id INT NOT NULL,
+			device_id VARCHAR(64) NOT NULL,
+			channel INT NOT NULL,
+			action VARCHAR(20) NOT NULL,
+			duty_cycle INT NULL,
+			duration_seconds INT NULL,
+			status VARCHAR(20) NOT NULL,
+			error VARCHAR(255) NOT NULL DEFAULT '',
+			issued_by INT NOT NULL,
+			created_at DATETIME NOT NULL,
+			sent_at DATETIME NULL,
+			acked_at DATETIME NULL,
+			expires_at DATETIME NOT NULL,
+			INDEX (actuator_id, id),
+			INDEX (device_id, status),
+			INDEX (status, expires_at),
+			FOREIGN KEY (actuator_id) REFERENCES actuators(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de automatizaciones
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS automations (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			device_id VARCHAR(64) NOT NULL,
+			sensor_type VARCHAR(32) NOT NULL,
+			operator VARCHAR(8) NOT NULL,
+			threshold FLOAT NOT NULL,
+			for_seconds INT NOT NULL DEFAULT 0,
+			actuator_id INT NOT NULL,
+			action VARCHAR(20) NOT NULL,
+			duty_cycle INT NULL,
+			duration_seconds INT NULL,
+			cooldown_seconds INT NOT NULL DEFAULT 0,
+			max_per_day INT NOT NULL DEFAULT 0,
+			condition_since DATETIME NULL,
+			last_triggered_at DATETIME NULL,
+			created_by INT NOT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			INDEX (device_id, enabled),
+			FOREIGN KEY (actuator_id) REFERENCES actuators(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla del historial de ejecuciones de las automatizaciones
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS automation_executions (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			automation_id INT NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			value FLOAT NOT NULL,
+			command_id INT NULL,
+			error VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			INDEX (automation_id, created_at),
+			FOREIGN KEY (automation_id) REFERENCES automations(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de programaciones
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS schedules (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			kind VARCHAR(10) NOT NULL,
+			cron VARCHAR(100) NOT NULL DEFAULT '',
+			sun_event VARCHAR(10) NOT NULL DEFAULT '',
+			offset_minutes INT NOT NULL DEFAULT 0,
+			actuator_id INT NOT NULL,
+			action VARCHAR(20) NOT NULL,
+			duty_cycle INT NULL,
+			duration_seconds INT NULL,
+			skip_device_id VARCHAR(64) NULL,
+			skip_sensor_type VARCHAR(32) NULL,
+			skip_operator VARCHAR(8) NULL,
+			skip_threshold FLOAT NULL,
+			next_run_at DATETIME NULL,
+			last_run_at DATETIME NULL,
+			created_by INT NOT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			INDEX (enabled, next_run_at),
+			FOREIGN KEY (actuator_id) REFERENCES actuators(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla del historial de ejecuciones de las programaciones
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS schedule_runs (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			schedule_id INT NOT NULL,
+			status VARCHAR(20) NOT NULL,
+			scheduled_for DATETIME NOT NULL,
+			command_id INT NULL,
+			reason VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			INDEX (schedule_id, id),
+			FOREIGN KEY (schedule_id) REFERENCES schedules(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de lazos de control
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS control_loops (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(100) NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			device_id VARCHAR(64) NOT NULL,
+			sensor_type VARCHAR(32) NOT NULL,
+			setpoint DOUBLE NOT NULL,
+			kp DOUBLE NOT NULL,
+			ki DOUBLE NOT NULL,
+			kd DOUBLE NOT NULL,
+			reverse_acting BOOLEAN NOT NULL DEFAULT FALSE,
+			actuator_id INT NOT NULL,
+			output_min DOUBLE NOT NULL,
+			output_max DOUBLE NOT NULL,
+			sample_seconds INT NOT NULL,
+			integral DOUBLE NOT NULL DEFAULT 0,
+			last_input DOUBLE NULL,
+			last_input_at DATETIME NULL,
+			last_output INT NULL,
+			last_command_id INT NULL,
+			last_update_at DATETIME NULL,
+			created_by INT NOT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL,
+			INDEX (enabled),
+			FOREIGN KEY (actuator_id) REFERENCES actuators(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla del registro de los lazos de control
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS control_loop_samples (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			loop_id INT NOT NULL,
+			input_value DOUBLE NOT NULL,
+			setpoint DOUBLE NOT NULL,
+			error DOUBLE NOT NULL,
+			p_term DOUBLE NOT NULL,
+			i_term DOUBLE NOT NULL,
+			d_term DOUBLE NOT NULL,
+			output_value DOUBLE NOT NULL,
+			duty_cycle INT NOT NULL,
+			command_id INT NULL,
+			command_error VARCHAR(255) NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			INDEX (loop_id, id),
+			FOREIGN KEY (loop_id) REFERENCES control_loops(id) ON DELETE CASCADE
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Crear tabla de la configuración deseada y comunicada de los dispositivos
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS device_twins (
+			device_id VARCHAR(64) PRIMARY KEY,
+			desired TEXT NOT NULL,
+			desired_version INT NOT NULL DEFAULT 0,
+			desired_updated_at DATETIME NULL,
+			reported TEXT NOT NULL,
+			reported_version INT NOT NULL DEFAULT 0,
+			applied_version INT NOT NULL DEFAULT 0,
+			reported_updated_at DATETIME NULL
+		) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+	`)
+	if err != nil {
+		return err
+	}
+
+	return migrateTables(db)
+}
+
+// Columna añadida a una tabla existente
+type columnMigration struct {
+	table      string
+	column     string
+	definition string
+}
+
+// Columnas agregadas después de la creación inicial de las tablas
+var columnMigrations = []columnMigration{
+	{"sensor_data", "device_id", "VARCHAR(64) NOT NULL DEFAULT 'default' AFTER id, ADD INDEX (device_id)"},
+	{"alerts", "device_id", "VARCHAR(64) NOT NULL DEFAULT 'default' AFTER sensor_id, ADD INDEX (device_id)"},
+	{"alerts", "severity", "VARCHAR(20) NOT NULL DEFAULT 'warning' AFTER sensor_type, ADD INDEX (severity)"},
+	{"alerts", "state", "VARCHAR(20) NOT NULL DEFAULT 'active' AFTER severity, ADD INDEX (state)"},
+	{"alerts", "acknowledged_at", "DATETIME NULL"},
+	{"alerts", "resolved_at", "DATETIME NULL"},
+	{"alerts", "rule", "VARCHAR(50) NOT NULL DEFAULT '' AFTER sensor_type"},
+	{"alerts", "silenced", "BOOLEAN NOT NULL DEFAULT FALSE AFTER is_read"},
+	{"alerts", "message_key", "VARCHAR(100) NOT NULL DEFAULT '' AFTER message"},
+	{"alerts", "message_params", "TEXT NULL AFTER message_key"},
+	// Los webhooks anteriores quedan sin propietario y dejan de recibir eventos
+	{"webhooks", "user_id", "INT NOT NULL DEFAULT 0 AFTER id, ADD INDEX (user_id)"},
+	{"users", "role", "VARCHAR(20) NOT NULL DEFAULT 'user' AFTER password"},
+	{"users", "locale", "VARCHAR(10) NOT NULL DEFAULT '' AFTER role"},
+	{"devices", "garden_id", "INT NULL AFTER name, ADD INDEX (garden_id)"},
+	{"devices", "token_hash", "CHAR(64) NULL AFTER expected_interval_seconds"},
+	{"gardens", "timezone", "VARCHAR(64) NOT NULL DEFAULT 'UTC' AFTER owner_id"},
+	{"gardens", "latitude", "DOUBLE NULL AFTER timezone"},
+	{"gardens", "longitude", "DOUBLE NULL AFTER latitude"},
+	{"metric_readings", "device_time", "DATETIME NULL AFTER created_at"},
+	{"control_loops", "last_command_id", "INT NULL AFTER last_output"},
+	{"devices", "presence_online", "BOOLEAN NOT NULL DEFAULT FALSE AFTER last_reading_id"},
+}
+
+// Columnas que pasaron a admitir NULL
+var nullableMigrations = []columnMigration{
+	// Las alertas recibidas por WebSocket no tienen lectura en sensor_data
+	{"alerts", "sensor_id", "INT NULL"},
+}
+
+// Actualizar tablas creadas por versiones anteriores
+func migrateTables(db *sql.DB) error {
+	for _, m := range columnMigrations {
+		var count int
+		err := db.QueryRow(`
+			SELECT COUNT(*) 
+			FROM information_schema.COLUMNS 
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+		`, m.table, m.column).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("migrando %s.%s: %w", m.table, m.column, err)
+		}
+	}
+
+	for _, m := range nullableMigrations {
+		var nullable string
+		err := db.QueryRow(`
+			SELECT IS_NULLABLE 
+			FROM information_schema.COLUMNS 
+			WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+		`, m.table, m.column).Scan(&nullable)
+		if err != nil {
+			return err
+		}
+		if nullable == "YES" {
+			continue
+		}
+
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("migrando %s.%s: %w", m.table, m.column, err)
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,162 @@
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Configuración del servidor SMTP
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	StartTLS bool
+}
+
+// Message es un correo con versión en texto plano y HTML
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer envía correos a través de un servidor SMTP
+type Mailer struct {
+	config SMTPConfig
+}
+
+func NewMailer(config SMTPConfig) *Mailer {
+	return &Mailer{
+		config: config,
+	}
+}
+
+// Send entrega el mensaje como multipart/alternative con partes de texto y HTML
+func (m *Mailer) Send(msg Message) error {
+	if len(msg.To) == 0 {
+		return errors.New("el mensaje no tiene destinatarios")
+	}
+
+	body, err := m.buildMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	// Cifrar la conexión con STARTTLS si está habilitado
+	if m.config.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("el servidor SMTP no soporta STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return err
+		}
+	}
+
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(m.config.From); err != nil {
+		return err
+	}
+	for _, to := range msg.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func (m *Mailer) buildMessage(msg Message) ([]byte, error) {
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", m.config.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	}
+
+	for _, part := range parts {
+		if part.content == "" {
+			continue
+		}
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=UTF-8\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+func randomBoundary() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
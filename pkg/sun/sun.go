@@ -0,0 +1,68 @@
+// Package sun calcula la salida y la puesta del sol con la ecuación de la NOAA, con
+// una precisión de alrededor de un minuto
+package sun
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// Día juliano de la época J2000
+	j2000 = 2451545.0
+	// Día juliano de la época Unix
+	unixEpochJulian = 2440587.5
+	// Altura del centro del sol en la salida y la puesta, con la refracción atmosférica
+	horizonDegrees = -0.833
+	// Inclinación del eje de la Tierra
+	obliquityDegrees = 23.4397
+)
+
+// Times devuelve la salida y la puesta del sol del día de date, en su zona horaria, para
+// la latitud y longitud indicadas (este positivo). ok es false si ese día el sol no sale
+// o no se pone
+func Times(date time.Time, latitude, longitude float64) (sunrise, sunset time.Time, ok bool) {
+	loc := date.Location()
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, loc)
+
+	// Mediodía solar medio del día en la longitud dada
+	n := math.Round(julianDay(noon) - j2000 + longitude/360)
+	meanNoon := n - longitude/360
+
+	anomaly := math.Mod(357.5291+0.98560028*meanNoon, 360)
+	m := radians(anomaly)
+	center := 1.9148*math.Sin(m) + 0.02*math.Sin(2*m) + 0.0003*math.Sin(3*m)
+	eclipticLongitude := radians(math.Mod(anomaly+center+180+102.9372, 360))
+	transit := j2000 + meanNoon + 0.0053*math.Sin(m) - 0.0069*math.Sin(2*eclipticLongitude)
+
+	sinDeclination := math.Sin(eclipticLongitude) * math.Sin(radians(obliquityDegrees))
+	cosDeclination := math.Cos(math.Asin(sinDeclination))
+	phi := radians(latitude)
+
+	cosHourAngle := (math.Sin(radians(horizonDegrees)) - math.Sin(phi)*sinDeclination) / (math.Cos(phi) * cosDeclination)
+	if cosHourAngle < -1 || cosHourAngle > 1 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	hourAngle := degrees(math.Acos(cosHourAngle)) / 360
+	sunrise = fromJulianDay(transit - hourAngle).In(loc)
+	sunset = fromJulianDay(transit + hourAngle).In(loc)
+
+	return sunrise, sunset, true
+}
+
+func julianDay(t time.Time) float64 {
+	return float64(t.UnixNano())/float64(24*time.Hour) + unixEpochJulian
+}
+
+func fromJulianDay(jd float64) time.Time {
+	return time.Unix(0, int64((jd-unixEpochJulian)*float64(24*time.Hour))).Round(time.Second)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -0,0 +1,240 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/crypto/hkdf"
+)
+
+// Tamaño de registro declarado en la cabecera aes128gcm (RFC 8188)
+const recordSize = 4096
+
+// Validez del JWT de VAPID; RFC 8292 permite como máximo 24 horas
+const vapidTokenTTL = 12 * time.Hour
+
+// Claves de aplicación VAPID codificadas en base64url sin relleno
+type VAPIDKeys struct {
+	PublicKey  string
+	PrivateKey string
+}
+
+// Suscripción del navegador a la que se envía el mensaje
+type Subscription struct {
+	Endpoint string
+	P256dh   string
+	Auth     string
+}
+
+// Opciones de envío de un mensaje
+type Options struct {
+	Subject string // URL mailto: o https: de contacto del servidor de aplicaciones
+	TTL     int    // Segundos que el servicio de push conserva el mensaje
+	Urgency string // very-low, low, normal o high
+}
+
+// GenerateVAPIDKeys crea un nuevo par de claves P-256 para VAPID
+func GenerateVAPIDKeys() (*VAPIDKeys, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := key.PublicKey.ECDH()
+	if err != nil {
+		return nil, err
+	}
+
+	return &VAPIDKeys{
+		PublicKey:  base64.RawURLEncoding.EncodeToString(publicKey.Bytes()),
+		PrivateKey: base64.RawURLEncoding.EncodeToString(key.D.FillBytes(make([]byte, 32))),
+	}, nil
+}
+
+// Send cifra el payload para la suscripción y lo entrega al servicio de push
+func Send(client *http.Client, sub Subscription, payload []byte, keys VAPIDKeys, opts Options) (*http.Response, error) {
+	body, err := Encrypt(sub, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	authorization, err := vapidAuthorization(sub.Endpoint, keys, opts.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(opts.TTL))
+	req.Header.Set("Authorization", authorization)
+	if opts.Urgency != "" {
+		req.Header.Set("Urgency", opts.Urgency)
+	}
+
+	return client.Do(req)
+}
+
+// Encrypt cifra el payload según RFC 8291 con la codificación aes128gcm de RFC 8188
+func Encrypt(sub Subscription, payload []byte) ([]byte, error) {
+	// Par de claves efímero del servidor de aplicaciones y salt de un solo uso
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	return encrypt(sub, payload, asPrivate, salt)
+}
+
+// encrypt cifra con la clave efímera y el salt indicados
+func encrypt(sub Subscription, payload []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	uaPublicBytes, err := decodeBase64(sub.P256dh)
+	if err != nil {
+		return nil, fmt.Errorf("clave p256dh inválida: %w", err)
+	}
+	authSecret, err := decodeBase64(sub.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("secreto auth inválido: %w", err)
+	}
+
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, fmt.Errorf("clave p256dh inválida: %w", err)
+	}
+
+	asPublicBytes := asPrivate.PublicKey().Bytes()
+
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicBytes...)
+	keyInfo = append(keyInfo, asPublicBytes...)
+	ikm, err := hkdfBytes(ecdhSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	cek, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un único registro: el delimitador 0x02 marca el último registro
+	if len(payload)+1+gcm.Overhead() > recordSize {
+		return nil, errors.New("payload demasiado grande para un mensaje push")
+	}
+	plaintext := append(append([]byte{}, payload...), 0x02)
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	// Cabecera: salt || rs || idlen || keyid
+	header := make([]byte, 0, 16+4+1+len(asPublicBytes))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublicBytes)))
+	header = append(header, asPublicBytes...)
+
+	return append(header, ciphertext...), nil
+}
+
+// vapidAuthorization construye la cabecera Authorization de RFC 8292
+func vapidAuthorization(endpoint string, keys VAPIDKeys, subject string) (string, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	privateKey, err := parsePrivateKey(keys)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpointURL.Scheme + "://" + endpointURL.Host,
+		"exp": time.Now().Add(vapidTokenTTL).Unix(),
+		"sub": subject,
+	})
+
+	signed, err := token.SignedString(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("vapid t=%s, k=%s", signed, keys.PublicKey), nil
+}
+
+// parsePrivateKey reconstruye la clave ECDSA a partir de las claves VAPID en crudo
+func parsePrivateKey(keys VAPIDKeys) (*ecdsa.PrivateKey, error) {
+	publicBytes, err := decodeBase64(keys.PublicKey)
+	if err != nil || len(publicBytes) != 65 || publicBytes[0] != 0x04 {
+		return nil, errors.New("clave pública VAPID inválida")
+	}
+	privateBytes, err := decodeBase64(keys.PrivateKey)
+	if err != nil || len(privateBytes) != 32 {
+		return nil, errors.New("clave privada VAPID inválida")
+	}
+
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(publicBytes[1:33]),
+			Y:     new(big.Int).SetBytes(publicBytes[33:]),
+		},
+		D: new(big.Int).SetBytes(privateBytes),
+	}, nil
+}
+
+func hkdfBytes(secret, salt, info []byte, length int) ([]byte, error) {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// decodeBase64 acepta base64url con o sin relleno, como lo entregan los navegadores
+func decodeBase64(value string) ([]byte, error) {
+	if decoded, err := base64.RawURLEncoding.DecodeString(value); err == nil {
+		return decoded, nil
+	}
+	return base64.URLEncoding.DecodeString(value)
+}
@@ -0,0 +1,223 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/binary"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Vector de prueba de RFC 8291, apéndice A
+const (
+	rfcPlaintext  = "V2hlbiBJIGdyb3cgdXAsIEkgd2FudCB0byBiZSBhIHdhdGVybWVsb24"
+	rfcASPrivate  = "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"
+	rfcASPublic   = "BP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A8"
+	rfcUAPrivate  = "q1dXpw3UpT5VOmu_cf_v6ih07Aems3njxI-JWgLcM94"
+	rfcUAPublic   = "BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4"
+	rfcSalt       = "DGv6ra1nlYgDCS1FRnbzlw"
+	rfcAuthSecret = "BTBZMqHH6r4Tts7J_aSIgg"
+	rfcECDHSecret = "kyrL1jIIOHEzg3sM2ZWRHDRB62YACZhhSlknJ672kSs"
+	rfcKeyInfo    = "V2ViUHVzaDogaW5mbwAEJXGyvs3942BVGq8e0PTNNmwRzr5VX4m8t7GGpTM5FzFo7OLr4BhZe9MEebhuPI-OztV3ylkYfpJGmQ22ggCLDgT-M_SrDepxkU21WCP3O1SUj0EwbZIHMtu5pZpTKGSCIA5Zent7wmC6HCJ5mFgJkuk5cwAvMBKiiujwa7t45ewP"
+	rfcIKM        = "S4lYMb_L0FxCeq0WhDx813KgSYqU26kOyzWUdsXYyrg"
+	rfcCEK        = "oIhVW04MRdy2XN9CiKLxTg"
+	rfcNonce      = "4h_95klXJ5E_qnoN"
+	rfcBody       = "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+)
+
+func mustDecode(t *testing.T, value string) []byte {
+	t.Helper()
+	decoded, err := decodeBase64(value)
+	if err != nil {
+		t.Fatalf("base64 inválido %q: %v", value, err)
+	}
+	return decoded
+}
+
+func mustPrivateKey(t *testing.T, value string) *ecdh.PrivateKey {
+	t.Helper()
+	key, err := ecdh.P256().NewPrivateKey(mustDecode(t, value))
+	if err != nil {
+		t.Fatalf("clave privada inválida: %v", err)
+	}
+	return key
+}
+
+func TestEncryptRFC8291Vector(t *testing.T) {
+	asPrivate := mustPrivateKey(t, rfcASPrivate)
+	if got := base64.RawURLEncoding.EncodeToString(asPrivate.PublicKey().Bytes()); got != rfcASPublic {
+		t.Fatalf("clave pública del servidor = %s, se esperaba %s", got, rfcASPublic)
+	}
+
+	sub := Subscription{Endpoint: "https://push.example.net/push/JzLQ3raZJfFBR0aqvOMsLrt54w4rJUsV", P256dh: rfcUAPublic, Auth: rfcAuthSecret}
+	body, err := encrypt(sub, mustDecode(t, rfcPlaintext), asPrivate, mustDecode(t, rfcSalt))
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+
+	if got := base64.RawURLEncoding.EncodeToString(body); got != rfcBody {
+		t.Errorf("cuerpo cifrado =\n%s\nse esperaba\n%s", got, rfcBody)
+	}
+}
+
+// TestEncryptRFC8291Steps comprueba por separado cada paso de la derivación del vector
+func TestEncryptRFC8291Steps(t *testing.T) {
+	uaPrivate := mustPrivateKey(t, rfcUAPrivate)
+	asPublic, err := ecdh.P256().NewPublicKey(mustDecode(t, rfcASPublic))
+	if err != nil {
+		t.Fatalf("clave pública del servidor inválida: %v", err)
+	}
+
+	ecdhSecret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		t.Fatalf("ECDH: %v", err)
+	}
+	if !bytes.Equal(ecdhSecret, mustDecode(t, rfcECDHSecret)) {
+		t.Errorf("ecdh_secret = %x", ecdhSecret)
+	}
+
+	keyInfo := append([]byte("WebPush: info\x00"), mustDecode(t, rfcUAPublic)...)
+	keyInfo = append(keyInfo, mustDecode(t, rfcASPublic)...)
+	if !bytes.Equal(keyInfo, mustDecode(t, rfcKeyInfo)) {
+		t.Errorf("key_info = %x", keyInfo)
+	}
+
+	ikm, err := hkdfBytes(ecdhSecret, mustDecode(t, rfcAuthSecret), keyInfo, 32)
+	if err != nil || !bytes.Equal(ikm, mustDecode(t, rfcIKM)) {
+		t.Fatalf("ikm = %x, %v", ikm, err)
+	}
+
+	salt := mustDecode(t, rfcSalt)
+	cek, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil || !bytes.Equal(cek, mustDecode(t, rfcCEK)) {
+		t.Errorf("cek = %x, %v", cek, err)
+	}
+	nonce, err := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil || !bytes.Equal(nonce, mustDecode(t, rfcNonce)) {
+		t.Errorf("nonce = %x, %v", nonce, err)
+	}
+}
+
+// TestEncryptRecordLayout descifra como lo haría el navegador a partir de la cabecera aes128gcm
+func TestEncryptRecordLayout(t *testing.T) {
+	uaPrivate := mustPrivateKey(t, rfcUAPrivate)
+	authSecret := mustDecode(t, rfcAuthSecret)
+	payload := []byte(`{"title":"SmartGarden","body":"Humedad baja"}`)
+
+	body, err := Encrypt(Subscription{P256dh: rfcUAPublic, Auth: rfcAuthSecret}, payload)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	// salt (16) || rs (4) || idlen (1) || keyid (65) || registro
+	if len(body) < 86 {
+		t.Fatalf("cuerpo demasiado corto: %d bytes", len(body))
+	}
+	salt := body[:16]
+	if rs := binary.BigEndian.Uint32(body[16:20]); rs != recordSize {
+		t.Errorf("rs = %d, se esperaba %d", rs, recordSize)
+	}
+	if idlen := body[20]; idlen != 65 {
+		t.Fatalf("idlen = %d, se esperaba 65", idlen)
+	}
+	asPublic, err := ecdh.P256().NewPublicKey(body[21:86])
+	if err != nil {
+		t.Fatalf("keyid no es una clave P-256: %v", err)
+	}
+
+	ecdhSecret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		t.Fatalf("ECDH: %v", err)
+	}
+	keyInfo := append([]byte("WebPush: info\x00"), uaPrivate.PublicKey().Bytes()...)
+	keyInfo = append(keyInfo, asPublic.Bytes()...)
+	ikm, _ := hkdfBytes(ecdhSecret, authSecret, keyInfo, 32)
+	cek, _ := hkdfBytes(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce, _ := hkdfBytes(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, nonce, body[86:], nil)
+	if err != nil {
+		t.Fatalf("no se pudo descifrar el registro: %v", err)
+	}
+
+	// Un único registro terminado con el delimitador de último registro
+	want := append(append([]byte{}, payload...), 0x02)
+	if !bytes.Equal(plaintext, want) {
+		t.Errorf("registro descifrado = %q, se esperaba %q", plaintext, want)
+	}
+}
+
+func TestVAPIDAuthorization(t *testing.T) {
+	keys, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatalf("GenerateVAPIDKeys: %v", err)
+	}
+
+	header, err := vapidAuthorization("https://push.example.com:8443/send/abc?x=1", *keys, "mailto:admin@example.com")
+	if err != nil {
+		t.Fatalf("vapidAuthorization: %v", err)
+	}
+
+	// vapid t=<jwt>, k=<clave pública>
+	params := strings.TrimPrefix(header, "vapid ")
+	if params == header {
+		t.Fatalf("cabecera sin esquema vapid: %s", header)
+	}
+	parts := strings.Split(params, ", ")
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], "t=") || !strings.HasPrefix(parts[1], "k=") {
+		t.Fatalf("cabecera con formato inesperado: %s", header)
+	}
+	if k := strings.TrimPrefix(parts[1], "k="); k != keys.PublicKey {
+		t.Errorf("k = %s, se esperaba la clave pública VAPID", k)
+	}
+
+	// La firma se verifica solo con la clave pública anunciada en k
+	publicBytes := mustDecode(t, keys.PublicKey)
+	publicKey := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(publicBytes[1:33]),
+		Y:     new(big.Int).SetBytes(publicBytes[33:]),
+	}
+
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(strings.TrimPrefix(parts[0], "t="), claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodES256 {
+			t.Errorf("algoritmo = %v, se esperaba ES256", token.Header["alg"])
+		}
+		return publicKey, nil
+	})
+	if err != nil || !token.Valid {
+		t.Fatalf("el JWT de VAPID no se verifica: %v", err)
+	}
+
+	if aud := claims["aud"]; aud != "https://push.example.com:8443" {
+		t.Errorf("aud = %v, se esperaba el origen del endpoint", aud)
+	}
+	if sub := claims["sub"]; sub != "mailto:admin@example.com" {
+		t.Errorf("sub = %v", sub)
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		t.Fatalf("exp ausente: %v", claims["exp"])
+	}
+	if remaining := time.Until(time.Unix(int64(exp), 0)); remaining <= 0 || remaining > 24*time.Hour {
+		t.Errorf("exp a %v de ahora, RFC 8292 exige como máximo 24 horas", remaining)
+	}
+}